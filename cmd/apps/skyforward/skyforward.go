@@ -0,0 +1,184 @@
+/*
+port forwarding app for skywire visor
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/skyforward"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/buildinfo"
+)
+
+const (
+	appName = skyenv.SkyforwardName
+	netType = appnet.TypeSkynet
+)
+
+// rulesFlag collects forwarding rules from repeated flags.
+type rulesFlag []skyforward.Rule
+
+func (f *rulesFlag) String() string {
+	rules := make([]string, len(*f))
+	for i, rule := range *f {
+		rules[i] = rule.String()
+	}
+
+	return strings.Join(rules, ", ")
+}
+
+func (f *rulesFlag) Set(s string) error {
+	rule, err := skyforward.ParseRule(s)
+	if err != nil {
+		return err
+	}
+
+	*f = append(*f, rule)
+
+	return nil
+}
+
+func main() {
+	log := app.NewLogger(appName)
+
+	if _, err := buildinfo.Get().WriteTo(log.Writer()); err != nil {
+		log.Printf("Failed to output build info: %v", err)
+	}
+
+	var rules rulesFlag
+
+	flag.Var(&rules, "forward", "Forwarding rule `<listen addr> -> <pk>[:<port>] -> <target addr>`, may be repeated")
+	var port = flag.Uint("port", uint(skyforward.DefaultPort), "Skynet port to accept forwarded connections on")
+	var allowPKs = flag.String("allow-pk", "", "Comma-separated public keys of visors allowed to forward connections, * for any")
+	var allowTargets = flag.String("allow-target", "", "Comma-separated addresses connections may be forwarded to")
+	var statsInterval = flag.Duration("stats-interval", time.Minute, "Interval of reporting byte counts of tunnels")
+	flag.Parse()
+
+	allow, err := skyforward.ParseAllowlist(*allowPKs, *allowTargets)
+	if err != nil {
+		log.Fatalf("Invalid allowlist: %v", err)
+	}
+
+	if len(rules) == 0 && allow.Empty() {
+		log.Warn("No forwarding rules and no allowed visors and targets. Exiting")
+		return
+	}
+
+	config, err := app.ClientConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Error getting client config: %v\n", err)
+	}
+
+	forwardApp, err := app.NewClient(logging.MustGetLogger(fmt.Sprintf("app_%s", appName)), config)
+	if err != nil {
+		log.Fatal("Setup failure: ", err)
+	}
+
+	defer forwardApp.Close()
+
+	var srv *skyforward.Server
+
+	if !allow.Empty() {
+		l, err := forwardApp.Listen(netType, routing.Port(*port))
+		if err != nil {
+			log.Fatalf("Error listening network %v on port %d: %v\n", netType, *port, err)
+		}
+
+		srv = skyforward.NewServer(allow, log.PackageLogger("skyforward_server"))
+
+		go func() {
+			if err := srv.Serve(l); err != nil {
+				log.Fatalf("Error serving forwarded connections: %v", err)
+			}
+		}()
+
+		log.Infof("Accepting forwarded connections on port %d", *port)
+	}
+
+	dial := func(remote cipher.PubKey, port routing.Port) (net.Conn, error) {
+		return forwardApp.Dial(appnet.Addr{Net: netType, PubKey: remote, Port: port})
+	}
+
+	tunnels := make([]*skyforward.Tunnel, len(rules))
+
+	for i, rule := range rules {
+		tun := skyforward.NewTunnel(rule, dial, log.PackageLogger("skyforward_tunnel"))
+		tunnels[i] = tun
+
+		go func(rule skyforward.Rule) {
+			if err := tun.ListenAndServe(); err != nil {
+				log.Fatalf("Error serving tunnel %s: %v", rule, err)
+			}
+		}(rule)
+
+		log.Infof("Forwarding %s", rule)
+	}
+
+	reportStats(log, forwardApp, tunnels, srv)
+
+	termCh := make(chan os.Signal, 1)
+	signal.Notify(termCh, os.Interrupt)
+
+	ticker := time.NewTicker(*statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reportStats(log, forwardApp, tunnels, srv)
+		case <-termCh:
+			for _, tun := range tunnels {
+				if err := tun.Close(); err != nil {
+					log.WithError(err).Errorf("Failed to close tunnel %s", tun.Rule())
+				}
+			}
+
+			if srv != nil {
+				if err := srv.Close(); err != nil {
+					log.WithError(err).Error("Failed to close server")
+				}
+			}
+
+			reportStats(log, forwardApp, tunnels, srv)
+
+			return
+		}
+	}
+}
+
+// reportStats logs byte counts of tunnels and reports them to the visor as the status of the app.
+func reportStats(log *logging.MasterLogger, appCl *app.Client, tunnels []*skyforward.Tunnel, srv *skyforward.Server) {
+	var lines []string
+
+	for _, tun := range tunnels {
+		lines = append(lines, fmt.Sprintf("%s: %s", tun.Rule(), tun.Stats()))
+	}
+
+	if srv != nil {
+		stats := srv.Stats()
+		for _, name := range skyforward.SortedNames(stats) {
+			lines = append(lines, fmt.Sprintf("%s (exit): %s", name, stats[name]))
+		}
+	}
+
+	for _, line := range lines {
+		log.Info(line)
+	}
+
+	if err := appCl.SetStatus(true, strings.Join(lines, "; ")); err != nil {
+		log.WithError(err).Warn("Failed to report status")
+	}
+}
@@ -33,7 +33,7 @@ var lsAppsCmd = &cobra.Command{
 		internal.Catch(err)
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
-		_, err = fmt.Fprintln(w, "app\tports\tauto_start\tstatus")
+		_, err = fmt.Fprintln(w, "app\tports\tauto_start\tstatus\thealth")
 		internal.Catch(err)
 
 		for _, state := range states {
@@ -41,7 +41,11 @@ var lsAppsCmd = &cobra.Command{
 			if state.Status == visor.AppStatusRunning {
 				status = "running"
 			}
-			_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", state.Name, strconv.Itoa(int(state.Port)), state.AutoStart, status)
+			health := string(state.Health)
+			if health == "" {
+				health = "-"
+			}
+			_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", state.Name, strconv.Itoa(int(state.Port)), state.AutoStart, status, health)
 			internal.Catch(err)
 		}
 		internal.Catch(w.Flush())
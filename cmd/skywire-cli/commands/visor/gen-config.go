@@ -11,6 +11,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/restart"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/spf13/cobra"
@@ -30,6 +31,7 @@ var (
 	retainKeys    bool
 	configLocType = pathutil.WorkingDirLoc
 	testenv       bool
+	lanDiscovery  bool
 )
 
 func init() {
@@ -39,6 +41,7 @@ func init() {
 	genConfigCmd.Flags().BoolVar(&retainKeys, "retain-keys", false, "retain current keys")
 	genConfigCmd.Flags().VarP(&configLocType, "type", "m", fmt.Sprintf("config generation mode. Valid values: %v", pathutil.AllConfigLocationTypes()))
 	genConfigCmd.Flags().BoolVarP(&testenv, "testing-environment", "t", false, "whether to use production or test deployment service.")
+	genConfigCmd.Flags().BoolVar(&lanDiscovery, "lan-discovery", false, "whether to discover stcp addresses of visors within the local network.")
 }
 
 var genConfigCmd = &cobra.Command{
@@ -106,7 +109,7 @@ func localConfig() *visor.Config {
 }
 
 func defaultConfig() *visor.Config {
-	conf := &visor.Config{}
+	conf := &visor.Config{Version: visor.ConfigVersion}
 
 	if sk.Null() {
 		conf.KeyPair = visor.NewKeyPair()
@@ -118,6 +121,9 @@ func defaultConfig() *visor.Config {
 	if err != nil {
 		logger.Warn(err)
 	} else {
+		if lanDiscovery {
+			stcp.LANDiscovery = &snet.LANDiscoveryConfig{}
+		}
 		conf.STCP = stcp
 	}
 
@@ -161,6 +167,7 @@ func defaultConfig() *visor.Config {
 	}
 
 	conf.AppServerAddr = appcommon.DefaultServerAddr
+	conf.AppServerJSONAddr = appcommon.DefaultJSONServerAddr
 	conf.RestartCheckDelay = restart.DefaultCheckDelay.String()
 
 	return conf
@@ -0,0 +1,164 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/keystore"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+const (
+	visorConfigEnv      = "SW_CONFIG"
+	defaultKeyStoreName = "keystore.json"
+)
+
+var (
+	keyStorePath    string
+	passphraseFD    int
+	newPassphraseFD int
+	newKey          bool
+)
+
+func init() {
+	RootCmd.AddCommand(keyStoreCmd)
+	keyStoreCmd.AddCommand(
+		createKeyStoreCmd,
+		exportKeyStoreCmd,
+		rotateKeyStoreCmd,
+	)
+
+	keyStoreCmd.PersistentFlags().IntVar(&passphraseFD, "passphrase-fd", -1,
+		"file descriptor to read the keystore passphrase from (alternatively set "+keystore.EnvPassphrase+")")
+	createKeyStoreCmd.Flags().StringVarP(&keyStorePath, "keystore", "k", "",
+		"path of the keystore file. Defaults to '"+defaultKeyStoreName+"' next to the config file.")
+	rotateKeyStoreCmd.Flags().IntVar(&newPassphraseFD, "new-passphrase-fd", -1,
+		"file descriptor to read the new keystore passphrase from (alternatively set "+keystore.EnvNewPassphrase+")")
+	rotateKeyStoreCmd.Flags().BoolVar(&newKey, "new-key", false,
+		"also replace the key pair, which changes the identity of the visor")
+}
+
+var keyStoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "Manages the encrypted keystore of a local visor config",
+}
+
+var createKeyStoreCmd = &cobra.Command{
+	Use:   "create [config-path]",
+	Short: "Moves the secret key of the visor config into a new encrypted keystore",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		confPath := findVisorConfig(args)
+		conf := readVisorConfig(confPath)
+
+		if conf.KeyPair == nil || conf.KeyPair.SecKey.Null() {
+			internal.Catch(errors.New("config contains no secret key"))
+		}
+		if conf.KeyPair.KeyStore != "" {
+			internal.Catch(fmt.Errorf("config already uses keystore %s", conf.KeyPair.KeyStore))
+		}
+
+		if keyStorePath == "" {
+			keyStorePath = filepath.Join(filepath.Dir(confPath), defaultKeyStoreName)
+		}
+		path, err := filepath.Abs(keyStorePath)
+		internal.Catch(err)
+
+		passphrase, err := keystore.ReadNewPassphrase(passphraseFD, keystore.EnvPassphrase)
+		internal.Catch(err)
+		saveKeyStore(conf.KeyPair.SecKey, passphrase, path, false)
+
+		conf.KeyPair.KeyStore = path
+		conf.KeyPair.SecKey = cipher.SecKey{}
+		pathutil.WriteJSONConfig(conf, confPath, true)
+	},
+}
+
+var exportKeyStoreCmd = &cobra.Command{
+	Use:   "export [config-path]",
+	Short: "Prints the secret key of the keystore referenced by the visor config",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		conf := readVisorConfig(findVisorConfig(args))
+		unlockVisorConfig(conf)
+
+		fmt.Println(conf.KeyPair.SecKey.Hex())
+	},
+}
+
+var rotateKeyStoreCmd = &cobra.Command{
+	Use:   "rotate [config-path]",
+	Short: "Re-encrypts the keystore of the visor config with a new passphrase",
+	Long: "Re-encrypts the keystore of the visor config with a new passphrase.\n" +
+		"With --new-key the key pair is replaced too, which changes the identity of the visor.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		confPath := findVisorConfig(args)
+		conf := readVisorConfig(confPath)
+		unlockVisorConfig(conf)
+
+		passphrase, err := keystore.ReadNewPassphrase(newPassphraseFD, keystore.EnvNewPassphrase)
+		internal.Catch(err)
+
+		sk := conf.KeyPair.SecKey
+		if newKey {
+			conf.KeyPair.PubKey, sk = cipher.GenerateKeyPair()
+		}
+
+		// The new keystore replaces the current one only after the config is written,
+		// so that a failure in between leaves the visor with a keystore it can unlock.
+		path := conf.KeyPair.KeyStore
+		newPath := path + ".new"
+		saveKeyStore(sk, passphrase, newPath, true)
+
+		conf.KeyPair.SecKey = cipher.SecKey{}
+		pathutil.WriteJSONConfig(conf, confPath, true)
+
+		internal.Catch(os.Rename(newPath, path))
+
+		fmt.Println(conf.KeyPair.PubKey)
+	},
+}
+
+func findVisorConfig(args []string) string {
+	return pathutil.FindConfigPath(args, 0, visorConfigEnv, pathutil.VisorDefaults())
+}
+
+func readVisorConfig(path string) *visor.Config {
+	raw, err := ioutil.ReadFile(filepath.Clean(path))
+	internal.Catch(err)
+
+	raw, _, err = visor.MigrateConfig(raw)
+	internal.Catch(err)
+
+	var conf visor.Config
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		internal.Catch(fmt.Errorf("invalid config file %s: %v", path, err))
+	}
+	return &conf
+}
+
+func unlockVisorConfig(conf *visor.Config) {
+	if conf.KeyPair == nil || conf.KeyPair.KeyStore == "" {
+		internal.Catch(errors.New("config does not use a keystore"))
+	}
+
+	passphrase, err := keystore.ReadPassphrase(passphraseFD, "Keystore passphrase: ")
+	internal.Catch(err)
+	internal.Catch(conf.UnlockKeyStore(passphrase))
+}
+
+func saveKeyStore(sk cipher.SecKey, passphrase []byte, path string, replace bool) {
+	ks, err := keystore.Encrypt(sk, passphrase, keystore.DefaultParams)
+	internal.Catch(err)
+	internal.Catch(ks.Save(path, replace))
+}
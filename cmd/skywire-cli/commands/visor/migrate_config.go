@@ -0,0 +1,91 @@
+package visor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+var applyMigration bool
+
+func init() {
+	RootCmd.AddCommand(migrateConfigCmd)
+	migrateConfigCmd.Flags().BoolVar(&applyMigration, "apply", false,
+		"write the migrated config, keeping a backup of the original (dry-run otherwise)")
+}
+
+var migrateConfigCmd = &cobra.Command{
+	Use:   "migrate-config [config-path]",
+	Short: "Migrates a visor config to the current schema version and validates it",
+	Long: "Migrates a visor config to the current schema version and validates it.\n" +
+		"By default, only the changes are shown. Use --apply to write the migrated config.",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		confPath := findVisorConfig(args)
+
+		raw, err := ioutil.ReadFile(filepath.Clean(confPath))
+		internal.Catch(err)
+
+		migrated, migrations, err := visor.MigrateConfig(raw)
+		internal.Catch(err)
+
+		if len(migrations) == 0 {
+			fmt.Printf("Config is already of version %s.\n", visor.ConfigVersion)
+		} else {
+			fmt.Printf("Migrations: %v\n\n", migrations)
+
+			diff, err := configDiff(confPath, raw, migrated)
+			internal.Catch(err)
+			fmt.Println(diff)
+
+			if applyMigration {
+				backup := confPath + ".bak"
+				internal.Catch(ioutil.WriteFile(backup, raw, 0600))
+				internal.Catch(ioutil.WriteFile(confPath, migrated, 0600))
+				fmt.Printf("Wrote migrated config to %s, the original is backed up to %s.\n", confPath, backup)
+			}
+		}
+
+		var conf visor.Config
+		internal.Catch(json.Unmarshal(migrated, &conf))
+		internal.Catch(conf.Validate())
+		fmt.Println("Config is valid.")
+	},
+}
+
+// configDiff returns a unified diff of two JSON configs.
+// Both are normalized first, so that only semantic changes are shown.
+func configDiff(name string, a, b []byte) (string, error) {
+	normalize := func(raw []byte) (string, error) {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "", err
+		}
+		out, err := json.MarshalIndent(v, "", "  ")
+		return string(out), err
+	}
+
+	from, err := normalize(a)
+	if err != nil {
+		return "", err
+	}
+	to, err := normalize(b)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from + "\n"),
+		B:        difflib.SplitLines(to + "\n"),
+		FromFile: name,
+		ToFile:   name + " (migrated)",
+		Context:  3,
+	})
+}
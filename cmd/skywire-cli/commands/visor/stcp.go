@@ -0,0 +1,64 @@
+package visor
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-cli/internal"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/visor"
+)
+
+func init() {
+	RootCmd.AddCommand(
+		lsSTCPCmd,
+		addSTCPCmd,
+		rmSTCPCmd,
+	)
+}
+
+var lsSTCPCmd = &cobra.Command{
+	Use:   "ls-stcp",
+	Short: "Lists the entries of the stcp pk table",
+	Run: func(_ *cobra.Command, _ []string) {
+		entries, err := rpcClient().STCPTable()
+		internal.Catch(err)
+
+		printSTCPEntries(entries...)
+	},
+}
+
+var addSTCPCmd = &cobra.Command{
+	Use:   "add-stcp <remote-public-key> <tcp-address>",
+	Short: "Adds or updates an entry of the stcp pk table",
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		pk := internal.ParsePK("remote-public-key", args[0])
+		internal.Catch(rpcClient().AddSTCPEntry(pk, args[1]))
+		fmt.Println("OK")
+	},
+}
+
+var rmSTCPCmd = &cobra.Command{
+	Use:   "rm-stcp <remote-public-key>",
+	Short: "Removes an entry of the stcp pk table",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		pk := internal.ParsePK("remote-public-key", args[0])
+		internal.Catch(rpcClient().RemoveSTCPEntry(pk))
+		fmt.Println("OK")
+	},
+}
+
+func printSTCPEntries(entries ...visor.STCPEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
+	_, err := fmt.Fprintln(w, "pk\taddr")
+	internal.Catch(err)
+	for _, e := range entries {
+		_, err = fmt.Fprintf(w, "%s\t%s\n", e.PK, e.Addr)
+		internal.Catch(err)
+	}
+	internal.Catch(w.Flush())
+}
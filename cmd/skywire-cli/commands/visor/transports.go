@@ -23,6 +23,7 @@ func init() {
 		tpCmd,
 		addTpCmd,
 		rmTpCmd,
+		lsTrustedCmd,
 	)
 }
 
@@ -60,6 +61,24 @@ var lsTpCmd = &cobra.Command{
 	},
 }
 
+var lsTrustedCmd = &cobra.Command{
+	Use:   "ls-trusted",
+	Short: "Lists the states of transports maintained to trusted visors",
+	Run: func(_ *cobra.Command, _ []string) {
+		states, err := rpcClient().TrustedVisors()
+		internal.Catch(err)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 5, ' ', tabwriter.TabIndent)
+		_, err = fmt.Fprintln(w, "remote	state	type	id	failures	last_error")
+		internal.Catch(err)
+		for _, s := range states {
+			_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", s.PK, s.State, s.Type, s.TpID, s.Failures, s.LastError)
+			internal.Catch(err)
+		}
+		internal.Catch(w.Flush())
+	},
+}
+
 var tpCmd = &cobra.Command{
 	Use:   "tp <transport-id>",
 	Short: "Returns summary of given transport by id",
@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/utclient"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/keystore"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/restart"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/buildinfo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
@@ -44,6 +45,7 @@ type runCfg struct {
 	profileMode  string
 	port         string
 	startDelay   string
+	passphraseFD int
 	args         []string
 
 	profileStop  func()
@@ -69,6 +71,7 @@ var rootCmd = &cobra.Command{
 		cfg.startProfiler().
 			startLogger().
 			readConfig().
+			unlockKeyStore().
 			runVisor().
 			waitOsSignals().
 			stopVisor()
@@ -84,6 +87,7 @@ func init() {
 	rootCmd.Flags().StringVarP(&cfg.profileMode, "profile", "p", "none", "enable profiling with pprof. Mode:  none or one of: [cpu, mem, mutex, block, trace, http]")
 	rootCmd.Flags().StringVarP(&cfg.port, "port", "", "6060", "port for http-mode of pprof")
 	rootCmd.Flags().StringVarP(&cfg.startDelay, "delay", "", "0ns", "delay before visor start")
+	rootCmd.Flags().IntVar(&cfg.passphraseFD, "keystore-passphrase-fd", -1, "file descriptor to read the keystore passphrase from (alternatively set "+keystore.EnvPassphrase+")")
 
 	cfg.restartCtx = restart.CaptureContext()
 }
@@ -176,8 +180,25 @@ func (cfg *runCfg) readConfig() *runCfg {
 		cfg.logger.Fatalf("Failed to read config: %v", err)
 	}
 
-	if err := json.Unmarshal(raw, &cfg.conf); err != nil {
-		cfg.logger.WithField("raw", string(raw)).Fatalf("Failed to decode config: %s", err)
+	migrated, migrations, err := visor.MigrateConfig(raw)
+	if err != nil {
+		cfg.logger.Fatalf("Failed to migrate config: %v", err)
+	}
+
+	if len(migrations) > 0 {
+		cfg.logger.Infof("Migrated config: %v", migrations)
+
+		if configPath != nil {
+			cfg.saveMigratedConfig(*configPath, raw, migrated)
+		}
+	}
+
+	if err := json.Unmarshal(migrated, &cfg.conf); err != nil {
+		cfg.logger.WithField("raw", string(migrated)).Fatalf("Failed to decode config: %s", err)
+	}
+
+	if err := cfg.conf.Validate(); err != nil {
+		cfg.logger.Fatal(err)
 	}
 
 	cfg.logger.Infof("Config: %#v", &cfg.conf)
@@ -187,6 +208,41 @@ func (cfg *runCfg) readConfig() *runCfg {
 	return cfg
 }
 
+// saveMigratedConfig replaces the config file with its migrated version, keeping a backup of the original.
+func (cfg *runCfg) saveMigratedConfig(path string, original, migrated []byte) {
+	const filePerm = 0600
+
+	backup := path + ".bak"
+	if err := ioutil.WriteFile(backup, original, filePerm); err != nil {
+		cfg.logger.Fatalf("Failed to back up config to %s: %v", backup, err)
+	}
+
+	if err := ioutil.WriteFile(path, migrated, filePerm); err != nil {
+		cfg.logger.Fatalf("Failed to write migrated config: %v", err)
+	}
+
+	cfg.logger.Infof("Saved migrated config to %s, the original is backed up to %s", path, backup)
+}
+
+func (cfg *runCfg) unlockKeyStore() *runCfg {
+	if cfg.conf.KeyPair == nil || cfg.conf.KeyPair.KeyStore == "" {
+		return cfg
+	}
+
+	cfg.logger.Infof("Unlocking keystore %v", cfg.conf.KeyPair.KeyStore)
+
+	passphrase, err := keystore.ReadPassphrase(cfg.passphraseFD, "Keystore passphrase: ")
+	if err != nil {
+		cfg.logger.Fatalf("Failed to read keystore passphrase: %v", err)
+	}
+
+	if err := cfg.conf.UnlockKeyStore(passphrase); err != nil {
+		cfg.logger.Fatalf("Failed to unlock keystore: %v", err)
+	}
+
+	return cfg
+}
+
 func (cfg *runCfg) runVisor() *runCfg {
 	startDelay, err := time.ParseDuration(cfg.startDelay)
 	if err != nil {
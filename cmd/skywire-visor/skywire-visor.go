@@ -5,8 +5,12 @@ package main
 
 import (
 	"github.com/SkycoinProject/skywire-mainnet/cmd/skywire-visor/commands"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
 )
 
 func main() {
+	// apps with resource limits are started through the visor binary, see appserver.ExecWithLimits
+	appserver.ExecWithLimits()
+
 	commands.Execute()
 }
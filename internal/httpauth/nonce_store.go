@@ -0,0 +1,124 @@
+package httpauth
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	boltTimeout         = 10 * time.Second
+	boltNonceBucketName = "nonces"
+)
+
+// NonceStore stores the next expected nonce of each public key.
+type NonceStore interface {
+	// Nonce returns the next expected nonce of 'remote'.
+	Nonce(ctx context.Context, remote cipher.PubKey) (Nonce, error)
+
+	// IncrementNonce increments the nonce of 'remote' and returns the next expected nonce.
+	IncrementNonce(ctx context.Context, remote cipher.PubKey) (Nonce, error)
+
+	// Count returns the number of public keys stored.
+	Count(ctx context.Context) (int, error)
+}
+
+type memoryNonceStore struct {
+	nonces map[cipher.PubKey]Nonce
+	mx     sync.RWMutex
+}
+
+// NewMemoryNonceStore returns a NonceStore which keeps nonces in memory.
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{nonces: make(map[cipher.PubKey]Nonce)}
+}
+
+func (s *memoryNonceStore) Nonce(_ context.Context, remote cipher.PubKey) (Nonce, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return s.nonces[remote], nil
+}
+
+func (s *memoryNonceStore) IncrementNonce(_ context.Context, remote cipher.PubKey) (Nonce, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.nonces[remote]++
+	return s.nonces[remote], nil
+}
+
+func (s *memoryNonceStore) Count(_ context.Context) (int, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return len(s.nonces), nil
+}
+
+// BoltNonceStore implements NonceStore, storing nonces in a bbolt database file.
+type BoltNonceStore struct {
+	*bbolt.DB
+}
+
+// NewBoltNonceStore creates a new BoltNonceStore.
+func NewBoltNonceStore(path string) (*BoltNonceStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: boltTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltNonceBucketName))
+		return err
+	})
+
+	return &BoltNonceStore{DB: db}, err
+}
+
+// Nonce implements NonceStore.
+func (s *BoltNonceStore) Nonce(_ context.Context, remote cipher.PubKey) (nonce Nonce, err error) {
+	err = s.View(func(tx *bbolt.Tx) error {
+		nonce = decodeNonce(tx.Bucket([]byte(boltNonceBucketName)).Get(remote[:]))
+		return nil
+	})
+	return nonce, err
+}
+
+// IncrementNonce implements NonceStore.
+func (s *BoltNonceStore) IncrementNonce(_ context.Context, remote cipher.PubKey) (nonce Nonce, err error) {
+	err = s.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(boltNonceBucketName))
+		nonce = decodeNonce(b.Get(remote[:])) + 1
+
+		var raw [8]byte
+		binary.BigEndian.PutUint64(raw[:], uint64(nonce))
+		return b.Put(remote[:], raw[:])
+	})
+	return nonce, err
+}
+
+// Count implements NonceStore.
+func (s *BoltNonceStore) Count(_ context.Context) (n int, err error) {
+	err = s.View(func(tx *bbolt.Tx) error {
+		n = tx.Bucket([]byte(boltNonceBucketName)).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func decodeNonce(raw []byte) Nonce {
+	if len(raw) != 8 {
+		return 0
+	}
+	return Nonce(binary.BigEndian.Uint64(raw))
+}
@@ -0,0 +1,188 @@
+package httpauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+)
+
+// NoncePath is the path prefix of the endpoint which serves the next expected nonce of a public key.
+// The full path is NoncePath + "/{pk}".
+const NoncePath = "/security/nonces"
+
+// maxBodySize limits the size of request bodies which are read to verify signatures.
+const maxBodySize = 8 << 20
+
+type ctxKey struct{}
+
+// PKFromContext returns the authenticated public key of a request which passed Server.Authenticate.
+func PKFromContext(ctx context.Context) (cipher.PubKey, bool) {
+	pk, ok := ctx.Value(ctxKey{}).(cipher.PubKey)
+	return pk, ok
+}
+
+// Server authenticates requests signed by Client.
+type Server struct {
+	log   *logging.Logger
+	store NonceStore
+
+	locks map[cipher.PubKey]*pkLock
+	mx    sync.Mutex
+}
+
+// pkLock serializes the requests of a single public key, so that a nonce cannot be used twice.
+type pkLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewServer creates a new Server which keeps track of nonces in 'store'.
+func NewServer(store NonceStore) *Server {
+	return &Server{
+		log:   logging.MustGetLogger("httpauth_server"),
+		store: store,
+		locks: make(map[cipher.PubKey]*pkLock),
+	}
+}
+
+// SetLogger sets the logger of the Server.
+func (s *Server) SetLogger(log *logging.Logger) {
+	s.log = log
+}
+
+// Handler serves the nonce endpoint under NoncePath, and authenticates all other requests before passing
+// them to 'next'.
+func (s *Server) Handler(next http.Handler) http.Handler {
+	auth := s.Authenticate(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, NoncePath+"/") {
+			s.ServeNonce(w, r)
+			return
+		}
+		auth.ServeHTTP(w, r)
+	})
+}
+
+// ServeNonce serves the next expected nonce of the public key in the last path element.
+func (s *Server) ServeNonce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var pk cipher.PubKey
+	if err := pk.UnmarshalText([]byte(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid public key: "+err.Error())
+		return
+	}
+
+	nonce, err := s.store.Nonce(r.Context(), pk)
+	if err != nil {
+		s.log.WithError(err).Errorf("Failed to obtain nonce of %s", pk)
+		writeError(w, http.StatusInternalServerError, "failed to obtain nonce")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NextNonceResponse{Edge: pk, NextNonce: nonce})
+}
+
+// Authenticate is a middleware which verifies the SW-Public, SW-Nonce and SW-Sig headers of requests.
+// The nonce of the public key is incremented if 'next' responds with http.StatusOK, as Client does.
+// The authenticated public key can be obtained from the request context with PKFromContext.
+func (s *Server) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, err := AuthFromHeaders(r.Header)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read request body: "+err.Error())
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		unlock := s.lock(auth.Key)
+		defer unlock()
+
+		nonce, err := s.store.Nonce(r.Context(), auth.Key)
+		if err != nil {
+			s.log.WithError(err).Errorf("Failed to obtain nonce of %s", auth.Key)
+			writeError(w, http.StatusInternalServerError, "failed to obtain nonce")
+			return
+		}
+		if auth.Nonce != nonce {
+			writeError(w, http.StatusUnauthorized, invalidNonceErrorMessage)
+			return
+		}
+		if err := auth.Verify(body); err != nil {
+			writeError(w, http.StatusUnauthorized, "SW-Sig is invalid")
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), ctxKey{}, auth.Key)))
+
+		if sw.status == http.StatusOK {
+			if _, err := s.store.IncrementNonce(r.Context(), auth.Key); err != nil {
+				s.log.WithError(err).Errorf("Failed to increment nonce of %s", auth.Key)
+			}
+		}
+	})
+}
+
+func (s *Server) lock(pk cipher.PubKey) (unlock func()) {
+	s.mx.Lock()
+	l, ok := s.locks[pk]
+	if !ok {
+		l = new(pkLock)
+		s.locks[pk] = l
+	}
+	l.refs++
+	s.mx.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+
+		s.mx.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, pk)
+		}
+		s.mx.Unlock()
+	}
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, HTTPResponse{Error: &HTTPError{Message: msg, Code: code}})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Warn("Failed to write HTTP response")
+	}
+}
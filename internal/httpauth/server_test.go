@@ -0,0 +1,137 @@
+package httpauth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	store := NewMemoryNonceStore()
+
+	srv := httptest.NewServer(NewServer(store).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authPK, ok := PKFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, pk, authPK)
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, err = w.Write(body)
+		require.NoError(t, err)
+	})))
+	defer srv.Close()
+
+	c, err := NewClient(context.TODO(), srv.URL, pk, sk)
+	require.NoError(t, err)
+
+	post := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/echo", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, post("hello").StatusCode)
+	}
+	nonce, err := store.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(3), nonce)
+
+	// Unsuccessful requests do not consume the nonce.
+	assert.Equal(t, http.StatusBadRequest, post("fail").StatusCode)
+	nonce, err = c.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(3), nonce)
+
+	t.Run("replay", func(t *testing.T) {
+		sig, err := Sign([]byte("hello"), 0, sk)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/echo", bytes.NewBufferString("hello"))
+		require.NoError(t, err)
+		req.Header.Set("SW-Public", pk.Hex())
+		req.Header.Set("SW-Nonce", "0")
+		req.Header.Set("SW-Sig", sig.Hex())
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		_, otherSK := cipher.GenerateKeyPair()
+		sig, err := Sign([]byte("hello"), 3, otherSK)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/echo", bytes.NewBufferString("hello"))
+		require.NoError(t, err)
+		req.Header.Set("SW-Public", pk.Hex())
+		req.Header.Set("SW-Nonce", "3")
+		req.Header.Set("SW-Sig", sig.Hex())
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/echo", "text/plain", bytes.NewBufferString("hello"))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestBoltNonceStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonces")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	path := filepath.Join(dir, "nonces.db")
+	pk, _ := cipher.GenerateKeyPair()
+
+	store, err := NewBoltNonceStore(path)
+	require.NoError(t, err)
+
+	nonce, err := store.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(0), nonce)
+
+	for i := 1; i <= 3; i++ {
+		nonce, err = store.IncrementNonce(context.TODO(), pk)
+		require.NoError(t, err)
+		assert.Equal(t, Nonce(i), nonce)
+	}
+	require.NoError(t, store.Close())
+
+	// Nonces persist across restarts.
+	store, err = NewBoltNonceStore(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, store.Close()) }()
+
+	nonce, err = store.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(3), nonce)
+
+	n, err := store.Count(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,205 @@
+package skyforward
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+)
+
+// DialTimeout is how long the exit side tries to connect to a target.
+var DialTimeout = 10 * time.Second
+
+// Server is the exit side of tunnels: it connects forwarded connections to their targets.
+type Server struct {
+	allow Allowlist
+	log   *logging.Logger
+
+	mu      sync.Mutex
+	lis     net.Listener
+	closed  bool
+	tunnels map[string]*counters // by `<remote pk> -> <target>`
+}
+
+// NewServer constructs a Server forwarding connections as allowed by `allow`.
+func NewServer(allow Allowlist, log *logging.Logger) *Server {
+	return &Server{
+		allow:   allow,
+		log:     log,
+		tunnels: make(map[string]*counters),
+	}
+}
+
+// Stats returns byte counts of tunnels, by `<remote pk> -> <target>`.
+func (s *Server) Stats() map[string]Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]Stats, len(s.tunnels))
+	for name, c := range s.tunnels {
+		stats[name] = c.stats()
+	}
+
+	return stats
+}
+
+// Serve forwards connections accepted from `l` until the server is closed.
+// `l` should accept skywire connections, so that remote visors are known.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return l.Close()
+	}
+	s.lis = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+
+			return err
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	log := s.log.WithField("remote", conn.RemoteAddr().String())
+
+	remote, ok := conn.RemoteAddr().(appnet.Addr)
+	if !ok {
+		log.Warn("Rejected connection from unknown visor.")
+		closeConn(s.log, conn)
+
+		return
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		log.WithError(err).Warn("Failed to set handshake deadline.")
+		closeConn(s.log, conn)
+
+		return
+	}
+
+	target, err := readRequest(conn)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read forwarding request.")
+		closeConn(s.log, conn)
+
+		return
+	}
+
+	log = log.WithField("target", target)
+
+	if !s.allow.Allows(remote.PubKey, target) {
+		log.Warn("Forwarding is not allowed.")
+		s.respond(log, conn, statusNotAllowed)
+		closeConn(s.log, conn)
+
+		return
+	}
+
+	targetConn, err := net.DialTimeout("tcp", target, DialTimeout)
+	if err != nil {
+		log.WithError(err).Warn("Failed to connect to target.")
+		s.respond(log, conn, statusDialFailed)
+		closeConn(s.log, conn)
+
+		return
+	}
+
+	if !s.respond(log, conn, statusOK) {
+		closeConn(s.log, conn)
+		closeConn(s.log, targetConn)
+
+		return
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		log.WithError(err).Warn("Failed to clear handshake deadline.")
+	}
+
+	log.Debug("Forwarding connection.")
+
+	c := s.counters(remote.PubKey, target)
+
+	if err := forward(targetConn, conn, c); err != nil && !isClosedErr(err) {
+		log.WithError(err).Debug("Forwarded connection failed.")
+	}
+
+	log.WithField("stats", c.stats().String()).Debug("Forwarded connection closed.")
+}
+
+func (s *Server) respond(log logrus.FieldLogger, conn net.Conn, status byte) bool {
+	if _, err := conn.Write([]byte{status}); err != nil {
+		log.WithError(err).Warn("Failed to respond to forwarding request.")
+		return false
+	}
+
+	return true
+}
+
+// counters returns counters of the tunnel from `remote` to `target`.
+func (s *Server) counters(remote cipher.PubKey, target string) *counters {
+	name := fmt.Sprintf("%s -> %s", remote, target)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.tunnels[name]
+	if !ok {
+		c = &counters{}
+		s.tunnels[name] = c
+	}
+
+	return c
+}
+
+// Close stops accepting connections. Forwarded connections are kept until either side closes them.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	if s.lis == nil {
+		return nil
+	}
+
+	return s.lis.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// SortedNames returns names of tunnels in `stats` in order.
+func SortedNames(stats map[string]Stats) []string {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,341 @@
+// Package skyforward implements forwarding of TCP connections over skywire.
+//
+// The entry side of a tunnel accepts TCP connections and dials skyforward of a remote visor, asking it to
+// connect to a target address. The exit side checks the remote visor and the target against an allowlist,
+// connects to the target and copies data in both directions.
+package skyforward
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+)
+
+// DefaultPort is the skynet port skyforward listens on by default.
+const DefaultPort = routing.Port(skyenv.SkyforwardPort)
+
+const (
+	protocolVersion = 1
+
+	// statuses of responses to forwarding requests
+	statusOK         = 0
+	statusNotAllowed = 1
+	statusDialFailed = 2
+
+	maxTargetLen = 255
+)
+
+var (
+	// ErrInvalidRule is returned when a forwarding rule can't be parsed.
+	ErrInvalidRule = errors.New("invalid forwarding rule")
+	// ErrNotAllowed is returned when the remote skyforward does not allow forwarding to the target.
+	ErrNotAllowed = errors.New("forwarding is not allowed")
+	// ErrDialFailed is returned when the remote skyforward fails to connect to the target.
+	ErrDialFailed = errors.New("remote failed to connect to target")
+
+	errUnsupportedVersion = errors.New("unsupported protocol version")
+	errUnknownStatus      = errors.New("unknown status")
+)
+
+// Rule is a forwarding rule: TCP connections accepted at ListenAddr are forwarded to skyforward
+// listening on Port of the visor Remote, which connects them to Target.
+type Rule struct {
+	ListenAddr string
+	Remote     cipher.PubKey
+	Port       routing.Port
+	Target     string
+}
+
+// ParseRule parses rule of the form `<listen addr> -> <pk>[:<port>] -> <target addr>`,
+// e.g. `localhost:8080 -> 02ab…:5 -> 127.0.0.1:80`. A bare port of the listen address means localhost,
+// DefaultPort is used if the skynet port is omitted.
+func ParseRule(s string) (Rule, error) {
+	parts := strings.Split(s, "->")
+	if len(parts) != 3 {
+		return Rule{}, fmt.Errorf("%w %q: should be <listen addr> -> <pk>[:<port>] -> <target addr>", ErrInvalidRule, s)
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	rule := Rule{ListenAddr: parts[0], Port: DefaultPort, Target: parts[2]}
+
+	if _, err := strconv.ParseUint(rule.ListenAddr, 10, 16); err == nil {
+		rule.ListenAddr = net.JoinHostPort("localhost", rule.ListenAddr)
+	}
+
+	if _, _, err := net.SplitHostPort(rule.ListenAddr); err != nil {
+		return Rule{}, fmt.Errorf("%w %q: listen address: %v", ErrInvalidRule, s, err)
+	}
+
+	remote := parts[1]
+	if i := strings.IndexByte(remote, ':'); i != -1 {
+		port, err := strconv.ParseUint(remote[i+1:], 10, 16)
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w %q: skynet port: %v", ErrInvalidRule, s, err)
+		}
+
+		rule.Port = routing.Port(port)
+		remote = remote[:i]
+	}
+
+	if err := rule.Remote.UnmarshalText([]byte(remote)); err != nil {
+		return Rule{}, fmt.Errorf("%w %q: public key: %v", ErrInvalidRule, s, err)
+	}
+
+	if err := validateTarget(rule.Target); err != nil {
+		return Rule{}, fmt.Errorf("%w %q: target address: %v", ErrInvalidRule, s, err)
+	}
+
+	return rule, nil
+}
+
+// String returns the rule in the format accepted by ParseRule.
+func (r Rule) String() string {
+	return fmt.Sprintf("%s -> %s:%d -> %s", r.ListenAddr, r.Remote, r.Port, r.Target)
+}
+
+func validateTarget(target string) error {
+	if len(target) > maxTargetLen {
+		return fmt.Errorf("longer than %d bytes", maxTargetLen)
+	}
+
+	_, _, err := net.SplitHostPort(target)
+
+	return err
+}
+
+// Allowlist tells which visors may forward connections and to which targets, on the exit side.
+// The zero value allows nothing.
+type Allowlist struct {
+	pks     map[cipher.PubKey]struct{}
+	anyPK   bool
+	targets map[string]struct{}
+}
+
+// ParseAllowlist parses comma-separated lists of public keys and target addresses.
+// `*` in the list of keys allows any visor.
+func ParseAllowlist(pks, targets string) (Allowlist, error) {
+	a := Allowlist{
+		pks:     make(map[cipher.PubKey]struct{}),
+		targets: make(map[string]struct{}),
+	}
+
+	for _, s := range splitList(pks) {
+		if s == "*" {
+			a.anyPK = true
+			continue
+		}
+
+		var pk cipher.PubKey
+		if err := pk.UnmarshalText([]byte(s)); err != nil {
+			return Allowlist{}, fmt.Errorf("invalid public key %q: %v", s, err)
+		}
+
+		a.pks[pk] = struct{}{}
+	}
+
+	for _, s := range splitList(targets) {
+		if err := validateTarget(s); err != nil {
+			return Allowlist{}, fmt.Errorf("invalid target %q: %v", s, err)
+		}
+
+		a.targets[s] = struct{}{}
+	}
+
+	return a, nil
+}
+
+// Empty tells whether the allowlist allows nothing.
+func (a Allowlist) Empty() bool {
+	return (!a.anyPK && len(a.pks) == 0) || len(a.targets) == 0
+}
+
+// Allows tells whether `remote` may forward connections to `target`.
+func (a Allowlist) Allows(remote cipher.PubKey, target string) bool {
+	if _, ok := a.targets[target]; !ok {
+		return false
+	}
+
+	if a.anyPK {
+		return true
+	}
+
+	_, ok := a.pks[remote]
+
+	return ok
+}
+
+func splitList(s string) []string {
+	var list []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// Stats are byte counts of a tunnel.
+type Stats struct {
+	Sent     uint64 // bytes sent to the remote side
+	Received uint64 // bytes received from the remote side
+	Active   int64  // number of forwarded connections
+	Total    int64  // number of connections forwarded since start
+}
+
+// String returns stats in a human readable form.
+func (s Stats) String() string {
+	return fmt.Sprintf("sent=%d received=%d active=%d total=%d", s.Sent, s.Received, s.Active, s.Total)
+}
+
+// counters keeps stats of a tunnel, updated as data is copied.
+type counters struct {
+	sent     uint64
+	received uint64
+	active   int64
+	total    int64
+}
+
+func (c *counters) stats() Stats {
+	return Stats{
+		Sent:     atomic.LoadUint64(&c.sent),
+		Received: atomic.LoadUint64(&c.received),
+		Active:   atomic.LoadInt64(&c.active),
+		Total:    atomic.LoadInt64(&c.total),
+	}
+}
+
+// countingWriter adds the number of written bytes to `n`.
+type countingWriter struct {
+	w io.Writer
+	n *uint64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	atomic.AddUint64(w.n, uint64(n))
+
+	return n, err
+}
+
+// forward copies data between `local` and `remote` until either side is done, then closes both.
+func forward(local, remote net.Conn, c *counters) error {
+	atomic.AddInt64(&c.active, 1)
+	atomic.AddInt64(&c.total, 1)
+	defer atomic.AddInt64(&c.active, -1)
+
+	errCh := make(chan error, 2)
+
+	go func() {
+		_, err := io.Copy(countingWriter{w: remote, n: &c.sent}, local)
+		errCh <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(countingWriter{w: local, n: &c.received}, remote)
+		errCh <- err
+	}()
+
+	err := <-errCh
+
+	// the other direction is stopped by closing the connections
+	closeErr := closeBoth(local, remote)
+
+	<-errCh
+
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+func closeBoth(a, b net.Conn) error {
+	var (
+		wg   sync.WaitGroup
+		errs [2]error
+	)
+
+	wg.Add(2)
+
+	go func() { errs[0] = a.Close(); wg.Done() }()
+	go func() { errs[1] = b.Close(); wg.Done() }()
+
+	wg.Wait()
+
+	if errs[0] != nil {
+		return errs[0]
+	}
+
+	return errs[1]
+}
+
+// writeRequest asks the exit side to connect to `target`.
+func writeRequest(w io.Writer, target string) error {
+	buf := make([]byte, 3, 3+len(target))
+	buf[0] = protocolVersion
+	binary.BigEndian.PutUint16(buf[1:], uint16(len(target)))
+	buf = append(buf, target...)
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+// readRequest reads the target requested by the entry side.
+func readRequest(r io.Reader) (string, error) {
+	var hdr [3]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return "", err
+	}
+
+	if hdr[0] != protocolVersion {
+		return "", fmt.Errorf("%w: %d", errUnsupportedVersion, hdr[0])
+	}
+
+	n := binary.BigEndian.Uint16(hdr[1:])
+	if n > maxTargetLen {
+		return "", fmt.Errorf("target is longer than %d bytes", maxTargetLen)
+	}
+
+	target := make([]byte, n)
+	if _, err := io.ReadFull(r, target); err != nil {
+		return "", err
+	}
+
+	return string(target), nil
+}
+
+// readResponse reads the response of the exit side to a request.
+func readResponse(r io.Reader) error {
+	var status [1]byte
+	if _, err := io.ReadFull(r, status[:]); err != nil {
+		return err
+	}
+
+	switch status[0] {
+	case statusOK:
+		return nil
+	case statusNotAllowed:
+		return ErrNotAllowed
+	case statusDialFailed:
+		return ErrDialFailed
+	default:
+		return fmt.Errorf("%w: %d", errUnknownStatus, status[0])
+	}
+}
@@ -0,0 +1,230 @@
+package skyforward
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/nettest"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+func TestMain(m *testing.M) {
+	loggingLevel, ok := os.LookupEnv("TEST_LOGGING_LEVEL")
+	if ok {
+		lvl, err := logging.LevelFromString(loggingLevel)
+		if err != nil {
+			panic(err)
+		}
+
+		logging.SetLevel(lvl)
+	} else {
+		logging.Disable()
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestParseRule(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	tests := []struct {
+		name string
+		rule string
+		want Rule
+		err  bool
+	}{
+		{
+			name: "full",
+			rule: "localhost:8080 -> " + pk.Hex() + ":7 -> 127.0.0.1:80",
+			want: Rule{ListenAddr: "localhost:8080", Remote: pk, Port: 7, Target: "127.0.0.1:80"},
+		},
+		{
+			name: "bare listen port and default skynet port",
+			rule: "8080->" + pk.Hex() + "->example.com:22",
+			want: Rule{ListenAddr: "localhost:8080", Remote: pk, Port: DefaultPort, Target: "example.com:22"},
+		},
+		{name: "missing part", rule: "8080 -> " + pk.Hex(), err: true},
+		{name: "invalid pk", rule: "8080 -> 02ab:5 -> 127.0.0.1:80", err: true},
+		{name: "invalid skynet port", rule: "8080 -> " + pk.Hex() + ":x -> 127.0.0.1:80", err: true},
+		{name: "invalid target", rule: "8080 -> " + pk.Hex() + " -> 127.0.0.1", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRule(tc.rule)
+			if tc.err {
+				assert.True(t, errors.Is(err, ErrInvalidRule), err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, rule)
+
+			again, err := ParseRule(rule.String())
+			require.NoError(t, err)
+			assert.Equal(t, rule, again)
+		})
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	a, err := ParseAllowlist(pk1.Hex(), "127.0.0.1:80, 127.0.0.1:22")
+	require.NoError(t, err)
+	assert.False(t, a.Empty())
+	assert.True(t, a.Allows(pk1, "127.0.0.1:22"))
+	assert.False(t, a.Allows(pk2, "127.0.0.1:22"))
+	assert.False(t, a.Allows(pk1, "127.0.0.1:8080"))
+
+	a, err = ParseAllowlist("*", "127.0.0.1:80")
+	require.NoError(t, err)
+	assert.True(t, a.Allows(pk2, "127.0.0.1:80"))
+
+	a, err = ParseAllowlist(pk1.Hex(), "")
+	require.NoError(t, err)
+	assert.True(t, a.Empty())
+	assert.True(t, Allowlist{}.Empty())
+
+	_, err = ParseAllowlist("02ab", "127.0.0.1:80")
+	assert.Error(t, err)
+
+	_, err = ParseAllowlist("*", "localhost")
+	assert.Error(t, err)
+}
+
+// skyConn is a connection from the visor `remote`.
+type skyConn struct {
+	net.Conn
+	remote cipher.PubKey
+}
+
+func (c skyConn) RemoteAddr() net.Addr {
+	return appnet.Addr{Net: appnet.TypeSkynet, PubKey: c.remote, Port: DefaultPort}
+}
+
+func TestTunnel(t *testing.T) {
+	entryPK, _ := cipher.GenerateKeyPair()
+	exitPK, _ := cipher.GenerateKeyPair()
+
+	// target echoes everything back
+	target, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, target.Close()) }()
+
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				_, _ = io.Copy(conn, conn) //nolint:errcheck
+				_ = conn.Close()           //nolint:errcheck
+			}()
+		}
+	}()
+
+	allow, err := ParseAllowlist(entryPK.Hex(), target.Addr().String())
+	require.NoError(t, err)
+
+	srv := NewServer(allow, logging.MustGetLogger("skyforward_server"))
+
+	dial := func(remote cipher.PubKey, port routing.Port) (net.Conn, error) {
+		if remote != exitPK || port != DefaultPort {
+			return nil, errors.New("unexpected remote")
+		}
+
+		entry, exit := net.Pipe()
+		go srv.serveConn(skyConn{Conn: exit, remote: entryPK})
+
+		return entry, nil
+	}
+
+	serve := func(t *testing.T, targetAddr string) (*Tunnel, net.Addr) {
+		tun := NewTunnel(Rule{Remote: exitPK, Port: DefaultPort, Target: targetAddr}, dial,
+			logging.MustGetLogger("skyforward_tunnel"))
+
+		l, err := nettest.NewLocalListener("tcp")
+		require.NoError(t, err)
+
+		go func() { _ = tun.Serve(l) }() //nolint:errcheck
+
+		return tun, l.Addr()
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		tun, addr := serve(t, target.Addr().String())
+
+		conn, err := net.Dial("tcp", addr.String())
+		require.NoError(t, err)
+
+		msg := []byte("hello over skywire")
+
+		_, err = conn.Write(msg)
+		require.NoError(t, err)
+
+		got := make([]byte, len(msg))
+		_, err = io.ReadFull(conn, got)
+		require.NoError(t, err)
+		assert.Equal(t, msg, got)
+
+		require.NoError(t, conn.Close())
+
+		want := Stats{Sent: uint64(len(msg)), Received: uint64(len(msg)), Total: 1}
+		require.Eventually(t, func() bool { return tun.Stats() == want }, time.Second, 10*time.Millisecond)
+
+		exitName := entryPK.Hex() + " -> " + target.Addr().String()
+		require.Eventually(t, func() bool {
+			// on the exit side, sent bytes come from the target
+			return srv.Stats()[exitName] == want
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{exitName}, SortedNames(srv.Stats()))
+
+		require.NoError(t, tun.Close())
+	})
+
+	t.Run("target not allowed", func(t *testing.T) {
+		tun, addr := serve(t, "127.0.0.1:1")
+
+		conn, err := net.Dial("tcp", addr.String())
+		require.NoError(t, err)
+
+		_, err = conn.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err)
+
+		assert.Equal(t, Stats{}, tun.Stats())
+		require.NoError(t, tun.Close())
+	})
+}
+
+func TestRequest(t *testing.T) {
+	r, w := net.Pipe()
+
+	go func() {
+		_ = writeRequest(w, "127.0.0.1:80") //nolint:errcheck
+	}()
+
+	target, err := readRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:80", target)
+
+	go func() {
+		_, _ = w.Write([]byte{statusNotAllowed}) //nolint:errcheck
+	}()
+
+	assert.Equal(t, ErrNotAllowed, readResponse(r))
+}
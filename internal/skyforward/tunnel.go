@@ -0,0 +1,161 @@
+package skyforward
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// HandshakeTimeout is how long the request to forward a connection may take.
+var HandshakeTimeout = 10 * time.Second
+
+// Dialer dials skyforward listening on `port` of the visor `remote`.
+type Dialer func(remote cipher.PubKey, port routing.Port) (net.Conn, error)
+
+// Tunnel is the entry side of a forwarding rule.
+type Tunnel struct {
+	rule Rule
+	dial Dialer
+	log  *logging.Logger
+	c    *counters // allocated separately, so that 64-bit counters are aligned on 32-bit platforms
+
+	mu     sync.Mutex
+	lis    net.Listener
+	closed bool
+}
+
+// NewTunnel constructs a Tunnel forwarding connections according to `rule`.
+func NewTunnel(rule Rule, dial Dialer, log *logging.Logger) *Tunnel {
+	return &Tunnel{rule: rule, dial: dial, log: log, c: &counters{}}
+}
+
+// Rule returns the forwarding rule of the tunnel.
+func (t *Tunnel) Rule() Rule {
+	return t.rule
+}
+
+// Stats returns byte counts of the tunnel.
+func (t *Tunnel) Stats() Stats {
+	return t.c.stats()
+}
+
+// ListenAndServe listens on the listen address of the rule and forwards accepted connections.
+func (t *Tunnel) ListenAndServe() error {
+	l, err := net.Listen("tcp", t.rule.ListenAddr)
+	if err != nil {
+		return err
+	}
+
+	return t.Serve(l)
+}
+
+// Serve forwards connections accepted from `l` until the tunnel is closed.
+func (t *Tunnel) Serve(l net.Listener) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return l.Close()
+	}
+	t.lis = l
+	t.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if t.isClosed() {
+				return nil
+			}
+
+			return err
+		}
+
+		go t.serveConn(conn)
+	}
+}
+
+func (t *Tunnel) serveConn(conn net.Conn) {
+	log := t.log.WithField("rule", t.rule.String()).WithField("client", conn.RemoteAddr().String())
+
+	remote, err := t.dial(t.rule.Remote, t.rule.Port)
+	if err != nil {
+		log.WithError(err).Warn("Failed to dial remote skyforward.")
+		closeConn(t.log, conn)
+
+		return
+	}
+
+	if err := t.handshake(remote); err != nil {
+		log.WithError(err).Warn("Remote skyforward refused to forward connection.")
+		closeConn(t.log, conn)
+		closeConn(t.log, remote)
+
+		return
+	}
+
+	log.Debug("Forwarding connection.")
+
+	if err := forward(conn, remote, t.c); err != nil && !isClosedErr(err) {
+		log.WithError(err).Debug("Forwarded connection failed.")
+	}
+
+	log.WithField("stats", t.Stats().String()).Debug("Forwarded connection closed.")
+}
+
+// handshake asks the remote skyforward to connect to the target of the rule.
+func (t *Tunnel) handshake(remote net.Conn) error {
+	if err := remote.SetDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return err
+	}
+
+	if err := writeRequest(remote, t.rule.Target); err != nil {
+		return err
+	}
+
+	if err := readResponse(remote); err != nil {
+		return err
+	}
+
+	return remote.SetDeadline(time.Time{})
+}
+
+// Close stops accepting connections. Forwarded connections are kept until either side closes them.
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+
+	t.closed = true
+
+	if t.lis == nil {
+		return nil
+	}
+
+	return t.lis.Close()
+}
+
+func (t *Tunnel) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closed
+}
+
+func closeConn(log *logging.Logger, conn net.Conn) {
+	if err := conn.Close(); err != nil && !isClosedErr(err) {
+		log.WithError(err).Debug("Error closing conn.")
+	}
+}
+
+func isClosedErr(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection") ||
+		strings.Contains(err.Error(), "closed pipe")
+}
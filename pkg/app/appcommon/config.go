@@ -3,6 +3,12 @@ package appcommon
 // DefaultServerAddr is a default address to run the app server at.
 const DefaultServerAddr = "localhost:5505"
 
+// DefaultJSONServerAddr is a default address to run the JSON-RPC endpoint of the app server at.
+const DefaultJSONServerAddr = "localhost:5506"
+
+// UnixSocketName is the name of the unix socket of the app server, created in the work dir of an app.
+const UnixSocketName = "app-server.sock"
+
 // Config defines configuration parameters for `Proc`.
 type Config struct {
 	Name       string `json:"name"`
@@ -10,4 +16,29 @@ type Config struct {
 	VisorPK    string `json:"visor_pk"`
 	BinaryDir  string `json:"binary_dir"`
 	WorkDir    string `json:"work_dir"`
+
+	Limits     Limits      `json:"limits,omitempty"`
+	Credential *Credential `json:"credential,omitempty"` // the visor user if not set
+
+	// UnixSocket makes the app talk to the app server over a unix socket in WorkDir
+	// instead of ServerAddr. Linux only.
+	UnixSocket bool `json:"unix_socket,omitempty"`
+}
+
+// Limits are resource limits of an app process, enforced on Linux. Zero values mean no limit.
+type Limits struct {
+	MaxMemory    uint64 `json:"max_memory,omitempty"`     // address space, in bytes
+	MaxCPUTime   uint64 `json:"max_cpu_time,omitempty"`   // in seconds
+	MaxOpenFiles uint64 `json:"max_open_files,omitempty"` // number of file descriptors
+}
+
+// IsSet reports whether any limit is set.
+func (l Limits) IsSet() bool {
+	return l.MaxMemory != 0 || l.MaxCPUTime != 0 || l.MaxOpenFiles != 0
+}
+
+// Credential is the user and group an app process runs as.
+type Credential struct {
+	UID uint32 `json:"uid"`
+	GID uint32 `json:"gid"`
 }
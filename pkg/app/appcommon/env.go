@@ -7,4 +7,6 @@ const (
 	EnvServerAddr = "APP_SERVER_ADDR"
 	// EnvVisorPK is a name for env arg containing public key of visor.
 	EnvVisorPK = "VISOR_PK"
+	// EnvServerNet is a name for env arg containing network of the app server address, tcp if not set.
+	EnvServerNet = "APP_SERVER_NET"
 )
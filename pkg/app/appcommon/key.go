@@ -12,3 +12,7 @@ type Key string
 func GenerateAppKey() Key {
 	return Key(uuid.New().String())
 }
+
+// UnixSocketKey is the key of apps talking to the app server over their own unix socket.
+// Such apps are authenticated by their process ID instead, so the key is not secret.
+const UnixSocketKey Key = "App"
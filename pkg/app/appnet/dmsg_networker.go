@@ -43,3 +43,13 @@ func (n *DmsgNetworker) Listen(addr Addr) (net.Listener, error) {
 func (n *DmsgNetworker) ListenContext(_ context.Context, addr Addr) (net.Listener, error) {
 	return n.dmsgC.Listen(uint16(addr.Port))
 }
+
+// DialPacketContext is not supported by dmsg network, as dmsg streams do not preserve boundaries of writes.
+func (n *DmsgNetworker) DialPacketContext(context.Context, Addr) (net.Conn, error) {
+	return nil, ErrPacketsNotSupported
+}
+
+// ListenPacketContext is not supported by dmsg network, as dmsg streams do not preserve boundaries of writes.
+func (n *DmsgNetworker) ListenPacketContext(context.Context, Addr) (net.Listener, error) {
+	return nil, ErrPacketsNotSupported
+}
@@ -60,6 +60,29 @@ func (_m *MockNetworker) DialContext(ctx context.Context, addr Addr) (net.Conn,
 	return r0, r1
 }
 
+// DialPacketContext provides a mock function with given fields: ctx, addr
+func (_m *MockNetworker) DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	ret := _m.Called(ctx, addr)
+
+	var r0 net.Conn
+	if rf, ok := ret.Get(0).(func(context.Context, Addr) net.Conn); ok {
+		r0 = rf(ctx, addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Conn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Addr) error); ok {
+		r1 = rf(ctx, addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Listen provides a mock function with given fields: addr
 func (_m *MockNetworker) Listen(addr Addr) (net.Listener, error) {
 	ret := _m.Called(addr)
@@ -105,3 +128,26 @@ func (_m *MockNetworker) ListenContext(ctx context.Context, addr Addr) (net.List
 
 	return r0, r1
 }
+
+// ListenPacketContext provides a mock function with given fields: ctx, addr
+func (_m *MockNetworker) ListenPacketContext(ctx context.Context, addr Addr) (net.Listener, error) {
+	ret := _m.Called(ctx, addr)
+
+	var r0 net.Listener
+	if rf, ok := ret.Get(0).(func(context.Context, Addr) net.Listener); ok {
+		r0 = rf(ctx, addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Listener)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Addr) error); ok {
+		r1 = rf(ctx, addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -14,6 +14,8 @@ var (
 	ErrNoSuchNetworker = errors.New("no such networker")
 	// ErrNetworkerAlreadyExists is being returned when there's already one with such Network type.
 	ErrNetworkerAlreadyExists = errors.New("networker already exists")
+	// ErrPacketsNotSupported is being returned when networker does not support packet connections.
+	ErrPacketsNotSupported = errors.New("packet connections are not supported by networker")
 )
 
 // nolint: gochecknoglobals
@@ -65,6 +67,12 @@ type Networker interface {
 	DialContext(ctx context.Context, addr Addr) (net.Conn, error)
 	Listen(addr Addr) (net.Listener, error)
 	ListenContext(ctx context.Context, addr Addr) (net.Listener, error)
+
+	// DialPacketContext and ListenPacketContext are like DialContext and ListenContext, but
+	// each Write to the resulting connections is delivered by a single Read of the remote,
+	// data is not merged or split in between. Zero-length writes are not delivered.
+	DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error)
+	ListenPacketContext(ctx context.Context, addr Addr) (net.Listener, error)
 }
 
 // Dial dials the remote `addr`.
@@ -96,3 +104,24 @@ func ListenContext(ctx context.Context, addr Addr) (net.Listener, error) {
 
 	return networker.ListenContext(ctx, addr)
 }
+
+// DialPacketContext dials the remote `addr` with the context, the connection preserves boundaries of writes.
+func DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	n, err := ResolveNetworker(addr.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.DialPacketContext(ctx, addr)
+}
+
+// ListenPacketContext starts listening on the local `addr` with the context,
+// accepted connections preserve boundaries of writes.
+func ListenPacketContext(ctx context.Context, addr Addr) (net.Listener, error) {
+	networker, err := ResolveNetworker(addr.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return networker.ListenPacketContext(ctx, addr)
+}
@@ -21,6 +21,22 @@ var (
 	ErrPortAlreadyBound = errors.New("port already bound")
 )
 
+type dialOptionsKey struct{}
+
+// WithDialOptions returns a copy of ctx, which makes skynet dials use 'opts' to set up routes.
+func WithDialOptions(ctx context.Context, opts *router.DialOptions) context.Context {
+	return context.WithValue(ctx, dialOptionsKey{}, opts)
+}
+
+// DialOptionsFromContext returns dial options of ctx, or default ones if there are none.
+func DialOptionsFromContext(ctx context.Context) *router.DialOptions {
+	if opts, ok := ctx.Value(dialOptionsKey{}).(*router.DialOptions); ok && opts != nil {
+		return opts
+	}
+
+	return router.DefaultDialOptions()
+}
+
 // SkywireNetworker implements `Networker` for skynet.
 type SkywireNetworker struct {
 	log       *logging.Logger
@@ -44,7 +60,18 @@ func (r *SkywireNetworker) Dial(addr Addr) (net.Conn, error) {
 }
 
 // DialContext dials remote `addr` via `skynet` with context.
-func (r *SkywireNetworker) DialContext(ctx context.Context, addr Addr) (conn net.Conn, err error) {
+// Routes are set up according to dial options of `ctx`, see `WithDialOptions`.
+func (r *SkywireNetworker) DialContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	return r.dial(ctx, addr, false)
+}
+
+// DialPacketContext dials remote `addr` via `skynet` with context.
+// Each write to the connection is sent as a single data packet, and each read returns a single data packet.
+func (r *SkywireNetworker) DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	return r.dial(ctx, addr, true)
+}
+
+func (r *SkywireNetworker) dial(ctx context.Context, addr Addr, packets bool) (conn net.Conn, err error) {
 	localPort, freePort, err := r.porter.ReserveEphemeral(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -57,13 +84,18 @@ func (r *SkywireNetworker) DialContext(ctx context.Context, addr Addr) (conn net
 		}
 	}()
 
-	rg, err := r.r.DialRoutes(ctx, addr.PubKey, routing.Port(localPort), addr.Port, router.DefaultDialOptions())
+	rg, err := r.r.DialRoutes(ctx, addr.PubKey, routing.Port(localPort), addr.Port, DialOptionsFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
+	conn = rg
+	if packets {
+		conn = &packetConn{RouteGroup: rg}
+	}
+
 	return &skywireConn{
-		Conn:     rg,
+		Conn:     conn,
 		freePort: freePort,
 	}, nil
 }
@@ -75,10 +107,21 @@ func (r *SkywireNetworker) Listen(addr Addr) (net.Listener, error) {
 
 // ListenContext starts listening on local `addr` in the skynet with context.
 func (r *SkywireNetworker) ListenContext(ctx context.Context, addr Addr) (net.Listener, error) {
+	return r.listen(ctx, addr, false)
+}
+
+// ListenPacketContext starts listening on local `addr` in the skynet with context.
+// Accepted connections send and receive a single data packet per write and read.
+func (r *SkywireNetworker) ListenPacketContext(ctx context.Context, addr Addr) (net.Listener, error) {
+	return r.listen(ctx, addr, true)
+}
+
+func (r *SkywireNetworker) listen(ctx context.Context, addr Addr, packets bool) (net.Listener, error) {
 	const bufSize = 1000000
 
 	lis := &skywireListener{
-		addr: addr,
+		addr:    addr,
+		packets: packets,
 		// TODO: pass buf size
 		connsCh:  make(chan net.Conn, bufSize),
 		freePort: nil,
@@ -152,6 +195,18 @@ func (r *SkywireNetworker) serve(conn net.Conn) {
 		return
 	}
 
+	if lis.packets {
+		rg, ok := conn.(*router.RouteGroup)
+		if !ok {
+			r.close(conn)
+			r.log.Errorf("wrong type of conn on packet listener on port %d", localAddr.Port)
+
+			return
+		}
+
+		conn = &packetConn{RouteGroup: rg}
+	}
+
 	lis.putConn(conn)
 }
 
@@ -166,6 +221,7 @@ func (r *SkywireNetworker) close(closer io.Closer) {
 // Implements net.Listener.
 type skywireListener struct {
 	addr       Addr
+	packets    bool // accepted conns are packet conns
 	connsCh    chan net.Conn
 	freePort   func()
 	freePortMx sync.RWMutex
@@ -231,3 +287,13 @@ func (c *skywireConn) Close() error {
 
 	return err
 }
+
+// packetConn is a route group, reads of which return a single data packet.
+type packetConn struct {
+	*router.RouteGroup
+}
+
+// Read reads payload of the next data packet.
+func (c *packetConn) Read(p []byte) (int, error) {
+	return c.RouteGroup.ReadPacket(p)
+}
@@ -0,0 +1,103 @@
+package appserver
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// MissedHeartbeats is the number of heartbeat intervals after which an app is considered unhealthy.
+const MissedHeartbeats = 3
+
+var errInvalidHeartbeatInterval = errors.New("heartbeat interval should be positive")
+
+// HealthStatus is health of an app, as reported by the app itself.
+type HealthStatus string
+
+const (
+	// HealthUnknown is health of an app which does not report it.
+	HealthUnknown HealthStatus = ""
+
+	// HealthStarting is health of an app which is not ready yet.
+	HealthStarting HealthStatus = "starting"
+
+	// HealthHealthy is health of a ready app.
+	HealthHealthy HealthStatus = "healthy"
+
+	// HealthUnhealthy is health of an app which is no longer ready, or stopped sending heartbeats.
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+// Health is health of an app.
+type Health struct {
+	Status        HealthStatus
+	Message       string
+	LastHeartbeat time.Time
+	Stale         bool // app stopped sending heartbeats
+}
+
+// healthState keeps readiness and liveness reported by an app.
+type healthState struct {
+	mu                sync.Mutex
+	reported          bool // readiness was reported at least once
+	ready             bool
+	wasReady          bool
+	message           string
+	lastHeartbeat     time.Time
+	heartbeatInterval time.Duration
+}
+
+func newHealthState() *healthState {
+	return &healthState{}
+}
+
+func (h *healthState) setStatus(ready bool, message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.reported = true
+	h.ready = ready
+	h.wasReady = h.wasReady || ready
+	h.message = message
+}
+
+func (h *healthState) heartbeat(now time.Time, interval time.Duration) error {
+	if interval <= 0 {
+		return errInvalidHeartbeatInterval
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastHeartbeat = now
+	h.heartbeatInterval = interval
+
+	return nil
+}
+
+func (h *healthState) health(now time.Time) Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	health := Health{
+		Message:       h.message,
+		LastHeartbeat: h.lastHeartbeat,
+		Stale: !h.lastHeartbeat.IsZero() &&
+			now.Sub(h.lastHeartbeat) > MissedHeartbeats*h.heartbeatInterval,
+	}
+
+	switch {
+	case !h.reported && h.lastHeartbeat.IsZero():
+		health.Status = HealthUnknown
+	case health.Stale:
+		health.Status = HealthUnhealthy
+	case h.reported && !h.ready && !h.wasReady:
+		health.Status = HealthStarting
+	case h.reported && !h.ready:
+		health.Status = HealthUnhealthy
+	default:
+		health.Status = HealthHealthy
+	}
+
+	return health
+}
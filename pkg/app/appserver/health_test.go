@@ -0,0 +1,57 @@
+package appserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthState(t *testing.T) {
+	now := time.Now()
+	h := newHealthState()
+
+	assert.Equal(t, HealthUnknown, h.health(now).Status)
+
+	h.setStatus(false, "syncing")
+	assert.Equal(t, Health{Status: HealthStarting, Message: "syncing"}, h.health(now))
+
+	h.setStatus(true, "")
+	assert.Equal(t, HealthHealthy, h.health(now).Status)
+
+	h.setStatus(false, "lost connection")
+	assert.Equal(t, HealthUnhealthy, h.health(now).Status)
+
+	h.setStatus(true, "")
+	assert.Equal(t, errInvalidHeartbeatInterval, h.heartbeat(now, 0))
+	require.NoError(t, h.heartbeat(now, time.Second))
+
+	health := h.health(now.Add(MissedHeartbeats * time.Second))
+	assert.Equal(t, HealthHealthy, health.Status)
+	assert.False(t, health.Stale)
+
+	health = h.health(now.Add(MissedHeartbeats*time.Second + 1))
+	assert.Equal(t, HealthUnhealthy, health.Status)
+	assert.True(t, health.Stale)
+	assert.Equal(t, now, health.LastHeartbeat)
+
+	// heartbeats alone make app healthy
+	h = newHealthState()
+	require.NoError(t, h.heartbeat(now, time.Second))
+	assert.Equal(t, HealthHealthy, h.health(now).Status)
+}
+
+func TestRPCGateway_Health(t *testing.T) {
+	rpc := NewRPCGateway(nil)
+
+	require.NoError(t, rpc.SetStatus(&StatusReq{Ready: true, Message: "ok"}, nil))
+
+	interval := time.Minute
+	require.NoError(t, rpc.Heartbeat(&interval, nil))
+
+	health := rpc.health.health(time.Now())
+	assert.Equal(t, HealthHealthy, health.Status)
+	assert.Equal(t, "ok", health.Message)
+	assert.False(t, health.Stale)
+}
@@ -0,0 +1,590 @@
+package appserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// JSONRPCVersion is the version of JSON-RPC spoken by the JSON-RPC endpoint of the app server.
+const JSONRPCVersion = "2.0"
+
+// Error codes of the JSON-RPC endpoint. Codes from -32700 to -32600 are defined by JSON-RPC 2.0.
+const (
+	JSONRPCParseError     = -32700 // the message is not valid JSON, the connection is closed
+	JSONRPCInvalidRequest = -32600 // the message is not a valid request object
+	JSONRPCMethodNotFound = -32601 // the method does not exist
+	JSONRPCInvalidParams  = -32602 // the params are invalid for the method
+	JSONRPCCallFailed     = -32000 // the operation failed, the reason is in the message
+	JSONRPCNotAuthorized  = -32001 // `Hello` was not called successfully on the connection
+)
+
+var (
+	errNotAuthorized     = errors.New("not authorized, call Hello first")
+	errAlreadyAuthorized = errors.New("already authorized")
+	errUnknownAppKey     = errors.New("unknown app key")
+)
+
+// jsonRPCRequest is a JSON-RPC 2.0 request object. `ID` is nil for notifications.
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response object.
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+// jsonRPCError is a JSON-RPC 2.0 error object.
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// jsonNull is the id of responses to requests whose id can't be determined.
+var jsonNull = json.RawMessage("null")
+
+func newJSONRPCResponse(id json.RawMessage, result interface{}, err *jsonRPCError) *jsonRPCResponse {
+	if id == nil {
+		id = jsonNull
+	}
+
+	if result == nil && err == nil {
+		result = struct{}{}
+	}
+
+	return &jsonRPCResponse{JSONRPC: JSONRPCVersion, ID: id, Result: result, Error: err}
+}
+
+// jsonAddr is the JSON representation of `appnet.Addr`.
+type jsonAddr struct {
+	Net  appnet.Type   `json:"net"`
+	PK   cipher.PubKey `json:"pk"`
+	Port routing.Port  `json:"port"`
+}
+
+func (a jsonAddr) addr() appnet.Addr {
+	return appnet.Addr{Net: a.Net, PubKey: a.PK, Port: a.Port}
+}
+
+func newJSONAddr(addr appnet.Addr) jsonAddr {
+	return jsonAddr{Net: addr.Net, PK: addr.PubKey, Port: addr.Port}
+}
+
+// jsonDialOptions is the JSON representation of `router.DialOptions`.
+// Omitted fields take their values from `router.DefaultDialOptions`.
+type jsonDialOptions struct {
+	MinForwardRoutes *int     `json:"min_forward_routes"`
+	MaxForwardRoutes *int     `json:"max_forward_routes"`
+	MinConsumeRoutes *int     `json:"min_consume_routes"`
+	MaxConsumeRoutes *int     `json:"max_consume_routes"`
+	MinHops          *int     `json:"min_hops"`
+	MaxHops          *int     `json:"max_hops"`
+	TransportTypes   []string `json:"transport_types"`
+}
+
+func (o *jsonDialOptions) options() *router.DialOptions {
+	if o == nil {
+		return nil
+	}
+
+	opts := router.DefaultDialOptions()
+
+	for _, f := range []struct {
+		v   *int
+		opt *int
+	}{
+		{o.MinForwardRoutes, &opts.MinForwardRts},
+		{o.MaxForwardRoutes, &opts.MaxForwardRts},
+		{o.MinConsumeRoutes, &opts.MinConsumeRts},
+		{o.MaxConsumeRoutes, &opts.MaxConsumeRts},
+		{o.MinHops, &opts.MinHops},
+		{o.MaxHops, &opts.MaxHops},
+	} {
+		if f.v != nil {
+			*f.opt = *f.v
+		}
+	}
+
+	if o.TransportTypes != nil {
+		opts.TransportTypes = o.TransportTypes
+	}
+
+	return opts
+}
+
+// jsonIOErr is the JSON representation of `RPCIOErr`.
+type jsonIOErr struct {
+	Message   string `json:"message"`
+	EOF       bool   `json:"eof"`
+	Timeout   bool   `json:"timeout"`
+	Temporary bool   `json:"temporary"`
+}
+
+func newJSONIOErr(err *RPCIOErr) *jsonIOErr {
+	if err == nil {
+		return nil
+	}
+
+	return &jsonIOErr{
+		Message:   err.Text,
+		EOF:       !err.IsNetErr && err.Text == io.EOF.Error(),
+		Timeout:   err.IsTimeoutErr,
+		Temporary: err.IsTemporaryErr,
+	}
+}
+
+type (
+	jsonHelloReq struct {
+		AppKey appcommon.Key `json:"app_key"`
+	}
+
+	jsonDialReq struct {
+		Remote   jsonAddr         `json:"remote"`
+		Deadline *time.Time       `json:"deadline"`
+		Opts     *jsonDialOptions `json:"opts"`
+	}
+
+	jsonDialResp struct {
+		ConnID    uint16       `json:"conn_id"`
+		LocalPort routing.Port `json:"local_port"`
+	}
+
+	jsonListenReq struct {
+		Local jsonAddr `json:"local"`
+	}
+
+	jsonListenResp struct {
+		LisID uint16 `json:"lis_id"`
+	}
+
+	jsonLisReq struct {
+		LisID uint16 `json:"lis_id"`
+	}
+
+	jsonAcceptResp struct {
+		ConnID uint16   `json:"conn_id"`
+		Remote jsonAddr `json:"remote"`
+	}
+
+	jsonConnReq struct {
+		ConnID uint16 `json:"conn_id"`
+	}
+
+	jsonReadReq struct {
+		ConnID uint16 `json:"conn_id"`
+		BufLen int    `json:"buf_len"`
+	}
+
+	jsonReadResp struct {
+		Data  []byte     `json:"data"`
+		Error *jsonIOErr `json:"error"`
+	}
+
+	jsonWriteReq struct {
+		ConnID uint16 `json:"conn_id"`
+		Data   []byte `json:"data"`
+	}
+
+	jsonWriteResp struct {
+		N     int        `json:"n"`
+		Error *jsonIOErr `json:"error"`
+	}
+
+	jsonDeadlineReq struct {
+		ConnID   uint16     `json:"conn_id"`
+		Deadline *time.Time `json:"deadline"`
+	}
+
+	jsonStatusReq struct {
+		Ready   bool   `json:"ready"`
+		Message string `json:"message"`
+	}
+
+	jsonHeartbeatReq struct {
+		IntervalMS int64 `json:"interval_ms"`
+	}
+)
+
+// jsonRPCMethod calls a method of `gateway`, `decode` decodes the params of the call.
+type jsonRPCMethod func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error)
+
+// jsonRPCMethods maps names of methods of the JSON-RPC endpoint to `RPCGateway` calls.
+// `Hello` is handled by the connection itself.
+var jsonRPCMethods = map[string]jsonRPCMethod{
+	"Dial":         jsonDial((*RPCGateway).DialContext),
+	"DialPacket":   jsonDial((*RPCGateway).DialPacket),
+	"Listen":       jsonListen((*RPCGateway).Listen),
+	"ListenPacket": jsonListen((*RPCGateway).ListenPacket),
+	"Accept": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonLisReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		var resp AcceptResp
+		if err := gateway.Accept(&req.LisID, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonAcceptResp{ConnID: resp.ConnID, Remote: newJSONAddr(resp.Remote)}, nil
+	},
+	"Read": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonReadReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		if req.BufLen <= 0 {
+			return nil, invalidParams(errors.New("buf_len should be positive"))
+		}
+
+		var resp ReadResp
+		if err := gateway.Read(&ReadReq{ConnID: req.ConnID, BufLen: req.BufLen}, &resp); err != nil {
+			return nil, err
+		}
+
+		data := resp.B
+		if data == nil {
+			data = []byte{}
+		}
+
+		return jsonReadResp{Data: data, Error: newJSONIOErr(resp.Err)}, nil
+	},
+	"Write": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonWriteReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		var resp WriteResp
+		if err := gateway.Write(&WriteReq{ConnID: req.ConnID, B: req.Data}, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonWriteResp{N: resp.N, Error: newJSONIOErr(resp.Err)}, nil
+	},
+	"CloseConn": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonConnReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		return nil, gateway.CloseConn(&req.ConnID, nil)
+	},
+	"CloseListener": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonLisReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		return nil, gateway.CloseListener(&req.LisID, nil)
+	},
+	"SetDeadline":      jsonDeadline((*RPCGateway).SetDeadline),
+	"SetReadDeadline":  jsonDeadline((*RPCGateway).SetReadDeadline),
+	"SetWriteDeadline": jsonDeadline((*RPCGateway).SetWriteDeadline),
+	"SetStatus": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonStatusReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		return nil, gateway.SetStatus(&StatusReq{Ready: req.Ready, Message: req.Message}, nil)
+	},
+	"Heartbeat": func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonHeartbeatReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		interval := time.Duration(req.IntervalMS) * time.Millisecond
+
+		return nil, gateway.Heartbeat(&interval, nil)
+	},
+}
+
+func jsonDial(dial func(*RPCGateway, *DialReq, *DialResp) error) jsonRPCMethod {
+	return func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonDialReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		dialReq := DialReq{Remote: req.Remote.addr(), Opts: req.Opts.options()}
+		if req.Deadline != nil {
+			dialReq.Deadline = *req.Deadline
+		}
+
+		var resp DialResp
+		if err := dial(gateway, &dialReq, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonDialResp{ConnID: resp.ConnID, LocalPort: resp.LocalPort}, nil
+	}
+}
+
+func jsonListen(listen func(*RPCGateway, *appnet.Addr, *uint16) error) jsonRPCMethod {
+	return func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonListenReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		local := req.Local.addr()
+
+		var lisID uint16
+		if err := listen(gateway, &local, &lisID); err != nil {
+			return nil, err
+		}
+
+		return jsonListenResp{LisID: lisID}, nil
+	}
+}
+
+func jsonDeadline(setDeadline func(*RPCGateway, *DeadlineReq, *struct{}) error) jsonRPCMethod {
+	return func(gateway *RPCGateway, decode func(v interface{}) error) (interface{}, error) {
+		var req jsonDeadlineReq
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+
+		deadlineReq := DeadlineReq{ConnID: req.ConnID}
+		if req.Deadline != nil {
+			deadlineReq.Deadline = *req.Deadline
+		}
+
+		return nil, setDeadline(gateway, &deadlineReq, nil)
+	}
+}
+
+// invalidParamsError marks errors caused by invalid params of a call.
+type invalidParamsError struct {
+	err error
+}
+
+func (e invalidParamsError) Error() string {
+	return e.err.Error()
+}
+
+func invalidParams(err error) error {
+	return invalidParamsError{err: err}
+}
+
+// jsonRPCConn serves JSON-RPC 2.0 on a single app connection.
+// Messages are JSON values, requests are served concurrently and responses are written as they are ready.
+type jsonRPCConn struct {
+	s    *Server
+	conn net.Conn
+	log  *logging.Logger
+
+	encMu sync.Mutex
+	enc   *json.Encoder
+
+	mu      sync.RWMutex
+	gateway *RPCGateway // gateway of the app, set by `Hello`
+}
+
+func newJSONRPCConn(s *Server, conn net.Conn) *jsonRPCConn {
+	return &jsonRPCConn{
+		s:    s,
+		conn: conn,
+		log:  s.log,
+		enc:  json.NewEncoder(conn),
+	}
+}
+
+// serve reads messages until the connection is closed or a message is not valid JSON.
+func (c *jsonRPCConn) serve() {
+	dec := json.NewDecoder(c.conn)
+
+	for {
+		var msg json.RawMessage
+		if err := dec.Decode(&msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				c.send(newJSONRPCResponse(nil, nil, &jsonRPCError{Code: JSONRPCParseError, Message: err.Error()}))
+			}
+
+			if err := c.conn.Close(); err != nil {
+				c.log.WithError(err).Debug("Error closing JSON-RPC conn.")
+			}
+
+			return
+		}
+
+		c.handleMessage(msg)
+	}
+}
+
+// handleMessage handles a single request or a batch of requests.
+// `Hello` is handled before following messages are read, so that it may be pipelined.
+func (c *jsonRPCConn) handleMessage(msg json.RawMessage) {
+	msg = bytes.TrimSpace(msg)
+
+	if len(msg) == 0 || msg[0] != '[' {
+		var req jsonRPCRequest
+		if err := json.Unmarshal(msg, &req); err != nil || req.Method != "Hello" {
+			go c.send(c.handleRequest(msg))
+			return
+		}
+
+		c.send(c.handleRequest(msg))
+
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(msg, &batch); err != nil || len(batch) == 0 {
+		c.send(newJSONRPCResponse(nil, nil, &jsonRPCError{Code: JSONRPCInvalidRequest, Message: "invalid batch"}))
+		return
+	}
+
+	go func() {
+		responses := make([]*jsonRPCResponse, 0, len(batch))
+
+		for _, msg := range batch {
+			if resp := c.handleRequest(msg); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+
+		if len(responses) != 0 {
+			c.send(responses)
+		}
+	}()
+}
+
+// handleRequest handles a single request. Returns nil for notifications.
+func (c *jsonRPCConn) handleRequest(msg json.RawMessage) *jsonRPCResponse {
+	var req jsonRPCRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return newJSONRPCResponse(nil, nil, &jsonRPCError{Code: JSONRPCInvalidRequest, Message: "request should be an object"})
+	}
+
+	if req.JSONRPC != JSONRPCVersion || req.Method == "" {
+		return newJSONRPCResponse(req.ID, nil, &jsonRPCError{
+			Code:    JSONRPCInvalidRequest,
+			Message: fmt.Sprintf("jsonrpc should be %q and method should be set", JSONRPCVersion),
+		})
+	}
+
+	result, rpcErr := c.call(req.Method, req.Params)
+
+	if req.ID == nil {
+		return nil
+	}
+
+	return newJSONRPCResponse(req.ID, result, rpcErr)
+}
+
+// call calls `method` with `params`.
+func (c *jsonRPCConn) call(method string, params json.RawMessage) (interface{}, *jsonRPCError) {
+	decode := func(v interface{}) error {
+		if len(params) == 0 {
+			return nil
+		}
+
+		if err := json.Unmarshal(params, v); err != nil {
+			return invalidParams(err)
+		}
+
+		return nil
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	if method == "Hello" {
+		err = c.hello(decode)
+	} else {
+		m, ok := jsonRPCMethods[method]
+		if !ok {
+			return nil, &jsonRPCError{Code: JSONRPCMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+		}
+
+		c.mu.RLock()
+		gateway := c.gateway
+		c.mu.RUnlock()
+
+		if gateway == nil {
+			return nil, &jsonRPCError{Code: JSONRPCNotAuthorized, Message: errNotAuthorized.Error()}
+		}
+
+		result, err = m(gateway, decode)
+	}
+
+	if err == nil {
+		return result, nil
+	}
+
+	var paramsErr invalidParamsError
+	if errors.As(err, &paramsErr) {
+		return nil, &jsonRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+	}
+
+	if errors.Is(err, errUnknownAppKey) {
+		return nil, &jsonRPCError{Code: JSONRPCNotAuthorized, Message: err.Error()}
+	}
+
+	return nil, &jsonRPCError{Code: JSONRPCCallFailed, Message: err.Error()}
+}
+
+// hello binds the connection to the gateway of the app with the key passed in params.
+func (c *jsonRPCConn) hello(decode func(v interface{}) error) error {
+	var req jsonHelloReq
+	if err := decode(&req); err != nil {
+		return err
+	}
+
+	gateway, ok := c.s.gateway(req.AppKey)
+	if !ok {
+		return errUnknownAppKey
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gateway != nil {
+		return errAlreadyAuthorized
+	}
+
+	c.gateway = gateway
+
+	return nil
+}
+
+// send writes a response or a batch of responses.
+func (c *jsonRPCConn) send(v interface{}) {
+	if resp, ok := v.(*jsonRPCResponse); ok && resp == nil {
+		return
+	}
+
+	c.encMu.Lock()
+	defer c.encMu.Unlock()
+
+	if err := c.enc.Encode(v); err != nil {
+		c.log.WithError(err).Debug("Error writing JSON-RPC response.")
+	}
+}
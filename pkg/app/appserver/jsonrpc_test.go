@@ -0,0 +1,277 @@
+package appserver_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
+)
+
+// jsonRPCClient drives the JSON-RPC endpoint with raw bytes.
+type jsonRPCClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// call writes `req` as is and returns the next line written by the server.
+func (c *jsonRPCClient) call(t *testing.T, req string) string {
+	c.notify(t, req)
+
+	require.NoError(t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	resp, err := c.r.ReadString('\n')
+	require.NoError(t, err)
+
+	return resp
+}
+
+// notify writes `req` as is, expecting no response.
+func (c *jsonRPCClient) notify(t *testing.T, req string) {
+	_, err := io.WriteString(c.conn, req+"\n")
+	require.NoError(t, err)
+}
+
+func TestServer_ListenAndServeJSON(t *testing.T) {
+	l := logging.MustGetLogger("app_server")
+
+	s := appserver.New(l, appcommon.DefaultServerAddr)
+
+	appKey := appcommon.GenerateAppKey()
+	require.NoError(t, s.Register(appKey))
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.ListenAndServeJSON(appcommon.DefaultJSONServerAddr)
+	}()
+
+	time.Sleep(sleepDelay)
+
+	dmsgLocal, dmsgRemote, remote := prepAddrs()
+
+	var noErr error
+
+	dialConn := &appcommon.MockConn{}
+	dialConn.On("LocalAddr").Return(dmsgLocal)
+	dialConn.On("RemoteAddr").Return(dmsgRemote)
+	dialConn.On("Write", []byte("hello")).Return(5, noErr)
+	dialConn.On("Read", mock.Anything).Run(func(args mock.Arguments) {
+		copy(args.Get(0).([]byte), "world")
+	}).Return(5, noErr).Once()
+	dialConn.On("Read", mock.Anything).Return(0, io.EOF)
+	dialConn.On("SetDeadline", time.Time{}).Return(noErr)
+	dialConn.On("SetReadDeadline", time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)).Return(noErr)
+	dialConn.On("Close").Return(noErr)
+
+	acceptConn := &appcommon.MockConn{}
+	acceptConn.On("LocalAddr").Return(dmsgLocal)
+	acceptConn.On("RemoteAddr").Return(dmsgRemote)
+
+	lis := &appcommon.MockListener{}
+	lis.On("Accept").Return(acceptConn, noErr)
+	lis.On("Close").Return(noErr)
+
+	local := appnet.Addr{Net: appnet.TypeDmsg, PubKey: dmsgLocal.PK, Port: 10}
+
+	appnet.ClearNetworkers()
+
+	n := &appnet.MockNetworker{}
+	// omitted dial options take default values
+	wantOpts := router.DefaultDialOptions()
+	wantOpts.MaxHops = 0
+	matchOpts := mock.MatchedBy(func(ctx context.Context) bool {
+		return reflect.DeepEqual(wantOpts, appnet.DialOptionsFromContext(ctx))
+	})
+	n.On("DialContext", matchOpts, remote).Return(dialConn, noErr)
+	n.On("ListenContext", mock.Anything, local).Return(lis, noErr)
+
+	require.NoError(t, appnet.AddNetworker(appnet.TypeDmsg, n))
+
+	conn, err := net.Dial("tcp", appcommon.DefaultJSONServerAddr)
+	require.NoError(t, err)
+
+	c := &jsonRPCClient{conn: conn, r: bufio.NewReader(conn)}
+
+	tt := []struct {
+		name string
+		req  string
+		want string
+	}{
+		{
+			name: "call before hello",
+			req:  `{"jsonrpc":"2.0","id":1,"method":"CloseConn","params":{"conn_id":1}}`,
+			want: `{"jsonrpc":"2.0","id":1,"error":{"code":-32001,"message":"not authorized, call Hello first"}}`,
+		},
+		{
+			name: "hello with unknown key",
+			req:  `{"jsonrpc":"2.0","id":"a","method":"Hello","params":{"app_key":"unknown"}}`,
+			want: `{"jsonrpc":"2.0","id":"a","error":{"code":-32001,"message":"unknown app key"}}`,
+		},
+		{
+			name: "hello",
+			req:  fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"Hello","params":{"app_key":%q}}`, appKey),
+			want: `{"jsonrpc":"2.0","id":2,"result":{}}`,
+		},
+		{
+			name: "second hello",
+			req:  fmt.Sprintf(`{"jsonrpc":"2.0","id":3,"method":"Hello","params":{"app_key":%q}}`, appKey),
+			want: `{"jsonrpc":"2.0","id":3,"error":{"code":-32000,"message":"already authorized"}}`,
+		},
+		{
+			name: "dial",
+			req: fmt.Sprintf(`{"jsonrpc":"2.0","id":4,"method":"Dial","params":{"remote":{"net":"dmsg","pk":%q,"port":11},`+
+				`"opts":{"max_hops":0}}}`,
+				remote.PubKey.Hex()),
+			want: `{"jsonrpc":"2.0","id":4,"result":{"conn_id":1,"local_port":10}}`,
+		},
+		{
+			name: "write",
+			req:  `{"jsonrpc":"2.0","id":5,"method":"Write","params":{"conn_id":1,"data":"aGVsbG8="}}`,
+			want: `{"jsonrpc":"2.0","id":5,"result":{"n":5,"error":null}}`,
+		},
+		{
+			name: "read",
+			req:  `{"jsonrpc":"2.0","id":6,"method":"Read","params":{"conn_id":1,"buf_len":16}}`,
+			want: `{"jsonrpc":"2.0","id":6,"result":{"data":"d29ybGQ=","error":null}}`,
+		},
+		{
+			name: "read at eof",
+			req:  `{"jsonrpc":"2.0","id":7,"method":"Read","params":{"conn_id":1,"buf_len":16}}`,
+			want: `{"jsonrpc":"2.0","id":7,"result":{"data":"",` +
+				`"error":{"message":"EOF","eof":true,"timeout":false,"temporary":false}}}`,
+		},
+		{
+			name: "clear deadline",
+			req:  `{"jsonrpc":"2.0","id":8,"method":"SetDeadline","params":{"conn_id":1,"deadline":null}}`,
+			want: `{"jsonrpc":"2.0","id":8,"result":{}}`,
+		},
+		{
+			name: "set read deadline",
+			req:  `{"jsonrpc":"2.0","id":9,"method":"SetReadDeadline","params":{"conn_id":1,"deadline":"2030-01-02T03:04:05Z"}}`,
+			want: `{"jsonrpc":"2.0","id":9,"result":{}}`,
+		},
+		{
+			name: "close conn",
+			req:  `{"jsonrpc":"2.0","id":10,"method":"CloseConn","params":{"conn_id":1}}`,
+			want: `{"jsonrpc":"2.0","id":10,"result":{}}`,
+		},
+		{
+			name: "close closed conn",
+			req:  `{"jsonrpc":"2.0","id":11,"method":"CloseConn","params":{"conn_id":1}}`,
+			want: `{"jsonrpc":"2.0","id":11,"error":{"code":-32000,"message":"no conn: no value with id 1"}}`,
+		},
+		{
+			name: "listen",
+			req: fmt.Sprintf(`{"jsonrpc":"2.0","id":12,"method":"Listen","params":{"local":{"net":"dmsg","pk":%q,"port":10}}}`,
+				local.PubKey.Hex()),
+			want: `{"jsonrpc":"2.0","id":12,"result":{"lis_id":1}}`,
+		},
+		{
+			name: "accept",
+			req:  `{"jsonrpc":"2.0","id":13,"method":"Accept","params":{"lis_id":1}}`,
+			want: fmt.Sprintf(`{"jsonrpc":"2.0","id":13,"result":{"conn_id":2,"remote":{"net":"dmsg","pk":%q,"port":11}}}`,
+				remote.PubKey.Hex()),
+		},
+		{
+			name: "close listener",
+			req:  `{"jsonrpc":"2.0","id":14,"method":"CloseListener","params":{"lis_id":1}}`,
+			want: `{"jsonrpc":"2.0","id":14,"result":{}}`,
+		},
+		{
+			name: "set status",
+			req:  `{"jsonrpc":"2.0","id":15,"method":"SetStatus","params":{"ready":true,"message":"serving"}}`,
+			want: `{"jsonrpc":"2.0","id":15,"result":{}}`,
+		},
+		{
+			name: "invalid heartbeat interval",
+			req:  `{"jsonrpc":"2.0","id":16,"method":"Heartbeat","params":{"interval_ms":0}}`,
+			want: `{"jsonrpc":"2.0","id":16,"error":{"code":-32000,"message":"heartbeat interval should be positive"}}`,
+		},
+		{
+			name: "unknown method",
+			req:  `{"jsonrpc":"2.0","id":17,"method":"Explode"}`,
+			want: `{"jsonrpc":"2.0","id":17,"error":{"code":-32601,"message":"method \"Explode\" not found"}}`,
+		},
+		{
+			name: "invalid params",
+			req:  `{"jsonrpc":"2.0","id":18,"method":"Read","params":{"conn_id":1,"buf_len":0}}`,
+			want: `{"jsonrpc":"2.0","id":18,"error":{"code":-32602,"message":"buf_len should be positive"}}`,
+		},
+		{
+			name: "wrong version",
+			req:  `{"jsonrpc":"1.0","id":19,"method":"Heartbeat"}`,
+			want: `{"jsonrpc":"2.0","id":19,"error":{"code":-32600,"message":"jsonrpc should be \"2.0\" and method should be set"}}`,
+		},
+		{
+			name: "not an object",
+			req:  `42`,
+			want: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"request should be an object"}}`,
+		},
+		{
+			name: "batch",
+			req: `[{"jsonrpc":"2.0","id":20,"method":"Heartbeat","params":{"interval_ms":1000}},` +
+				`{"jsonrpc":"2.0","method":"Heartbeat","params":{"interval_ms":1000}},` +
+				`{"jsonrpc":"2.0","id":21,"method":"Explode"}]`,
+			want: `[{"jsonrpc":"2.0","id":20,"result":{}},` +
+				`{"jsonrpc":"2.0","id":21,"error":{"code":-32601,"message":"method \"Explode\" not found"}}]`,
+		},
+		{
+			name: "empty batch",
+			req:  `[]`,
+			want: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid batch"}}`,
+		},
+	}
+
+	for _, tc := range tt {
+		if !t.Run(tc.name, func(t *testing.T) {
+			require.JSONEq(t, tc.want, c.call(t, tc.req))
+		}) {
+			t.FailNow()
+		}
+	}
+
+	t.Run("notification", func(t *testing.T) {
+		c.notify(t, `{"jsonrpc":"2.0","method":"Heartbeat","params":{"interval_ms":1000}}`)
+
+		// the next response is the response to the next request
+		resp := c.call(t, `{"jsonrpc":"2.0","id":22,"method":"SetStatus","params":{"ready":true}}`)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":22,"result":{}}`, resp)
+	})
+
+	t.Run("parse error closes connection", func(t *testing.T) {
+		resp := c.call(t, `{"jsonrpc":`+"\n}{")
+		require.True(t, strings.Contains(resp, `"code":-32700`), resp)
+
+		_, err := c.r.ReadString('\n')
+		require.Equal(t, io.EOF, err)
+	})
+
+	require.NoError(t, s.Close())
+
+	err = <-errCh
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "use of closed network connection"))
+}
+
+func TestServer_ListenAndServeJSON_UnknownListenAddr(t *testing.T) {
+	s := appserver.New(logging.MustGetLogger("app_server"), appcommon.DefaultServerAddr)
+
+	err := s.ListenAndServeJSON("not an address")
+	require.Error(t, err)
+
+	require.NoError(t, s.Close())
+}
@@ -31,6 +31,27 @@ func (_m *MockProcManager) Exists(name string) bool {
 	return r0
 }
 
+// Health provides a mock function with given fields: name
+func (_m *MockProcManager) Health(name string) (Health, error) {
+	ret := _m.Called(name)
+
+	var r0 Health
+	if rf, ok := ret.Get(0).(func(string) Health); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(Health)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Range provides a mock function with given fields: next
 func (_m *MockProcManager) Range(next func(string, *Proc) bool) {
 	_m.Called(next)
@@ -76,6 +97,27 @@ func (_m *MockProcManager) StopAll() {
 	_m.Called()
 }
 
+// Usage provides a mock function with given fields: name
+func (_m *MockProcManager) Usage(name string) (ProcUsage, error) {
+	ret := _m.Called(name)
+
+	var r0 ProcUsage
+	if rf, ok := ret.Get(0).(func(string) ProcUsage); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(ProcUsage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Wait provides a mock function with given fields: name
 func (_m *MockProcManager) Wait(name string) error {
 	ret := _m.Called(name)
@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
@@ -18,8 +19,18 @@ import (
 var (
 	errProcAlreadyRunning = errors.New("process already running")
 	errProcNotStarted     = errors.New("process is not started")
+	errUsageNotSupported  = errors.New("resource usage is not supported on this platform")
 )
 
+// ProcStopTimeout is how long an app is given to exit after being interrupted, before it is killed.
+var ProcStopTimeout = 5 * time.Second
+
+// ProcUsage is resource usage of a running app.
+type ProcUsage struct {
+	MemoryRSS uint64        // resident memory in bytes
+	CPUTime   time.Duration // user and system CPU time
+}
+
 // Proc is a wrapper for a skywire app. Encapsulates
 // the running process itself and the RPC server for
 // app/visor communication.
@@ -31,6 +42,12 @@ type Proc struct {
 	isRunning int32
 	waitMx    sync.Mutex
 	waitErr   error
+	done      chan struct{}
+	health    *healthState
+
+	started chan struct{} // closed once the start of the process succeeds or fails
+	pid     int           // PID of the process, zero if it failed to start
+	unixSrv *unixServer   // app server on the unix socket of the app, if configured
 }
 
 // NewProc constructs `Proc`.
@@ -46,8 +63,14 @@ func NewProc(log *logging.Logger, c appcommon.Config, args []string, stdout, std
 	)
 
 	env := make([]string, 0, 4)
-	env = append(env, fmt.Sprintf(appKeyEnvFormat, key))
-	env = append(env, fmt.Sprintf(serverAddrEnvFormat, c.ServerAddr))
+	if c.UnixSocket {
+		// the app is authenticated by its PID, so no secret is passed in the environment
+		env = append(env, appcommon.EnvServerNet+"=unix")
+		env = append(env, fmt.Sprintf(serverAddrEnvFormat, unixSocketPath(c)))
+	} else {
+		env = append(env, fmt.Sprintf(appKeyEnvFormat, key))
+		env = append(env, fmt.Sprintf(serverAddrEnvFormat, c.ServerAddr))
+	}
 	env = append(env, fmt.Sprintf(visorPKEnvFormat, c.VisorPK))
 
 	cmd := exec.Command(binaryPath, args...) // nolint:gosec
@@ -58,11 +81,26 @@ func NewProc(log *logging.Logger, c appcommon.Config, args []string, stdout, std
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
+	attr, err := sysProcAttr(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.SysProcAttr = attr
+
+	if err := limitCmd(cmd, c.Limits); err != nil {
+		return nil, fmt.Errorf("failed to set limits of %s: %w", c.Name, err)
+	}
+
 	return &Proc{
 		key:    key,
 		config: c,
 		log:    log,
 		cmd:    cmd,
+		done:   make(chan struct{}),
+		health: newHealthState(),
+
+		started: make(chan struct{}),
 	}, nil
 }
 
@@ -72,15 +110,39 @@ func (p *Proc) Start() error {
 		return errProcAlreadyRunning
 	}
 
+	if p.config.UnixSocket {
+		if err := p.listenUnix(); err != nil {
+			close(p.started)
+			return fmt.Errorf("failed to serve %s on unix socket: %w", p.config.Name, err)
+		}
+	}
+
 	if err := p.cmd.Start(); err != nil {
+		close(p.started)
+		p.closeUnixServer()
+
 		return err
 	}
 
+	pid := p.cmd.Process.Pid
+
+	p.pid = pid
+	close(p.started)
+
 	// acquire lock immediately
 	p.waitMx.Lock()
 	go func() {
 		defer p.waitMx.Unlock()
+		defer close(p.done)
+
 		p.waitErr = p.cmd.Wait()
+
+		p.closeUnixServer()
+
+		// children of the app should not outlive it
+		if err := signalGroup(pid, syscall.SIGKILL); err != nil {
+			p.log.WithError(err).Warnf("Failed to kill processes of %s", p.config.Name)
+		}
 	}()
 
 	return nil
@@ -92,11 +154,23 @@ func (p *Proc) Stop() error {
 		return errProcNotStarted
 	}
 
-	err := p.cmd.Process.Signal(os.Interrupt)
+	// the whole process group is interrupted, so that children of the app stop too
+	err := signalGroup(p.cmd.Process.Pid, syscall.SIGINT)
 	if err != nil {
 		return err
 	}
 
+	// processes left in the group may also keep output of the app open, so that it is never considered exited
+	select {
+	case <-p.done:
+	case <-time.After(ProcStopTimeout):
+		p.log.Warnf("%s did not stop in %s, killing it", p.config.Name, ProcStopTimeout)
+
+		if err := signalGroup(p.cmd.Process.Pid, syscall.SIGKILL); err != nil {
+			return err
+		}
+	}
+
 	// the lock will be acquired as soon as the cmd finishes its work
 	p.waitMx.Lock()
 	defer p.waitMx.Unlock()
@@ -117,11 +191,62 @@ func (p *Proc) Wait() error {
 	return p.waitErr
 }
 
+// Usage returns resource usage of the application.
+func (p *Proc) Usage() (ProcUsage, error) {
+	if atomic.LoadInt32(&p.isRunning) != 1 {
+		return ProcUsage{}, errProcNotStarted
+	}
+
+	return procUsage(p.cmd.Process.Pid)
+}
+
+// Health returns health reported by the application.
+func (p *Proc) Health() Health {
+	return p.health.health(time.Now())
+}
+
 // IsRunning checks whether application cmd is running.
 func (p *Proc) IsRunning() bool {
 	return atomic.LoadInt32(&p.isRunning) == 1
 }
 
+// listenUnix starts serving the app on the unix socket in its work dir.
+func (p *Proc) listenUnix() error {
+	gateway := NewRPCGateway(logging.MustGetLogger(fmt.Sprintf("app_gateway:%s", p.config.Name)))
+	gateway.health = p.health
+
+	srv, err := newUnixServer(p.log, unixSocketPath(p.config), p.config.Credential, gateway, p.waitPID)
+	if err != nil {
+		return err
+	}
+
+	p.unixSrv = srv
+
+	return nil
+}
+
+// waitPID waits for the start of the process and returns its PID, false if it failed to start.
+func (p *Proc) waitPID() (int, bool) {
+	<-p.started
+
+	return p.pid, p.pid != 0
+}
+
+func (p *Proc) closeUnixServer() {
+	if p.unixSrv == nil {
+		return
+	}
+
+	if err := p.unixSrv.Close(); err != nil {
+		p.log.WithError(err).Warnf("Failed to close unix socket of %s", p.config.Name)
+	}
+}
+
+// unixSocketPath returns the path of the unix socket of the app server of the app.
+func unixSocketPath(c appcommon.Config) string {
+	return filepath.Join(c.WorkDir, appcommon.UnixSocketName)
+}
+
 // getBinaryPath formats binary path using app dir, name and version.
 func getBinaryPath(dir, name string) string {
 	return filepath.Join(dir, name)
@@ -0,0 +1,186 @@
+//go:build linux
+// +build linux
+
+package appserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc, which is 100 on all supported architectures.
+const clockTicks = 100
+
+// envLimits passes resource limits of an app to the limits helper, see ExecWithLimits.
+const envLimits = "SW_APP_LIMITS"
+
+// limitCmd makes `cmd` start the app through the limits helper, the visor binary itself, which sets the limits
+// and execs the app. So the limits apply from the first instruction of the app, and to all of its children.
+func limitCmd(cmd *exec.Cmd, l appcommon.Limits) error {
+	if !l.IsSet() {
+		return nil
+	}
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	cmd.Args = append([]string{cmd.Path}, cmd.Args...)
+	cmd.Path = "/proc/self/exe"
+	cmd.Env = append(cmd.Env, envLimits+"="+string(data))
+
+	return nil
+}
+
+// ExecWithLimits runs the limits helper if the process is started as one for an app with resource limits:
+// it sets the limits and replaces the process with the app. Otherwise it returns immediately.
+// It should be called first thing in main of binaries which run apps, such as the visor.
+func ExecWithLimits() {
+	v, ok := os.LookupEnv(envLimits)
+	if !ok {
+		return
+	}
+
+	if err := execWithLimits(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start app with limits: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func execWithLimits(v string) error {
+	if len(os.Args) < 2 {
+		return errors.New("app binary is not given")
+	}
+
+	var l appcommon.Limits
+	if err := json.Unmarshal([]byte(v), &l); err != nil {
+		return fmt.Errorf("invalid limits: %v", err)
+	}
+
+	if err := setLimits(l); err != nil {
+		return err
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, envLimits+"=") {
+			env = append(env, e)
+		}
+	}
+
+	return syscall.Exec(os.Args[1], os.Args[1:], env)
+}
+
+// setLimits applies resource limits to the current process.
+func setLimits(l appcommon.Limits) error {
+	limits := []struct {
+		resource int
+		value    uint64
+		name     string
+	}{
+		{syscall.RLIMIT_AS, l.MaxMemory, "memory"},
+		{syscall.RLIMIT_CPU, l.MaxCPUTime, "CPU time"},
+		{syscall.RLIMIT_NOFILE, l.MaxOpenFiles, "open files"},
+	}
+
+	for _, limit := range limits {
+		if limit.value == 0 {
+			continue
+		}
+
+		if err := syscall.Setrlimit(limit.resource, &syscall.Rlimit{Cur: limit.value, Max: limit.value}); err != nil {
+			return fmt.Errorf("failed to limit %s: %v", limit.name, err)
+		}
+	}
+
+	return nil
+}
+
+// procUsage reads resource usage of a process from /proc.
+func procUsage(pid int) (ProcUsage, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ProcUsage{}, err
+	}
+
+	return parseProcStat(string(data), os.Getpagesize())
+}
+
+// parseProcStat parses /proc/[pid]/stat, see proc(5).
+func parseProcStat(stat string, pageSize int) (ProcUsage, error) {
+	// The command name may contain spaces and parentheses, fields are counted from its end.
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return ProcUsage{}, fmt.Errorf("malformed stat: %q", stat)
+	}
+
+	// Fields after the command name start from the 3rd one, state.
+	fields := strings.Fields(stat[i+1:])
+
+	const (
+		utimeField = 14 - 3
+		stimeField = 15 - 3
+		rssField   = 24 - 3
+	)
+
+	if len(fields) <= rssField {
+		return ProcUsage{}, fmt.Errorf("malformed stat: %q", stat)
+	}
+
+	var values [3]uint64
+
+	for j, field := range []int{utimeField, stimeField, rssField} {
+		v, err := strconv.ParseUint(fields[field], 10, 64)
+		if err != nil {
+			return ProcUsage{}, fmt.Errorf("malformed stat: %v", err)
+		}
+
+		values[j] = v
+	}
+
+	return ProcUsage{
+		MemoryRSS: values[2] * uint64(pageSize),
+		CPUTime:   time.Duration(values[0]+values[1]) * time.Second / clockTicks,
+	}, nil
+}
+
+// peerCredSupported tells whether peerPID is able to identify processes.
+const peerCredSupported = true
+
+// peerPID returns the PID of the process which connected `conn`.
+func peerPID(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		cred    *syscall.Ucred
+		credErr error
+	)
+
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+
+	if credErr != nil {
+		return 0, credErr
+	}
+
+	return int(cred.Pid), nil
+}
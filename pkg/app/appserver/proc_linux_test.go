@@ -0,0 +1,170 @@
+//go:build linux
+// +build linux
+
+package appserver
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+func TestParseProcStat(t *testing.T) {
+	stat := "42 (a (b) c) S 1 42 42 0 -1 4194304 80 0 0 0 150 50 0 0 20 0 1 0 658310 2703360 313 18446744073709551615 0"
+
+	usage, err := parseProcStat(stat, 4096)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(313*4096), usage.MemoryRSS)
+	assert.Equal(t, 2*time.Second, usage.CPUTime)
+
+	_, err = parseProcStat("42 (a) S 1 42", 4096)
+	assert.Error(t, err)
+}
+
+func TestProc_LimitsAndGroup(t *testing.T) {
+	// background jobs of non-interactive shell ignore SIGINT, so the child outlives its parent unless killed
+	defer func(timeout time.Duration) { ProcStopTimeout = timeout }(ProcStopTimeout)
+	ProcStopTimeout = 100 * time.Millisecond
+
+	var stdout syncBuffer
+
+	c := appcommon.Config{
+		Name:      "sh",
+		BinaryDir: "/bin",
+		Limits:    appcommon.Limits{MaxOpenFiles: 64, MaxCPUTime: 100},
+	}
+
+	p, err := NewProc(logging.MustGetLogger("proc"), c, []string{"-c", "sleep 100 & echo $!; wait"}, &stdout, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	var childPID int
+
+	require.Eventually(t, func() bool {
+		childPID, err = strconv.Atoi(strings.TrimSpace(stdout.String()))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// limits are set before the app is executed, so its children are limited too
+	for _, pid := range []int{p.cmd.Process.Pid, childPID} {
+		limits, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+		require.NoError(t, err)
+		assert.Regexp(t, `Max open files\s+64\s+64`, string(limits))
+		assert.Regexp(t, `Max cpu time\s+100\s+100`, string(limits))
+	}
+
+	usage, err := p.Usage()
+	require.NoError(t, err)
+	assert.NotZero(t, usage.MemoryRSS)
+
+	require.NoError(t, p.Stop())
+
+	// the child is either gone or a zombie waiting to be reaped
+	require.Eventually(t, func() bool {
+		stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", childPID))
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, time.Second, 10*time.Millisecond)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestUnixServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix-server")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	path := filepath.Join(dir, appcommon.UnixSocketName)
+	log := logging.MustGetLogger("unix_server")
+
+	serve := func(t *testing.T, pid int) (*unixServer, *RPCGateway) {
+		gateway := NewRPCGateway(log)
+
+		s, err := newUnixServer(log, path, nil, gateway, func() (int, bool) { return pid, true })
+		require.NoError(t, err)
+
+		return s, gateway
+	}
+
+	t.Run("app process", func(t *testing.T) {
+		s, gateway := serve(t, os.Getpid())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		cl, err := rpc.Dial("unix", path)
+		require.NoError(t, err)
+
+		req := StatusReq{Ready: true, Message: "serving"}
+		require.NoError(t, cl.Call(string(appcommon.UnixSocketKey)+".SetStatus", &req, nil))
+		assert.Equal(t, HealthHealthy, gateway.health.health(time.Now()).Status)
+
+		require.NoError(t, s.Close())
+
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), err)
+
+		assert.Error(t, cl.Call(string(appcommon.UnixSocketKey)+".SetStatus", &req, nil))
+	})
+
+	t.Run("other process", func(t *testing.T) {
+		s, _ := serve(t, os.Getpid()+1)
+		defer func() { require.NoError(t, s.Close()) }()
+
+		cl, err := rpc.Dial("unix", path)
+		require.NoError(t, err)
+
+		req := StatusReq{Ready: true}
+		assert.Error(t, cl.Call(string(appcommon.UnixSocketKey)+".SetStatus", &req, nil))
+	})
+}
+
+func TestNewProc_UnixSocket(t *testing.T) {
+	c := appcommon.Config{
+		Name:       "app",
+		ServerAddr: appcommon.DefaultServerAddr,
+		VisorPK:    "pk",
+		WorkDir:    "/var/skywire/local/app",
+		UnixSocket: true,
+	}
+
+	p, err := NewProc(logging.MustGetLogger("proc"), c, nil, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		appcommon.EnvServerNet + "=unix",
+		appcommon.EnvServerAddr + "=/var/skywire/local/app/" + appcommon.UnixSocketName,
+		appcommon.EnvVisorPK + "=pk",
+	}, p.cmd.Env)
+}
@@ -27,6 +27,8 @@ type ProcManager interface {
 	Exists(name string) bool
 	Stop(name string) error
 	Wait(name string) error
+	Usage(name string) (ProcUsage, error)
+	Health(name string) (Health, error)
 	Range(next func(name string, proc *Proc) bool)
 	StopAll()
 }
@@ -61,8 +63,11 @@ func (m *procManager) Start(log *logging.Logger, c appcommon.Config, args []stri
 		return 0, err
 	}
 
-	if err := m.rpcServer.Register(p.key); err != nil {
-		return 0, err
+	// apps with a unix socket are served by their `Proc`
+	if !c.UnixSocket {
+		if err := m.rpcServer.register(p.key, p.health); err != nil {
+			return 0, err
+		}
 	}
 
 	m.mx.Lock()
@@ -70,6 +75,10 @@ func (m *procManager) Start(log *logging.Logger, c appcommon.Config, args []stri
 	m.mx.Unlock()
 
 	if err := p.Start(); err != nil {
+		if _, popErr := m.pop(c.Name); popErr != nil {
+			m.log.Debugf("Remove app <%v>: %v", c.Name, popErr)
+		}
+
 		return 0, err
 	}
 
@@ -122,6 +131,26 @@ func (m *procManager) Wait(name string) error {
 	return err
 }
 
+// Usage returns resource usage of the application.
+func (m *procManager) Usage(name string) (ProcUsage, error) {
+	p, err := m.get(name)
+	if err != nil {
+		return ProcUsage{}, err
+	}
+
+	return p.Usage()
+}
+
+// Health returns health reported by the application.
+func (m *procManager) Health(name string) (Health, error) {
+	p, err := m.get(name)
+	if err != nil {
+		return Health{}, err
+	}
+
+	return p.Health(), nil
+}
+
 // Range allows to iterate over running skywire apps. Calls `next` on
 // each iteration. If `next` returns falls - stops iteration.
 func (m *procManager) Range(next func(name string, proc *Proc) bool) {
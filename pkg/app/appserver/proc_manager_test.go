@@ -1,6 +1,7 @@
 package appserver
 
 import (
+	"os"
 	"sort"
 	"testing"
 
@@ -10,6 +11,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	// procs with limits re-exec the test binary as the limits helper
+	ExecWithLimits()
+
+	os.Exit(m.Run())
+}
+
 func TestProcManager_Exists(t *testing.T) {
 	srv := New(nil, appcommon.DefaultServerAddr)
 
@@ -0,0 +1,34 @@
+//go:build !linux
+// +build !linux
+
+package appserver
+
+import (
+	"errors"
+	"net"
+	"os/exec"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+func limitCmd(_ *exec.Cmd, l appcommon.Limits) error {
+	if l.IsSet() {
+		return errors.New("resource limits are only supported on linux")
+	}
+
+	return nil
+}
+
+// ExecWithLimits does nothing, as resource limits are only supported on linux.
+func ExecWithLimits() {}
+
+func procUsage(int) (ProcUsage, error) {
+	return ProcUsage{}, errUsageNotSupported
+}
+
+// peerCredSupported tells whether peerPID is able to identify processes.
+const peerCredSupported = false
+
+func peerPID(*net.UnixConn) (int, error) {
+	return 0, errUnixSocketNotSupported
+}
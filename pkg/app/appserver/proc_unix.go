@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package appserver
+
+import (
+	"syscall"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+// sysProcAttr puts the app in its own process group, so that its children are stopped along with it,
+// and runs it as the configured user.
+func sysProcAttr(c appcommon.Config) (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+
+	if c.Credential != nil {
+		attr.Credential = &syscall.Credential{Uid: c.Credential.UID, Gid: c.Credential.GID}
+	}
+
+	return attr, nil
+}
+
+// signalGroup sends signal to the process group of the app.
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if err == syscall.ESRCH {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package appserver
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+func sysProcAttr(c appcommon.Config) (*syscall.SysProcAttr, error) {
+	if c.Credential != nil {
+		return nil, errors.New("running apps as another user is not supported on windows")
+	}
+
+	return nil, nil
+}
+
+// signalGroup signals the app process only, as process groups are not supported.
+func signalGroup(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+
+	if sig == syscall.SIGKILL {
+		return p.Kill()
+	}
+
+	return p.Signal(sig)
+}
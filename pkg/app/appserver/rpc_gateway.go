@@ -1,6 +1,7 @@
 package appserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/idmanager"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/rpcutil"
 )
@@ -60,6 +62,8 @@ type RPCGateway struct {
 	lm  *idmanager.Manager // contains listeners associated with their IDs
 	cm  *idmanager.Manager // contains connections associated with their IDs
 	log *logging.Logger
+
+	health *healthState // readiness and liveness reported by the app
 }
 
 // NewRPCGateway constructs new server RPC interface.
@@ -68,6 +72,8 @@ func NewRPCGateway(log *logging.Logger) *RPCGateway {
 		lm:  idmanager.New(),
 		cm:  idmanager.New(),
 		log: log,
+
+		health: newHealthState(),
 	}
 }
 
@@ -81,12 +87,64 @@ type DialResp struct {
 func (r *RPCGateway) Dial(remote *appnet.Addr, resp *DialResp) (err error) {
 	defer rpcutil.LogCall(r.log, "Dial", remote)(resp, &err)
 
+	return r.dial(context.Background(), *remote, appnet.DialContext, resp)
+}
+
+// DialReq contains arguments for `DialContext`.
+type DialReq struct {
+	Remote   appnet.Addr
+	Deadline time.Time           // zero if there is no deadline
+	Opts     *router.DialOptions // default options if nil
+}
+
+// DialContext dials to the remote, giving up at the deadline of the app.
+// Routes of skynet connections are set up according to the dial options of the app.
+func (r *RPCGateway) DialContext(req *DialReq, resp *DialResp) (err error) {
+	defer rpcutil.LogCall(r.log, "DialContext", req)(resp, &err)
+
+	return r.dialReq(req, appnet.DialContext, resp)
+}
+
+// DialPacket dials to the remote like `DialContext`, but the connection preserves boundaries of writes.
+func (r *RPCGateway) DialPacket(req *DialReq, resp *DialResp) (err error) {
+	defer rpcutil.LogCall(r.log, "DialPacket", req)(resp, &err)
+
+	return r.dialReq(req, appnet.DialPacketContext, resp)
+}
+
+// dialFunc dials the remote `addr` with the context.
+type dialFunc func(ctx context.Context, addr appnet.Addr) (net.Conn, error)
+
+// dialReq dials to the remote using `dial`, according to the deadline and options of `req`.
+func (r *RPCGateway) dialReq(req *DialReq, dial dialFunc, resp *DialResp) error {
+	ctx := context.Background()
+
+	if !req.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+
+		defer cancel()
+	}
+
+	if req.Opts != nil {
+		if err := req.Opts.Validate(); err != nil {
+			return err
+		}
+
+		ctx = appnet.WithDialOptions(ctx, req.Opts)
+	}
+
+	return r.dial(ctx, req.Remote, dial, resp)
+}
+
+// dial dials to the remote with the context, using `dial`.
+func (r *RPCGateway) dial(ctx context.Context, remote appnet.Addr, dial dialFunc, resp *DialResp) error {
 	reservedConnID, free, err := r.cm.ReserveNextID()
 	if err != nil {
 		return err
 	}
 
-	conn, err := appnet.Dial(*remote)
+	conn, err := dial(ctx, remote)
 	if err != nil {
 		free()
 		return err
@@ -118,12 +176,25 @@ func (r *RPCGateway) Dial(remote *appnet.Addr, resp *DialResp) (err error) {
 func (r *RPCGateway) Listen(local *appnet.Addr, lisID *uint16) (err error) {
 	defer rpcutil.LogCall(r.log, "Listen", local)(lisID, &err)
 
+	return r.listen(*local, appnet.ListenContext, lisID)
+}
+
+// ListenPacket starts listening like `Listen`, but accepted connections preserve boundaries of writes.
+func (r *RPCGateway) ListenPacket(local *appnet.Addr, lisID *uint16) (err error) {
+	defer rpcutil.LogCall(r.log, "ListenPacket", local)(lisID, &err)
+
+	return r.listen(*local, appnet.ListenPacketContext, lisID)
+}
+
+// listen starts listening on `local` using `listen`.
+func (r *RPCGateway) listen(local appnet.Addr,
+	listen func(ctx context.Context, addr appnet.Addr) (net.Listener, error), lisID *uint16) error {
 	nextLisID, free, err := r.lm.ReserveNextID()
 	if err != nil {
 		return err
 	}
 
-	l, err := appnet.Listen(*local)
+	l, err := listen(context.Background(), local)
 	if err != nil {
 		free()
 		return err
@@ -317,6 +388,24 @@ func (r *RPCGateway) SetWriteDeadline(req *DeadlineReq, _ *struct{}) error {
 	return conn.SetWriteDeadline(req.Deadline)
 }
 
+// StatusReq contains arguments for `SetStatus`.
+type StatusReq struct {
+	Ready   bool
+	Message string
+}
+
+// SetStatus sets readiness of the app.
+func (r *RPCGateway) SetStatus(req *StatusReq, _ *struct{}) error {
+	r.health.setStatus(req.Ready, req.Message)
+
+	return nil
+}
+
+// Heartbeat notes that the app is alive. The app is expected to send the next heartbeat within `interval`.
+func (r *RPCGateway) Heartbeat(interval *time.Duration, _ *struct{}) error {
+	return r.health.heartbeat(time.Now(), *interval)
+}
+
 // popListener gets listener from the manager by `lisID` and removes it.
 // Handles type assertion.
 func (r *RPCGateway) popListener(lisID uint16) (net.Listener, error) {
@@ -20,6 +20,12 @@ type Server struct {
 	rpcS   *rpc.Server
 	done   sync.WaitGroup
 	stopCh chan struct{}
+
+	jsonMu  sync.Mutex
+	jsonLis net.Listener // listener of the JSON-RPC endpoint, if served
+
+	gatewaysMu sync.RWMutex
+	gateways   map[appcommon.Key]*RPCGateway // gateways of registered apps, used by the JSON-RPC endpoint
 }
 
 // New constructs server.
@@ -29,15 +35,41 @@ func New(log *logging.Logger, addr string) *Server {
 		addr:   addr,
 		rpcS:   rpc.NewServer(),
 		stopCh: make(chan struct{}),
+
+		gateways: make(map[appcommon.Key]*RPCGateway),
 	}
 }
 
 // Register registers an app key in RPC server.
 func (s *Server) Register(appKey appcommon.Key) error {
+	return s.register(appKey, newHealthState())
+}
+
+// register registers an app key in RPC server, health reported by the app is kept in `health`.
+func (s *Server) register(appKey appcommon.Key, health *healthState) error {
 	logger := logging.MustGetLogger(fmt.Sprintf("app_gateway:%s", appKey))
 	gateway := NewRPCGateway(logger)
+	gateway.health = health
 
-	return s.rpcS.RegisterName(string(appKey), gateway)
+	if err := s.rpcS.RegisterName(string(appKey), gateway); err != nil {
+		return err
+	}
+
+	s.gatewaysMu.Lock()
+	s.gateways[appKey] = gateway
+	s.gatewaysMu.Unlock()
+
+	return nil
+}
+
+// gateway returns the gateway registered for `appKey`.
+func (s *Server) gateway(appKey appcommon.Key) (*RPCGateway, bool) {
+	s.gatewaysMu.RLock()
+	defer s.gatewaysMu.RUnlock()
+
+	gateway, ok := s.gateways[appKey]
+
+	return gateway, ok
 }
 
 // ListenAndServe starts listening for incoming app connections via tcp socket.
@@ -61,6 +93,30 @@ func (s *Server) ListenAndServe() error {
 	}
 }
 
+// ListenAndServeJSON starts listening for incoming app connections speaking JSON-RPC 2.0
+// (see JSONRPC.md) via tcp socket on `addr`.
+func (s *Server) ListenAndServeJSON(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.jsonMu.Lock()
+	s.jsonLis = l
+	s.jsonMu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.done.Add(1) // nolint: gomnd
+
+		go s.serveJSONConn(conn)
+	}
+}
+
 // Close closes the server.
 func (s *Server) Close() error {
 	var err error
@@ -69,6 +125,14 @@ func (s *Server) Close() error {
 		err = s.lis.Close()
 	}
 
+	s.jsonMu.Lock()
+	if s.jsonLis != nil {
+		if jErr := s.jsonLis.Close(); jErr != nil && err == nil {
+			err = jErr
+		}
+	}
+	s.jsonMu.Unlock()
+
 	close(s.stopCh)
 
 	s.done.Wait()
@@ -88,3 +152,16 @@ func (s *Server) serveConn(conn net.Conn) {
 
 	s.done.Done()
 }
+
+// serveJSONConn serves JSON-RPC on a single connection.
+func (s *Server) serveJSONConn(conn net.Conn) {
+	go newJSONRPCConn(s, conn).serve()
+
+	<-s.stopCh
+
+	if err := conn.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		s.log.WithError(err).Error("Unexpected error while closing conn.")
+	}
+
+	s.done.Done()
+}
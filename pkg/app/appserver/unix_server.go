@@ -0,0 +1,199 @@
+package appserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+// maxUnixSocketPath is the maximum length of a unix socket path on linux.
+const maxUnixSocketPath = 107
+
+var errUnixSocketNotSupported = errors.New("unix socket app server is only supported on linux")
+
+// unixServer serves RPC for a single app on a unix socket. Only connections from the process
+// of the app are accepted, so that the app needs no key to authenticate.
+type unixServer struct {
+	log  *logging.Logger
+	path string
+	lis  *net.UnixListener
+	rpcS *rpc.Server
+
+	// waitPID returns the PID of the app once it is started, false if it is not going to be.
+	waitPID func() (int, bool)
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	closed  bool
+	done    sync.WaitGroup
+}
+
+// newUnixServer creates a socket at `path`, accessible only to the user of the app, and serves `gateway` on it.
+func newUnixServer(log *logging.Logger, path string, cred *appcommon.Credential, gateway *RPCGateway,
+	waitPID func() (int, bool)) (*unixServer, error) {
+	if !peerCredSupported {
+		return nil, errUnixSocketNotSupported
+	}
+
+	if len(path) > maxUnixSocketPath {
+		return nil, fmt.Errorf("unix socket path %q is longer than %d bytes", path, maxUnixSocketPath)
+	}
+
+	rpcS := rpc.NewServer()
+	if err := rpcS.RegisterName(string(appcommon.UnixSocketKey), gateway); err != nil {
+		return nil, err
+	}
+
+	// socket may be left over by a visor which did not exit cleanly
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	lis, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		closeUnixListener(log, lis)
+		return nil, err
+	}
+
+	if cred != nil {
+		if err := os.Chown(path, int(cred.UID), int(cred.GID)); err != nil {
+			closeUnixListener(log, lis)
+			return nil, err
+		}
+	}
+
+	s := &unixServer{
+		log:     log,
+		path:    path,
+		lis:     lis,
+		rpcS:    rpcS,
+		waitPID: waitPID,
+		conns:   make(map[net.Conn]struct{}),
+	}
+
+	s.done.Add(1)
+
+	go s.serve()
+
+	return s, nil
+}
+
+// serve accepts connections until the listener is closed.
+func (s *unixServer) serve() {
+	defer s.done.Done()
+
+	for {
+		conn, err := s.lis.AcceptUnix()
+		if err != nil {
+			if !strings.Contains(err.Error(), "use of closed network connection") {
+				s.log.WithError(err).Error("Unexpected error while accepting app conn.")
+			}
+
+			return
+		}
+
+		if err := s.checkPeer(conn); err != nil {
+			s.log.WithError(err).Warn("Rejected app conn.")
+
+			if err := conn.Close(); err != nil {
+				s.log.WithError(err).Debug("Error closing rejected app conn.")
+			}
+
+			continue
+		}
+
+		if !s.track(conn) {
+			return
+		}
+
+		go func() {
+			s.rpcS.ServeConn(conn)
+			s.untrack(conn)
+		}()
+	}
+}
+
+// checkPeer checks that `conn` comes from the process of the app.
+func (s *unixServer) checkPeer(conn *net.UnixConn) error {
+	peer, err := peerPID(conn)
+	if err != nil {
+		return fmt.Errorf("failed to get peer credentials: %w", err)
+	}
+
+	pid, ok := s.waitPID()
+	if !ok {
+		return errors.New("app is not running")
+	}
+
+	if peer != pid {
+		return fmt.Errorf("peer PID %d does not match app PID %d", peer, pid)
+	}
+
+	return nil
+}
+
+func (s *unixServer) track(conn net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		if err := conn.Close(); err != nil {
+			s.log.WithError(err).Debug("Error closing app conn.")
+		}
+
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (s *unixServer) untrack(conn net.Conn) {
+	s.connsMu.Lock()
+	delete(s.conns, conn)
+	s.connsMu.Unlock()
+}
+
+// Close stops accepting connections, closes served ones and removes the socket.
+func (s *unixServer) Close() error {
+	s.connsMu.Lock()
+	if s.closed {
+		s.connsMu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+
+	for conn := range s.conns {
+		if err := conn.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+			s.log.WithError(err).Error("Unexpected error while closing app conn.")
+		}
+	}
+	s.connsMu.Unlock()
+
+	// closing the listener removes the socket file
+	err := s.lis.Close()
+
+	s.done.Wait()
+
+	return err
+}
+
+func closeUnixListener(log *logging.Logger, lis *net.UnixListener) {
+	if err := lis.Close(); err != nil {
+		log.WithError(err).Error("Error closing unix listener.")
+	}
+}
@@ -1,12 +1,15 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/rpc"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -14,6 +17,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/idmanager"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
@@ -31,13 +35,21 @@ var (
 // ClientConfig is a configuration for `Client`.
 type ClientConfig struct {
 	VisorPK    cipher.PubKey
+	ServerNet  string // network of ServerAddr, "tcp" if empty
 	ServerAddr string
 	AppKey     appcommon.Key
 }
 
 // ClientConfigFromEnv creates client config from the ENV args.
 func ClientConfigFromEnv() (ClientConfig, error) {
+	serverNet := os.Getenv(appcommon.EnvServerNet)
+
 	appKey := os.Getenv(appcommon.EnvAppKey)
+	if serverNet == "unix" {
+		// app server on a unix socket authenticates the app by its PID
+		appKey = string(appcommon.UnixSocketKey)
+	}
+
 	if appKey == "" {
 		return ClientConfig{}, ErrAppKeyNotProvided
 	}
@@ -59,6 +71,7 @@ func ClientConfigFromEnv() (ClientConfig, error) {
 
 	return ClientConfig{
 		VisorPK:    visorPK,
+		ServerNet:  serverNet,
 		ServerAddr: serverAddr,
 		AppKey:     appcommon.Key(appKey),
 	}, nil
@@ -77,7 +90,12 @@ type Client struct {
 // - log: logger instance.
 // - config: client configuration.
 func NewClient(log *logging.Logger, config ClientConfig) (*Client, error) {
-	rpcCl, err := rpc.Dial("tcp", config.ServerAddr)
+	network := config.ServerNet
+	if network == "" {
+		network = "tcp"
+	}
+
+	rpcCl, err := rpc.Dial(network, config.ServerAddr)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to the app server: %v", err)
 	}
@@ -98,6 +116,29 @@ func (c *Client) Dial(remote appnet.Addr) (net.Conn, error) {
 		return nil, err
 	}
 
+	return c.addConn(remote, connID, localPort)
+}
+
+// DialContext dials the remote visor using `remote`. The deadline of `ctx` is passed to the visor,
+// which gives up dialing at that time. Routes of skynet connections are set up according to `opts`,
+// default options are used if it is nil.
+func (c *Client) DialContext(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (net.Conn, error) {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	connID, localPort, err := c.rpc.DialContext(ctx, remote, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.addConn(remote, connID, localPort)
+}
+
+// addConn keeps track of a dialed connection.
+func (c *Client) addConn(remote appnet.Addr, connID uint16, localPort routing.Port) (net.Conn, error) {
 	conn := &Conn{
 		id:  connID,
 		rpc: c.rpc,
@@ -143,6 +184,11 @@ func (c *Client) Listen(n appnet.Type, port routing.Port) (net.Listener, error)
 		return nil, err
 	}
 
+	return c.addListener(local, lisID)
+}
+
+// addListener keeps track of a listener.
+func (c *Client) addListener(local appnet.Addr, lisID uint16) (net.Listener, error) {
 	listener := &Listener{
 		log:  c.log,
 		id:   lisID,
@@ -171,6 +217,98 @@ func (c *Client) Listen(n appnet.Type, port routing.Port) (net.Listener, error)
 	return listener, nil
 }
 
+// DialPacket dials the remote visor using `remote`, like `DialContext`. The returned connection
+// sends each write as a single data packet, and each read returns a single data packet, so that data
+// is not delayed to be merged or split in between. Only skynet supports packet connections.
+func (c *Client) DialPacket(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (*PacketConn, error) {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	connID, localPort, err := c.rpc.DialPacket(ctx, remote, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.addConn(remote, connID, localPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDialedPacketConn(c.log, conn), nil
+}
+
+// ListenPacket listens on the specified `port` for datagrams. The returned connection receives
+// datagrams of all remotes which dial the port with `DialPacket`, and can reply to them.
+// Only skynet supports packet connections.
+func (c *Client) ListenPacket(n appnet.Type, port routing.Port) (*PacketConn, error) {
+	local := appnet.Addr{
+		Net:    n,
+		PubKey: c.visorPK,
+		Port:   port,
+	}
+
+	lisID, err := c.rpc.ListenPacket(local)
+	if err != nil {
+		return nil, err
+	}
+
+	lis, err := c.addListener(local, lisID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newListeningPacketConn(c.log, lis), nil
+}
+
+// SetStatus reports readiness of the app to the visor, along with an optional human readable `message`.
+// An app is shown as starting until it reports being ready for the first time.
+func (c *Client) SetStatus(ready bool, message string) error {
+	return c.rpc.SetStatus(ready, message)
+}
+
+// Heartbeat notes that the app is alive. The app is expected to send the next heartbeat within `interval`,
+// the visor restarts apps which miss several heartbeats in a row.
+func (c *Client) Heartbeat(interval time.Duration) error {
+	return c.rpc.Heartbeat(interval)
+}
+
+// StartHeartbeat sends heartbeats every `interval` until the returned function is called.
+func (c *Client) StartHeartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := c.Heartbeat(interval); err != nil {
+				c.log.WithError(err).Warn("Failed to send heartbeat.")
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
 // Close closes client/server communication entirely. It closes all open
 // listeners and connections.
 func (c *Client) Close() {
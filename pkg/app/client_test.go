@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -25,6 +26,9 @@ func TestClientConfigFromEnv(t *testing.T) {
 
 		err = os.Setenv(appcommon.EnvVisorPK, "")
 		require.NoError(t, err)
+
+		err = os.Setenv(appcommon.EnvServerNet, "")
+		require.NoError(t, err)
 	}
 
 	t.Run("ok", func(t *testing.T) {
@@ -52,6 +56,27 @@ func TestClientConfigFromEnv(t *testing.T) {
 		require.Equal(t, wantCfg, gotCfg)
 	})
 
+	t.Run("unix socket", func(t *testing.T) {
+		resetEnv(t)
+
+		visorPK, _ := cipher.GenerateKeyPair()
+
+		wantCfg := ClientConfig{
+			VisorPK:    visorPK,
+			ServerNet:  "unix",
+			ServerAddr: "/var/skywire/local/app/" + appcommon.UnixSocketName,
+			AppKey:     appcommon.UnixSocketKey,
+		}
+
+		require.NoError(t, os.Setenv(appcommon.EnvServerNet, wantCfg.ServerNet))
+		require.NoError(t, os.Setenv(appcommon.EnvServerAddr, wantCfg.ServerAddr))
+		require.NoError(t, os.Setenv(appcommon.EnvVisorPK, wantCfg.VisorPK.Hex()))
+
+		gotCfg, err := ClientConfigFromEnv()
+		require.NoError(t, err)
+		require.Equal(t, wantCfg, gotCfg)
+	})
+
 	t.Run("no app key", func(t *testing.T) {
 		resetEnv(t)
 
@@ -378,3 +403,24 @@ func prepClient(l *logging.Logger, visorPK cipher.PubKey, rpc RPCClient) *Client
 		cm:      idmanager.New(),
 	}
 }
+
+func TestClient_StartHeartbeat(t *testing.T) {
+	interval := 10 * time.Millisecond
+
+	rpc := &MockRPCClient{}
+	rpc.On("Heartbeat", interval).Return(nil)
+
+	cl := &Client{log: logging.MustGetLogger("app_client"), rpc: rpc}
+
+	stop := cl.StartHeartbeat(interval)
+	time.Sleep(5 * interval)
+	stop()
+	stop()
+
+	calls := len(rpc.Calls)
+	require.True(t, calls > 1, calls)
+
+	// no heartbeats after stop
+	time.Sleep(3 * interval)
+	require.Len(t, rpc.Calls, calls)
+}
@@ -1,28 +1,16 @@
 package app
 
 import (
-	"io"
-	"os"
 	"time"
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 )
 
-// NewLogger returns a logger which persists app logs. This logger should be passed down
-// for use on any other function used by the app. It's configured from an additional app argument.
-// It modifies os.Args stripping from it such value. Should be called before using os.Args inside the app
-func NewLogger(appName string) *logging.MasterLogger {
-	db, err := newBoltDB(os.Args[1], appName)
-	if err != nil {
-		panic(err)
-	}
-
-	l := newAppLogger()
-	l.SetOutput(io.MultiWriter(l.Out, db))
-
-	os.Args = append([]string{os.Args[0]}, os.Args[2:]...)
-
-	return l
+// NewLogger returns a logger for use by the app. This logger should be passed down
+// for use on any other function used by the app. Its output is persisted by the visor,
+// which stores everything the app writes to stdout and stderr.
+func NewLogger(_ string) *logging.MasterLogger {
+	return newAppLogger()
 }
 
 // TimestampFromLog is an utility function for retrieving the timestamp from a log. This function should be modified
@@ -31,18 +19,6 @@ func TimestampFromLog(log string) string {
 	return log[1:36]
 }
 
-func newPersistentLogger(path, appName string) (*logging.MasterLogger, LogStore, error) {
-	db, err := newBoltDB(path, appName)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	l := newAppLogger()
-	l.SetOutput(io.MultiWriter(l.Out, db))
-
-	return l, db, nil
-}
-
 func newAppLogger() *logging.MasterLogger {
 	l := logging.NewMasterLogger()
 	l.Logger.Formatter.(*logging.TextFormatter).TimestampFormat = time.RFC3339Nano
@@ -2,26 +2,86 @@ package app
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"io"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
 	"go.etcd.io/bbolt"
 )
 
+const (
+	// DefaultLogMaxSize is the default maximum total size of logs kept per app.
+	DefaultLogMaxSize = 10 << 20
+
+	// DefaultLogMaxAge is the default maximum age of logs kept per app.
+	DefaultLogMaxAge = 7 * 24 * time.Hour
+
+	// DefaultLogPageSize is the number of logs returned by a query which has no limit.
+	DefaultLogPageSize = 1000
+
+	logFlushInterval = 200 * time.Millisecond
+	logBatchSize     = 256
+	logMaxPending    = 16 * logBatchSize // logs kept while flushes fail, the oldest are dropped beyond it
+	logSubBufSize    = 256
+)
+
+// ErrLogStoreClosed is returned when a closed LogStore is used.
+var ErrLogStoreClosed = errors.New("log store is closed")
+
 // LogStore stores logs from apps, for later consumption from the hypervisor
 type LogStore interface {
-	// Write implements io.Writer
+	// Write implements io.Writer. Each line written is stored as a log, timestamped when it is complete.
 	Write(p []byte) (n int, err error)
 
 	// Store saves given log in db
 	Store(t time.Time, s string) error
 
-	// LogSince returns the logs since given timestamp. For optimal performance,
-	// the timestamp should exist in the store (you can get it from previous logs),
-	// otherwise the DB will be sequentially iterated until finding entries older than given timestamp
+	// LogsSince returns all the logs after given timestamp.
 	LogsSince(t time.Time) ([]string, error)
+
+	// Logs returns a page of logs matching the query.
+	Logs(q LogQuery) (*LogsPage, error)
+
+	// Subscribe returns a channel of logs stored after the call, and a function to cancel the subscription.
+	// Subscribers which fall behind are unsubscribed, in which case the channel is closed.
+	Subscribe() (<-chan LogEntry, func())
+
+	// Flush saves pending logs.
+	Flush() error
+
+	// Close flushes pending logs and releases the store.
+	Close() error
+}
+
+// LogStoreConfig configures retention of a LogStore.
+type LogStoreConfig struct {
+	MaxSize int64         // maximum total size of logs, oldest logs are deleted first
+	MaxAge  time.Duration // maximum age of logs
+}
+
+// LogEntry is a stored log.
+type LogEntry struct {
+	Time time.Time `json:"time"` // time the log was stored, unique within a store
+	Line string    `json:"line"`
+}
+
+// LogQuery selects logs.
+type LogQuery struct {
+	Since time.Time `json:"since"` // only logs stored after Since are returned
+	Level string    `json:"level"` // minimum severity, such as "warn", logs without a level always match
+	Limit int       `json:"limit"` // DefaultLogPageSize if not positive
+}
+
+// LogsPage is a page of logs.
+type LogsPage struct {
+	Logs []LogEntry `json:"logs"`
+	More bool       `json:"more"` // there are more logs after the last one
 }
 
 // NewLogStore returns a LogStore with path and app name of the given kind
@@ -34,143 +94,505 @@ func NewLogStore(path, appName, kind string) (LogStore, error) {
 	}
 }
 
+// ImportBoltLogs stores logs of app 'appName' from the bbolt file at 'path', written by older versions of visor,
+// into 'ls', and returns the number of imported logs. Older versions keyed logs by timestamps formatted as
+// RFC3339Nano, logs whose keys can't be parsed are skipped.
+func ImportBoltLogs(ls LogStore, path, appName string) (int, error) {
+	const openTimeout = 5 * time.Second
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: openTimeout, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = db.Close() //nolint:errcheck
+	}()
+
+	var entries []LogEntry
+
+	err = db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(appName))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if t, err := time.Parse(time.RFC3339Nano, string(k)); err == nil {
+				entries = append(entries, LogEntry{Time: t, Line: string(v)})
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// formatted timestamps do not sort by time
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	for _, e := range entries {
+		if err := ls.Store(e.Time, e.Line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(entries), ls.Flush()
+}
+
+// NewBoltLogStore returns a LogStore backed by a bbolt file at path, which is kept open until the store is closed.
+func NewBoltLogStore(path, appName string, conf LogStoreConfig) (LogStore, error) {
+	ls, err := newBoltDB(path, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	ls.conf = conf
+
+	return ls, nil
+}
+
 type boltDBappLogs struct {
-	dbpath string
+	db     *bbolt.DB
 	bucket []byte
+	conf   LogStoreConfig
+	log    logrus.FieldLogger
+
+	mu      sync.Mutex
+	pending []LogEntry
+	partial []byte // incomplete line given to Write
+	dropped int    // number of pending logs dropped since the last successful flush
+	size    int64
+	subs    map[chan LogEntry]struct{}
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
 }
 
-func newBoltDB(path, appName string) (_ LogStore, err error) {
-	db, err := bbolt.Open(path, 0600, nil)
+func newBoltDB(path, appName string) (*boltDBappLogs, error) {
+	const openTimeout = 5 * time.Second
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: openTimeout})
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		cErr := db.Close()
-		err = cErr
-	}()
+	l := &boltDBappLogs{
+		db:     db,
+		bucket: []byte(appName),
+		conf:   LogStoreConfig{MaxSize: DefaultLogMaxSize, MaxAge: DefaultLogMaxAge},
+		log:    logging.MustGetLogger("app_logs").WithField("app", appName),
+		subs:   make(map[chan LogEntry]struct{}),
+		done:   make(chan struct{}),
+	}
 
-	b := []byte(appName)
 	err = db.Update(func(tx *bbolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+		b, err := tx.CreateBucketIfNotExists(l.bucket)
+		if err != nil {
 			return fmt.Errorf("failed to create bucket: %s", err)
 		}
 
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			l.size += int64(len(v))
+		}
+
 		return nil
 	})
 
-	if err != nil && !strings.Contains(err.Error(), bbolt.ErrBucketExists.Error()) {
+	if err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			return nil, fmt.Errorf("%v (close: %v)", err, closeErr)
+		}
+
 		return nil, err
 	}
 
-	return &boltDBappLogs{path, b}, nil
+	l.wg.Add(1)
+	go l.flushLoop()
+
+	return l, nil
 }
 
 // Write implements io.Writer
-func (l *boltDBappLogs) Write(p []byte) (n int, err error) {
-	// ensure there is at least timestamp long bytes
-	if len(p) < 37 {
-		return 0, io.ErrShortBuffer
+func (l *boltDBappLogs) Write(p []byte) (int, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return 0, ErrLogStoreClosed
 	}
 
-	db, err := bbolt.Open(l.dbpath, 0600, nil)
-	if err != nil {
-		return 0, err
+	data := append(l.partial, p...)
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+
+		l.add(now, string(data[:i]))
+		data = data[i+1:]
 	}
 
-	defer func() {
-		if closeErr := db.Close(); err == nil {
-			err = closeErr
+	l.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+// Store implements LogStore
+func (l *boltDBappLogs) Store(t time.Time, s string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrLogStoreClosed
+	}
+
+	l.add(t, s)
+
+	return nil
+}
+
+// add queues a log stored at 't'. It should be called with mu held.
+func (l *boltDBappLogs) add(t time.Time, s string) {
+	if len(l.pending) >= logMaxPending {
+		copy(l.pending, l.pending[1:])
+		l.pending = l.pending[:len(l.pending)-1]
+		l.dropped++
+	}
+
+	l.pending = append(l.pending, LogEntry{Time: t, Line: s})
+
+	// While flushes fail, they are retried by further batches, and by flushLoop once logs are dropped.
+	if len(l.pending)%logBatchSize == 0 && l.dropped == 0 {
+		if err := l.flush(); err != nil {
+			l.log.WithError(err).Warn("Failed to flush app logs")
 		}
-	}()
+	}
+}
 
-	// time in RFC3339Nano is between the bytes 1 and 36. This will change if other time layout is in use
-	t := p[1:36]
+// LogsSince implements LogStore
+func (l *boltDBappLogs) LogsSince(t time.Time) ([]string, error) {
+	logs := make([]string, 0)
 
-	err = db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(l.bucket)
-		return b.Put(t, p)
+	for {
+		page, err := l.Logs(LogQuery{Since: t})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range page.Logs {
+			logs = append(logs, e.Line)
+		}
+
+		if !page.More {
+			return logs, nil
+		}
+
+		t = page.Logs[len(page.Logs)-1].Time
+	}
+}
+
+// Logs implements LogStore
+func (l *boltDBappLogs) Logs(q LogQuery) (*LogsPage, error) {
+	if err := l.Flush(); err != nil {
+		return nil, err
+	}
+
+	var minLevel *logrus.Level
+
+	if q.Level != "" {
+		level, err := logrus.ParseLevel(q.Level)
+		if err != nil {
+			return nil, err
+		}
+
+		minLevel = &level
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLogPageSize
+	}
+
+	page := &LogsPage{Logs: make([]LogEntry, 0)}
+
+	err := l.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(l.bucket).Cursor()
+		since := timeKey(q.Since)
+
+		k, v := c.Seek(since)
+		if k != nil && bytes.Equal(k, since) {
+			k, v = c.Next()
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			if minLevel != nil && !matchesLevel(string(v), *minLevel) {
+				continue
+			}
+
+			if len(page.Logs) == limit {
+				page.More = true
+				break
+			}
+
+			page.Logs = append(page.Logs, LogEntry{Time: keyTime(k), Line: string(v)})
+		}
+
+		return nil
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return len(p), nil
+	return page, nil
 }
 
-// Store implements LogStore
-func (l *boltDBappLogs) Store(t time.Time, s string) (err error) {
-	db, err := bbolt.Open(l.dbpath, 0600, nil)
-	if err != nil {
-		return err
+// Subscribe implements LogStore
+func (l *boltDBappLogs) Subscribe() (<-chan LogEntry, func()) {
+	ch := make(chan LogEntry, logSubBufSize)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		close(ch)
+		return ch, func() {}
 	}
 
-	defer func() {
-		cErr := db.Close()
-		err = cErr
-	}()
+	l.subs[ch] = struct{}{}
+
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.subs[ch]; ok {
+			delete(l.subs, ch)
+			close(ch)
+		}
+	}
+
+	return ch, cancel
+}
 
-	parsedTime := []byte(t.Format(time.RFC3339Nano))
+// Flush implements LogStore
+func (l *boltDBappLogs) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	return db.Update(func(tx *bbolt.Tx) error {
+	return l.flush()
+}
+
+// flush saves pending logs, enforces retention and notifies subscribers. It should be called with mu held.
+func (l *boltDBappLogs) flush() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+
+	keyed := make([]time.Time, len(l.pending))
+	size := l.size // only updated once the transaction is committed
+
+	err := l.db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(l.bucket)
-		return b.Put(parsedTime, []byte(s))
+
+		var prev time.Time
+
+		for i, e := range l.pending {
+			t := e.Time
+			if t.Before(minKeyTime) {
+				t = minKeyTime
+			}
+
+			// Logs stored at equal times are told apart by the following nanoseconds, in the order they are stored.
+			if i > 0 && e.Time.Equal(l.pending[i-1].Time) {
+				t = prev.Add(time.Nanosecond)
+			}
+
+			for b.Get(timeKey(t)) != nil {
+				t = t.Add(time.Nanosecond)
+			}
+
+			prev, keyed[i] = t, t
+
+			if err := b.Put(timeKey(t), []byte(e.Line)); err != nil {
+				return err
+			}
+
+			size += int64(len(e.Line))
+		}
+
+		var err error
+		size, err = l.enforceRetention(b, size)
+
+		return err
 	})
-}
 
-// LogSince implements LogStore
-func (l *boltDBappLogs) LogsSince(t time.Time) (logs []string, err error) {
-	db, err := bbolt.Open(l.dbpath, 0600, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer func() {
-		cErr := db.Close()
-		err = cErr
-	}()
+	l.size = size
 
-	logs = make([]string, 0)
+	if l.dropped > 0 {
+		l.log.Warnf("Dropped %d logs while they could not be saved", l.dropped)
+		l.dropped = 0
+	}
 
-	err = db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(l.bucket)
-		parsedTime := []byte(t.Format(time.RFC3339Nano))
-		c := b.Cursor()
+	for i, e := range l.pending {
+		e.Time = keyed[i]
+		l.notify(e)
+	}
 
-		v := b.Get(parsedTime)
-		if v == nil {
-			logs = iterateFromBeginning(c, parsedTime)
-			return nil
+	l.pending = l.pending[:0]
+
+	return nil
+}
+
+// enforceRetention deletes the oldest logs exceeding size or age limits, given the total 'size' of logs,
+// and returns the size left. It should be called with mu held.
+func (l *boltDBappLogs) enforceRetention(b *bbolt.Bucket, size int64) (int64, error) {
+	var minKey []byte
+	if l.conf.MaxAge > 0 {
+		minKey = timeKey(time.Now().Add(-l.conf.MaxAge))
+	}
+
+	c := b.Cursor()
+
+	for k, v := c.First(); k != nil; k, v = c.First() {
+		tooBig := l.conf.MaxSize > 0 && size > l.conf.MaxSize
+		tooOld := minKey != nil && bytes.Compare(k, minKey) < 0
+
+		if !tooBig && !tooOld {
+			break
 		}
-		c.Seek(parsedTime)
-		logs = iterateFromKey(c)
+
+		size -= int64(len(v))
+
+		if err := c.Delete(); err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+func (l *boltDBappLogs) notify(e LogEntry) {
+	for ch := range l.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(l.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (l *boltDBappLogs) flushLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			if err := l.Flush(); err != nil {
+				l.log.WithError(err).Warn("Failed to flush app logs")
+			}
+		}
+	}
+}
+
+// Close implements LogStore
+func (l *boltDBappLogs) Close() error {
+	l.mu.Lock()
+
+	if l.closed {
+		l.mu.Unlock()
 		return nil
-	})
+	}
+
+	if len(l.partial) > 0 {
+		l.add(time.Now(), string(l.partial))
+		l.partial = nil
+	}
 
-	return logs, err
+	err := l.flush()
+
+	l.closed = true
+
+	for ch := range l.subs {
+		delete(l.subs, ch)
+		close(ch)
+	}
+
+	l.mu.Unlock()
+
+	close(l.done)
+	l.wg.Wait()
+
+	if closeErr := l.db.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
 }
 
-func iterateFromKey(c *bbolt.Cursor) []string {
-	logs := make([]string, 0)
+// minKeyTime is the earliest time logs are keyed by.
+var minKeyTime = time.Unix(0, 1)
 
-	for k, v := c.Next(); k != nil; k, v = c.Next() {
-		logs = append(logs, string(v))
+// timeKey encodes t so that keys sort by time.
+// Times before the Unix epoch, including the zero time, map to the first key.
+func timeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+
+	if t.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
 	}
 
-	return logs
+	return k
 }
 
-func iterateFromBeginning(c *bbolt.Cursor, parsedTime []byte) []string {
-	logs := make([]string, 0)
+func keyTime(k []byte) time.Time {
+	if len(k) != 8 {
+		return time.Time{}
+	}
 
-	for k, v := c.First(); k != nil; k, v = c.Next() {
-		if bytes.Compare(k, parsedTime) < 0 {
-			continue
-		}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(k)))
+}
+
+// matchesLevel reports whether a log formatted by the app logger is at least as severe as min.
+// Logs without a recognizable level, such as panics, always match.
+func matchesLevel(line string, min logrus.Level) bool {
+	level, ok := logLevel(line)
 
-		logs = append(logs, string(v))
+	return !ok || level <= min
+}
+
+// logLevel parses the level of a log formatted as "[timestamp] LEVEL ...".
+func logLevel(line string) (logrus.Level, bool) {
+	i := strings.Index(line, "] ")
+	if !strings.HasPrefix(line, "[") || i < 0 {
+		return 0, false
+	}
+
+	fields := strings.Fields(line[i+2:])
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	level, err := logrus.ParseLevel(fields[0])
+	if err != nil {
+		return 0, false
 	}
 
-	return logs
+	return level, true
 }
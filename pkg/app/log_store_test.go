@@ -4,21 +4,33 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestLogStore(t *testing.T) {
-	p, err := ioutil.TempFile("", "test-db")
+func tempLogStore(t *testing.T, conf LogStoreConfig) (LogStore, string, func()) {
+	dir, err := ioutil.TempDir("", "test-db")
 	require.NoError(t, err)
 
-	defer os.Remove(p.Name()) // nolint
+	path := filepath.Join(dir, "foo.db")
 
-	ls, err := newBoltDB(p.Name(), "foo")
+	ls, err := NewBoltLogStore(path, "foo", conf)
 	require.NoError(t, err)
 
+	return ls, path, func() {
+		require.NoError(t, ls.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestLogStore(t *testing.T) {
+	ls, _, cleanup := tempLogStore(t, LogStoreConfig{})
+	defer cleanup()
+
 	t3, err := time.Parse(time.RFC3339, "2000-03-01T00:00:00Z")
 	require.NoError(t, err)
 
@@ -29,7 +41,6 @@ func TestLogStore(t *testing.T) {
 	require.NoError(t, err)
 
 	err = ls.Store(t1, "bar")
-	fmt.Println("original: ", t1.Format(time.RFC3339Nano))
 	require.NoError(t, err)
 
 	t2, err := time.Parse(time.RFC3339, "2000-02-01T00:00:00Z")
@@ -50,7 +61,163 @@ func TestLogStore(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, res, 3)
 	require.Contains(t, res[0], "bar")
-	fmt.Println("b_ :", res[0])
 	require.Contains(t, res[1], "middle")
 	require.Contains(t, res[2], "foo")
+
+	// Logs stored at equal times are kept in the order they are stored.
+	require.NoError(t, ls.Store(t2, "middle 2"))
+	require.NoError(t, ls.Store(t2, "middle 3"))
+
+	res, err = ls.LogsSince(t1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"middle", "middle 2", "middle 3", "foo"}, res)
+}
+
+func TestLogStore_Write(t *testing.T) {
+	ls, path, cleanup := tempLogStore(t, LogStoreConfig{})
+
+	_, err := ls.Write([]byte("[2000-01-01T00:00:00Z] INFO foo\n[2000-01-01T00:00:00Z] ERR"))
+	require.NoError(t, err)
+
+	_, err = ls.Write([]byte("OR bar\n[2000-01-01T00:00:00Z] DEBUG baz"))
+	require.NoError(t, err)
+
+	page, err := ls.Logs(LogQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Logs, 2)
+	require.True(t, page.Logs[0].Time.Before(page.Logs[1].Time))
+
+	// Incomplete lines are stored on close, logs survive reopening.
+	require.NoError(t, ls.Close())
+
+	ls, err = NewBoltLogStore(path, "foo", LogStoreConfig{})
+	require.NoError(t, err)
+
+	defer cleanup()
+
+	res, err := ls.LogsSince(time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"[2000-01-01T00:00:00Z] INFO foo",
+		"[2000-01-01T00:00:00Z] ERROR bar",
+		"[2000-01-01T00:00:00Z] DEBUG baz",
+	}, res)
+
+	page, err = ls.Logs(LogQuery{Level: "info"})
+	require.NoError(t, err)
+	require.Len(t, page.Logs, 2)
+	require.Contains(t, page.Logs[1].Line, "bar")
+
+	_, err = ls.Logs(LogQuery{Level: "loud"})
+	require.Error(t, err)
+}
+
+func TestLogStore_Logs(t *testing.T) {
+	ls, _, cleanup := tempLogStore(t, LogStoreConfig{})
+	defer cleanup()
+
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ls.Store(start, fmt.Sprint(i)))
+	}
+
+	var lines []string
+
+	q := LogQuery{Limit: 2}
+
+	for {
+		page, err := ls.Logs(q)
+		require.NoError(t, err)
+
+		for _, e := range page.Logs {
+			lines = append(lines, e.Line)
+		}
+
+		if !page.More {
+			break
+		}
+
+		q.Since = page.Logs[len(page.Logs)-1].Time
+	}
+
+	require.Equal(t, []string{"0", "1", "2", "3", "4"}, lines)
+}
+
+func TestLogStore_Retention(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		ls, _, cleanup := tempLogStore(t, LogStoreConfig{MaxSize: 10})
+		defer cleanup()
+
+		for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+			require.NoError(t, ls.Store(time.Now(), s))
+		}
+
+		res, err := ls.LogsSince(time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"bbbb", "cccc"}, res)
+	})
+
+	t.Run("age", func(t *testing.T) {
+		ls, _, cleanup := tempLogStore(t, LogStoreConfig{MaxAge: time.Hour})
+		defer cleanup()
+
+		require.NoError(t, ls.Store(time.Now().Add(-2*time.Hour), "old"))
+		require.NoError(t, ls.Store(time.Now(), "new"))
+
+		res, err := ls.LogsSince(time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"new"}, res)
+	})
+}
+
+func TestLogStore_flushFailure(t *testing.T) {
+	ls, path, _ := tempLogStore(t, LogStoreConfig{})
+	defer func() { require.NoError(t, os.RemoveAll(filepath.Dir(path))) }()
+
+	require.NoError(t, ls.Store(time.Now(), "saved"))
+	require.NoError(t, ls.Flush())
+
+	bl := ls.(*boltDBappLogs)
+	size := bl.size
+
+	// Flushes fail once the database is closed.
+	require.NoError(t, bl.db.Close())
+
+	for i := 0; i < logMaxPending+10; i++ {
+		require.NoError(t, ls.Store(time.Now(), "lost"))
+	}
+
+	require.Error(t, ls.Flush())
+
+	bl.mu.Lock()
+	assert.Len(t, bl.pending, logMaxPending)
+	assert.Equal(t, size, bl.size)
+	bl.mu.Unlock()
+
+	assert.Error(t, ls.Close())
+}
+
+func TestLogStore_Subscribe(t *testing.T) {
+	ls, _, cleanup := tempLogStore(t, LogStoreConfig{})
+	defer cleanup()
+
+	require.NoError(t, ls.Store(time.Now(), "before"))
+	require.NoError(t, ls.Flush())
+
+	logs, cancel := ls.Subscribe()
+
+	require.NoError(t, ls.Store(time.Now(), "after"))
+
+	select {
+	case e := <-logs:
+		require.Equal(t, "after", e.Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("log is not received")
+	}
+
+	cancel()
+
+	_, ok := <-logs
+	require.False(t, ok)
 }
@@ -1,34 +1,29 @@
 package app
 
 import (
-	"io/ioutil"
-	"os"
+	"bytes"
 	"testing"
-	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
-// TestNewLogger tests that after the new logger is created logs with it are persisted into storage
+// TestNewLogger tests that levels of logs written by the app logger are recognized by LogStore
 func TestNewLogger(t *testing.T) {
-	p, err := ioutil.TempFile("", "test-db")
-	require.NoError(t, err)
+	var buf bytes.Buffer
 
-	defer os.Remove(p.Name()) // nolint
+	l := NewLogger("foo")
+	l.SetOutput(&buf)
 
-	appName := "foo"
+	l.Warn("bar")
 
-	l, _, err := newPersistentLogger(p.Name(), appName)
-	require.NoError(t, err)
+	line := buf.String()
+	require.Contains(t, line, "bar")
 
-	dbl, err := newBoltDB(p.Name(), appName)
-	require.NoError(t, err)
+	level, ok := logLevel(line)
+	require.True(t, ok)
+	require.Equal(t, logrus.WarnLevel, level)
 
-	l.Info("bar")
-
-	beginning := time.Unix(0, 0)
-	res, err := dbl.(*boltDBappLogs).LogsSince(beginning)
-	require.NoError(t, err)
-	require.Len(t, res, 1)
-	require.Contains(t, res[0], "bar")
+	_, ok = logLevel("panic: foo")
+	require.False(t, ok)
 }
@@ -3,10 +3,14 @@
 package app
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
 	appnet "github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
 
+	router "github.com/SkycoinProject/skywire-mainnet/pkg/router"
+
 	routing "github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 
 	time "time"
@@ -101,6 +105,76 @@ func (_m *MockRPCClient) Dial(remote appnet.Addr) (uint16, routing.Port, error)
 	return r0, r1, r2
 }
 
+// DialContext provides a mock function with given fields: ctx, remote, opts
+func (_m *MockRPCClient) DialContext(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (uint16, routing.Port, error) {
+	ret := _m.Called(ctx, remote, opts)
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func(context.Context, appnet.Addr, *router.DialOptions) uint16); ok {
+		r0 = rf(ctx, remote, opts)
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	var r1 routing.Port
+	if rf, ok := ret.Get(1).(func(context.Context, appnet.Addr, *router.DialOptions) routing.Port); ok {
+		r1 = rf(ctx, remote, opts)
+	} else {
+		r1 = ret.Get(1).(routing.Port)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, appnet.Addr, *router.DialOptions) error); ok {
+		r2 = rf(ctx, remote, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DialPacket provides a mock function with given fields: ctx, remote, opts
+func (_m *MockRPCClient) DialPacket(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (uint16, routing.Port, error) {
+	ret := _m.Called(ctx, remote, opts)
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func(context.Context, appnet.Addr, *router.DialOptions) uint16); ok {
+		r0 = rf(ctx, remote, opts)
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	var r1 routing.Port
+	if rf, ok := ret.Get(1).(func(context.Context, appnet.Addr, *router.DialOptions) routing.Port); ok {
+		r1 = rf(ctx, remote, opts)
+	} else {
+		r1 = ret.Get(1).(routing.Port)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, appnet.Addr, *router.DialOptions) error); ok {
+		r2 = rf(ctx, remote, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Heartbeat provides a mock function with given fields: interval
+func (_m *MockRPCClient) Heartbeat(interval time.Duration) error {
+	ret := _m.Called(interval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Duration) error); ok {
+		r0 = rf(interval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Listen provides a mock function with given fields: local
 func (_m *MockRPCClient) Listen(local appnet.Addr) (uint16, error) {
 	ret := _m.Called(local)
@@ -122,6 +196,27 @@ func (_m *MockRPCClient) Listen(local appnet.Addr) (uint16, error) {
 	return r0, r1
 }
 
+// ListenPacket provides a mock function with given fields: local
+func (_m *MockRPCClient) ListenPacket(local appnet.Addr) (uint16, error) {
+	ret := _m.Called(local)
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func(appnet.Addr) uint16); ok {
+		r0 = rf(local)
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(appnet.Addr) error); ok {
+		r1 = rf(local)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Read provides a mock function with given fields: connID, b
 func (_m *MockRPCClient) Read(connID uint16, b []byte) (int, error) {
 	ret := _m.Called(connID, b)
@@ -171,6 +266,20 @@ func (_m *MockRPCClient) SetReadDeadline(connID uint16, d time.Time) error {
 	return r0
 }
 
+// SetStatus provides a mock function with given fields: ready, message
+func (_m *MockRPCClient) SetStatus(ready bool, message string) error {
+	ret := _m.Called(ready, message)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, string) error); ok {
+		r0 = rf(ready, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetWriteDeadline provides a mock function with given fields: connID, d
 func (_m *MockRPCClient) SetWriteDeadline(connID uint16, d time.Time) error {
 	ret := _m.Called(connID, d)
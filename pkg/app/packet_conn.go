@@ -0,0 +1,331 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/deadline"
+)
+
+// MaxPacketSize is the maximum size of a datagram, which is the maximum payload of a route data packet.
+const MaxPacketSize = math.MaxUint16
+
+// packetQueueSize is the number of received datagrams kept until they are read.
+// Datagrams received while the queue is full are dropped.
+const packetQueueSize = 128
+
+var (
+	// ErrPacketTooLarge is returned when a datagram exceeds MaxPacketSize.
+	ErrPacketTooLarge = errors.New("packet is too large")
+
+	// ErrUnknownPeer is returned when writing to a remote which has no connection to a PacketConn.
+	ErrUnknownPeer = errors.New("no connection to remote")
+
+	// ErrNotConnected is returned when writing to a listening PacketConn without an address.
+	ErrNotConnected = errors.New("packet conn is not connected")
+)
+
+// timeoutError is returned when a read deadline of a PacketConn is exceeded.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// datagram is a datagram received from a remote.
+type datagram struct {
+	data []byte
+	from appnet.Addr
+}
+
+// PacketConn is a datagram connection of an app, each write of which is sent as a single data packet.
+// If dialed, it is connected to a single remote. If listening, it receives datagrams of all remotes
+// which dial its port, and can reply to them.
+// It implements `net.PacketConn`, and `net.Conn` if dialed.
+type PacketConn struct {
+	log    *logging.Logger
+	local  appnet.Addr
+	remote *appnet.Addr // nil if listening
+	lis    net.Listener // nil if dialed
+
+	mu            sync.Mutex
+	conns         map[appnet.Addr]net.Conn // connections of remotes
+	writeDeadline time.Time
+	readErr       error // error which stopped reads of a dialed conn
+
+	datagrams    chan datagram
+	readDeadline deadline.PipeDeadline
+	readDone     chan struct{} // closed once a dialed conn fails to read
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+func newPacketConn(log *logging.Logger, local appnet.Addr) *PacketConn {
+	return &PacketConn{
+		log:          log,
+		local:        local,
+		conns:        make(map[appnet.Addr]net.Conn),
+		datagrams:    make(chan datagram, packetQueueSize),
+		readDeadline: deadline.MakePipeDeadline(),
+		readDone:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// newDialedPacketConn wraps a dialed packet connection.
+func newDialedPacketConn(log *logging.Logger, conn net.Conn) *PacketConn {
+	remote := conn.RemoteAddr().(appnet.Addr)
+
+	c := newPacketConn(log, conn.LocalAddr().(appnet.Addr))
+	c.remote = &remote
+	c.conns[remote] = conn
+
+	go c.serveConn(remote, conn)
+
+	return c
+}
+
+// newListeningPacketConn wraps a packet listener.
+func newListeningPacketConn(log *logging.Logger, lis net.Listener) *PacketConn {
+	c := newPacketConn(log, lis.Addr().(appnet.Addr))
+	c.lis = lis
+
+	go c.serveListener()
+
+	return c
+}
+
+// ReadFrom reads the next datagram into `p`. If `p` is shorter than the datagram, the rest of it is discarded.
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	// received datagrams are read even if reading of a dialed conn already stopped
+	select {
+	case d := <-c.datagrams:
+		return copy(p, d.data), d.from, nil
+	default:
+	}
+
+	select {
+	case d := <-c.datagrams:
+		return copy(p, d.data), d.from, nil
+	case <-c.readDeadline.Wait():
+		return 0, nil, timeoutError{}
+	case <-c.readDone:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		return 0, nil, c.readErr
+	case <-c.done:
+		return 0, nil, io.ErrClosedPipe
+	}
+}
+
+// WriteTo writes `p` as a single datagram to `addr`, which should be an `appnet.Addr`.
+// A listening PacketConn can only write to remotes which are connected to it.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if len(p) > MaxPacketSize {
+		return 0, ErrPacketTooLarge
+	}
+
+	remote, ok := addr.(appnet.Addr)
+	if !ok {
+		return 0, ErrUnknownPeer
+	}
+
+	c.mu.Lock()
+	conn, ok := c.conns[remote]
+	c.mu.Unlock()
+
+	if !ok {
+		return 0, ErrUnknownPeer
+	}
+
+	return conn.Write(p)
+}
+
+// Read reads the next datagram into `p`, see `ReadFrom`.
+func (c *PacketConn) Read(p []byte) (int, error) {
+	n, _, err := c.ReadFrom(p)
+	return n, err
+}
+
+// Write writes `p` as a single datagram to the remote of a dialed PacketConn.
+func (c *PacketConn) Write(p []byte) (int, error) {
+	if c.remote == nil {
+		return 0, ErrNotConnected
+	}
+
+	return c.WriteTo(p, *c.remote)
+}
+
+// Close closes the connection, along with connections of all remotes.
+func (c *PacketConn) Close() error {
+	err := io.ErrClosedPipe
+
+	c.closeOnce.Do(func() {
+		err = nil
+
+		close(c.done)
+
+		if c.lis != nil {
+			err = c.lis.Close()
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		for remote, conn := range c.conns {
+			if cErr := conn.Close(); cErr != nil && err == nil {
+				err = cErr
+			}
+
+			delete(c.conns, remote)
+		}
+	})
+
+	return err
+}
+
+// LocalAddr returns local address of the connection.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns remote address of a dialed connection, or nil if listening.
+func (c *PacketConn) RemoteAddr() net.Addr {
+	if c.remote == nil {
+		return nil
+	}
+
+	return *c.remote
+}
+
+// SetDeadline sets both read and write deadlines.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets read deadline.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	return nil
+}
+
+// SetWriteDeadline sets write deadline of connections of all remotes, including ones connected later.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeDeadline = t
+
+	for _, conn := range c.conns {
+		if err := conn.SetWriteDeadline(t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serveListener accepts connections of remotes until the listener is closed.
+func (c *PacketConn) serveListener() {
+	for {
+		conn, err := c.lis.Accept()
+		if err != nil {
+			c.log.WithError(err).Debug("Stopped accepting packet conns.")
+			return
+		}
+
+		remote := conn.RemoteAddr().(appnet.Addr)
+
+		if !c.addConn(remote, conn) {
+			c.closeConn(conn)
+			return
+		}
+
+		go c.serveConn(remote, conn)
+	}
+}
+
+// addConn adds a connection of `remote`, replacing the previous one. It returns false if PacketConn is closed.
+func (c *PacketConn) addConn(remote appnet.Addr, conn net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	if !c.writeDeadline.IsZero() {
+		if err := conn.SetWriteDeadline(c.writeDeadline); err != nil {
+			c.log.WithError(err).Warn("Failed to set write deadline of packet conn.")
+		}
+	}
+
+	if prev, ok := c.conns[remote]; ok {
+		c.closeConn(prev)
+	}
+
+	c.conns[remote] = conn
+
+	return true
+}
+
+// serveConn reads datagrams of `remote` until its connection fails.
+func (c *PacketConn) serveConn(remote appnet.Addr, conn net.Conn) {
+	buf := make([]byte, MaxPacketSize)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			c.removeConn(remote, conn, err)
+			return
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		select {
+		case c.datagrams <- datagram{data: data, from: remote}:
+		default:
+			c.log.Debugf("Dropped datagram of %s, as the queue is full.", remote)
+		}
+	}
+}
+
+// removeConn removes connection of `remote` after it failed with `err`.
+func (c *PacketConn) removeConn(remote appnet.Addr, conn net.Conn, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conns[remote] == conn {
+		delete(c.conns, remote)
+		c.closeConn(conn)
+	}
+
+	if c.remote != nil {
+		c.readErr = err
+		close(c.readDone)
+	}
+}
+
+// closeConn closes `conn` and logs unexpected errors.
+func (c *PacketConn) closeConn(conn net.Conn) {
+	if err := conn.Close(); err != nil && err != io.ErrClosedPipe &&
+		!strings.Contains(err.Error(), "use of closed network connection") {
+		c.log.WithError(err).Debug("Unexpected error while closing packet conn.")
+	}
+}
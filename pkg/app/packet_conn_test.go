@@ -0,0 +1,166 @@
+package app
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// addrConn is a pipe with app addresses. Each write to a pipe is delivered by a single read,
+// as long as the read buffer is large enough, like with packet conns.
+type addrConn struct {
+	net.Conn
+	local, remote appnet.Addr
+}
+
+func (c *addrConn) LocalAddr() net.Addr  { return c.local }
+func (c *addrConn) RemoteAddr() net.Addr { return c.remote }
+
+func pipeAddrs(local, remote appnet.Addr) (net.Conn, net.Conn) {
+	c1, c2 := net.Pipe()
+	return &addrConn{Conn: c1, local: local, remote: remote}, &addrConn{Conn: c2, local: remote, remote: local}
+}
+
+func makeAddr(port uint16) appnet.Addr {
+	pk, _ := cipher.GenerateKeyPair()
+	return appnet.Addr{Net: appnet.TypeSkynet, PubKey: pk, Port: routing.Port(port)}
+}
+
+type chanListener struct {
+	addr  appnet.Addr
+	conns chan net.Conn
+}
+
+func (l *chanListener) Accept() (net.Conn, error) {
+	conn, ok := <-l.conns
+	if !ok {
+		return nil, io.ErrClosedPipe
+	}
+
+	return conn, nil
+}
+
+func (l *chanListener) Close() error   { close(l.conns); return nil }
+func (l *chanListener) Addr() net.Addr { return l.addr }
+
+func TestPacketConn_Dialed(t *testing.T) {
+	local, remote := makeAddr(1), makeAddr(2)
+	conn, remoteConn := pipeAddrs(local, remote)
+
+	c := newDialedPacketConn(logging.MustGetLogger("packet_conn"), conn)
+	assert.Equal(t, local, c.LocalAddr())
+	assert.Equal(t, remote, c.RemoteAddr())
+
+	go func() {
+		_, err := remoteConn.Write([]byte("foo"))
+		assert.NoError(t, err)
+		_, err = remoteConn.Write([]byte("barbaz"))
+		assert.NoError(t, err)
+	}()
+
+	buf := make([]byte, 16)
+
+	n, from, err := c.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf[:n]))
+	assert.Equal(t, remote, from)
+
+	// Rest of a datagram is discarded on short reads.
+	n, err = c.Read(buf[:3])
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf[:n]))
+
+	go func() {
+		n, err := remoteConn.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "qux", string(buf[:n]))
+	}()
+
+	_, err = c.Write([]byte("qux"))
+	require.NoError(t, err)
+
+	_, err = c.WriteTo([]byte("qux"), makeAddr(3))
+	assert.Equal(t, ErrUnknownPeer, err)
+
+	_, err = c.Write(make([]byte, MaxPacketSize+1))
+	assert.Equal(t, ErrPacketTooLarge, err)
+
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err = c.Read(buf)
+	assert.Equal(t, timeoutError{}, err)
+
+	// Reads fail once the remote closes the connection.
+	require.NoError(t, c.SetReadDeadline(time.Time{}))
+	require.NoError(t, remoteConn.Close())
+	_, err = c.Read(buf)
+	assert.Equal(t, io.EOF, err)
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, io.ErrClosedPipe, c.Close())
+}
+
+func TestPacketConn_Listening(t *testing.T) {
+	local, remote1, remote2 := makeAddr(1), makeAddr(2), makeAddr(3)
+
+	lis := &chanListener{addr: local, conns: make(chan net.Conn, 2)}
+	c := newListeningPacketConn(logging.MustGetLogger("packet_conn"), lis)
+	assert.Equal(t, local, c.LocalAddr())
+	assert.Nil(t, c.RemoteAddr())
+
+	conn1, remoteConn1 := pipeAddrs(local, remote1)
+	conn2, remoteConn2 := pipeAddrs(local, remote2)
+	lis.conns <- conn1
+	lis.conns <- conn2
+
+	go func() {
+		_, err := remoteConn1.Write([]byte("foo"))
+		assert.NoError(t, err)
+	}()
+
+	go func() {
+		_, err := remoteConn2.Write([]byte("bar"))
+		assert.NoError(t, err)
+	}()
+
+	buf := make([]byte, 16)
+	received := make(map[string]net.Addr)
+
+	for i := 0; i < 2; i++ {
+		n, from, err := c.ReadFrom(buf)
+		require.NoError(t, err)
+
+		received[string(buf[:n])] = from
+	}
+
+	assert.Equal(t, map[string]net.Addr{"foo": remote1, "bar": remote2}, received)
+
+	// Replies go to the connection of the remote.
+	go func() {
+		n, err := remoteConn2.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "baz", string(buf[:n]))
+	}()
+
+	_, err := c.WriteTo([]byte("baz"), remote2)
+	require.NoError(t, err)
+
+	_, err = c.Write([]byte("baz"))
+	assert.Equal(t, ErrNotConnected, err)
+
+	require.NoError(t, c.Close())
+
+	_, err = remoteConn1.Write([]byte("foo"))
+	assert.Error(t, err)
+
+	_, _, err = c.ReadFrom(buf)
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
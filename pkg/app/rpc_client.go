@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"net/rpc"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
@@ -16,7 +18,10 @@ import (
 // RPCClient describes RPC interface to communicate with the server.
 type RPCClient interface {
 	Dial(remote appnet.Addr) (connID uint16, localPort routing.Port, err error)
+	DialContext(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (connID uint16, localPort routing.Port, err error)
+	DialPacket(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (connID uint16, localPort routing.Port, err error)
 	Listen(local appnet.Addr) (uint16, error)
+	ListenPacket(local appnet.Addr) (uint16, error)
 	Accept(lisID uint16) (connID uint16, remote appnet.Addr, err error)
 	Write(connID uint16, b []byte) (int, error)
 	Read(connID uint16, b []byte) (int, error)
@@ -25,6 +30,8 @@ type RPCClient interface {
 	SetDeadline(connID uint16, d time.Time) error
 	SetReadDeadline(connID uint16, d time.Time) error
 	SetWriteDeadline(connID uint16, d time.Time) error
+	SetStatus(ready bool, message string) error
+	Heartbeat(interval time.Duration) error
 }
 
 // rpcClient implements `RPCClient`.
@@ -51,6 +58,56 @@ func (c *rpcClient) Dial(remote appnet.Addr) (connID uint16, localPort routing.P
 	return resp.ConnID, resp.LocalPort, nil
 }
 
+// DialContext sends `DialContext` command to the server, along with the deadline of `ctx`.
+func (c *rpcClient) DialContext(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (connID uint16,
+	localPort routing.Port, err error) {
+	return c.dialContext(ctx, "DialContext", remote, opts)
+}
+
+// DialPacket sends `DialPacket` command to the server, along with the deadline of `ctx`.
+func (c *rpcClient) DialPacket(ctx context.Context, remote appnet.Addr, opts *router.DialOptions) (connID uint16,
+	localPort routing.Port, err error) {
+	return c.dialContext(ctx, "DialPacket", remote, opts)
+}
+
+// dialContext sends dial `method` to the server, along with the deadline of `ctx`.
+// If `ctx` is done before the server replies, the connection dialed by the server is closed.
+func (c *rpcClient) dialContext(ctx context.Context, method string, remote appnet.Addr,
+	opts *router.DialOptions) (uint16, routing.Port, error) {
+	req := appserver.DialReq{
+		Remote: remote,
+		Opts:   opts,
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Deadline = deadline
+	}
+
+	var resp appserver.DialResp
+
+	call := c.rpc.Go(c.formatMethod(method), &req, &resp, nil)
+
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			return 0, 0, contextErr(call.Error)
+		}
+
+		return resp.ConnID, resp.LocalPort, nil
+	case <-ctx.Done():
+		go func() {
+			<-call.Done
+
+			// nobody is going to use the connection
+			if call.Error == nil {
+				_ = c.CloseConn(resp.ConnID) //nolint:errcheck
+			}
+		}()
+
+		return 0, 0, ctx.Err()
+	}
+}
+
 // Listen sends `Listen` command to the server.
 func (c *rpcClient) Listen(local appnet.Addr) (uint16, error) {
 	var lisID uint16
@@ -61,6 +118,16 @@ func (c *rpcClient) Listen(local appnet.Addr) (uint16, error) {
 	return lisID, nil
 }
 
+// ListenPacket sends `ListenPacket` command to the server.
+func (c *rpcClient) ListenPacket(local appnet.Addr) (uint16, error) {
+	var lisID uint16
+	if err := c.rpc.Call(c.formatMethod("ListenPacket"), &local, &lisID); err != nil {
+		return 0, err
+	}
+
+	return lisID, nil
+}
+
 // Accept sends `Accept` command to the server.
 func (c *rpcClient) Accept(lisID uint16) (connID uint16, remote appnet.Addr, err error) {
 	var acceptResp appserver.AcceptResp
@@ -145,6 +212,33 @@ func (c *rpcClient) SetWriteDeadline(id uint16, t time.Time) error {
 	return c.rpc.Call(c.formatMethod("SetWriteDeadline"), &req, nil)
 }
 
+// SetStatus sends `SetStatus` command to the server.
+func (c *rpcClient) SetStatus(ready bool, message string) error {
+	req := appserver.StatusReq{
+		Ready:   ready,
+		Message: message,
+	}
+
+	return c.rpc.Call(c.formatMethod("SetStatus"), &req, nil)
+}
+
+// Heartbeat sends `Heartbeat` command to the server.
+func (c *rpcClient) Heartbeat(interval time.Duration) error {
+	return c.rpc.Call(c.formatMethod("Heartbeat"), &interval, nil)
+}
+
+// contextErr restores context errors, which lose their identity when passed over RPC.
+func contextErr(err error) error {
+	switch err.Error() {
+	case context.DeadlineExceeded.Error():
+		return context.DeadlineExceeded
+	case context.Canceled.Error():
+		return context.Canceled
+	default:
+		return err
+	}
+}
+
 // formatMethod formats complete RPC method signature.
 func (c *rpcClient) formatMethod(method string) string {
 	const methodFmt = "%s.%s"
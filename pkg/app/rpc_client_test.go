@@ -14,11 +14,13 @@ import (
 	"github.com/SkycoinProject/dmsg"
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/nettest"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
@@ -83,6 +85,143 @@ func TestRPCClient_Dial(t *testing.T) {
 	})
 }
 
+func TestRPCClient_DialContext(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		s := prepRPCServer(t, prepGateway())
+		rpcL, lisCleanup := prepListener(t)
+		defer lisCleanup()
+		go s.Accept(rpcL)
+
+		cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+		dmsgLocal, dmsgRemote, _, remote := prepAddrs()
+
+		dialConn := &appcommon.MockConn{}
+		dialConn.On("LocalAddr").Return(dmsgLocal)
+		dialConn.On("RemoteAddr").Return(dmsgRemote)
+
+		opts := &router.DialOptions{MinForwardRts: 1, MaxForwardRts: 1, MaxHops: 3, TransportTypes: []string{"stcp"}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		deadline, _ := ctx.Deadline()
+
+		dialCtx := mock.MatchedBy(func(ctx context.Context) bool {
+			d, ok := ctx.Deadline()
+			return ok && d.Equal(deadline) && assert.ObjectsAreEqual(opts, appnet.DialOptionsFromContext(ctx))
+		})
+
+		n := &appnet.MockNetworker{}
+		n.On("DialContext", dialCtx, remote).Return(dialConn, testhelpers.NoErr)
+
+		appnet.ClearNetworkers()
+		err := appnet.AddNetworker(appnet.TypeDmsg, n)
+		require.NoError(t, err)
+
+		connID, localPort, err := cl.DialContext(ctx, remote, opts)
+		require.NoError(t, err)
+		require.Equal(t, connID, uint16(1))
+		require.Equal(t, localPort, routing.Port(dmsgLocal.Port))
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		s := prepRPCServer(t, prepGateway())
+		rpcL, lisCleanup := prepListener(t)
+		defer lisCleanup()
+		go s.Accept(rpcL)
+
+		cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+		_, _, _, remote := prepAddrs()
+
+		var dialConn net.Conn
+
+		n := &appnet.MockNetworker{}
+		n.On("DialContext", mock.Anything, remote).Return(func(ctx context.Context, _ appnet.Addr) net.Conn {
+			<-ctx.Done()
+			return dialConn
+		}, func(ctx context.Context, _ appnet.Addr) error {
+			return ctx.Err()
+		})
+
+		appnet.ClearNetworkers()
+		err := appnet.AddNetworker(appnet.TypeDmsg, n)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, _, err = cl.DialContext(ctx, remote, nil)
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		s := prepRPCServer(t, prepGateway())
+		rpcL, lisCleanup := prepListener(t)
+		defer lisCleanup()
+		go s.Accept(rpcL)
+
+		cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+		_, _, _, remote := prepAddrs()
+
+		_, _, err := cl.DialContext(context.Background(), remote, &router.DialOptions{MinHops: 2, MaxHops: 1})
+		require.Error(t, err)
+	})
+}
+
+func TestRPCClient_DialPacket(t *testing.T) {
+	s := prepRPCServer(t, prepGateway())
+	rpcL, lisCleanup := prepListener(t)
+	defer lisCleanup()
+	go s.Accept(rpcL)
+
+	cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+	dmsgLocal, dmsgRemote, _, remote := prepAddrs()
+
+	dialConn := &appcommon.MockConn{}
+	dialConn.On("LocalAddr").Return(dmsgLocal)
+	dialConn.On("RemoteAddr").Return(dmsgRemote)
+
+	n := &appnet.MockNetworker{}
+	n.On("DialPacketContext", mock.Anything, remote).Return(dialConn, testhelpers.NoErr)
+
+	appnet.ClearNetworkers()
+	err := appnet.AddNetworker(appnet.TypeDmsg, n)
+	require.NoError(t, err)
+
+	connID, localPort, err := cl.DialPacket(context.Background(), remote, nil)
+	require.NoError(t, err)
+	require.Equal(t, connID, uint16(1))
+	require.Equal(t, localPort, routing.Port(dmsgLocal.Port))
+}
+
+func TestRPCClient_ListenPacket(t *testing.T) {
+	s := prepRPCServer(t, prepGateway())
+	rpcL, lisCleanup := prepListener(t)
+	defer lisCleanup()
+	go s.Accept(rpcL)
+
+	cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+	_, _, local, _ := prepAddrs()
+
+	var listenLis net.Listener
+
+	n := &appnet.MockNetworker{}
+	n.On("ListenPacketContext", mock.Anything, local).Return(listenLis, testhelpers.NoErr)
+
+	appnet.ClearNetworkers()
+	err := appnet.AddNetworker(appnet.TypeDmsg, n)
+	require.NoError(t, err)
+
+	lisID, err := cl.ListenPacket(local)
+	require.NoError(t, err)
+	require.Equal(t, lisID, uint16(1))
+}
+
 func TestRPCClient_Listen(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		s := prepRPCServer(t, prepGateway())
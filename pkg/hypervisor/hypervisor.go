@@ -3,9 +3,11 @@ package hypervisor
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/rpc"
@@ -142,7 +144,7 @@ func (hv *Hypervisor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	r.Route("/", func(r chi.Router) {
 		r.Route("/api", func(r chi.Router) {
-			r.Use(middleware.Timeout(httpTimeout))
+			r.Use(timeoutUnlessFollowing(httpTimeout))
 
 			r.Get("/ping", hv.getPong())
 
@@ -166,14 +168,22 @@ func (hv *Hypervisor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				r.Get("/visors/{pk}/health", hv.getHealth())
 				r.Get("/visors/{pk}/uptime", hv.getUptime())
 				r.Get("/visors/{pk}/apps", hv.getApps())
+				r.Post("/visors/{pk}/apps", hv.postApp())
 				r.Get("/visors/{pk}/apps/{app}", hv.getApp())
 				r.Put("/visors/{pk}/apps/{app}", hv.putApp())
+				r.Delete("/visors/{pk}/apps/{app}", hv.deleteApp())
+				r.Put("/visors/{pk}/apps/{app}/binary", hv.putAppBinary())
+				r.Get("/visors/{pk}/apps/{app}/settings", hv.getAppSettings())
+				r.Put("/visors/{pk}/apps/{app}/settings", hv.putAppSettings())
 				r.Get("/visors/{pk}/apps/{app}/logs", hv.appLogsSince())
 				r.Get("/visors/{pk}/transport-types", hv.getTransportTypes())
 				r.Get("/visors/{pk}/transports", hv.getTransports())
 				r.Post("/visors/{pk}/transports", hv.postTransport())
 				r.Get("/visors/{pk}/transports/{tid}", hv.getTransport())
 				r.Delete("/visors/{pk}/transports/{tid}", hv.deleteTransport())
+				r.Get("/visors/{pk}/stcp-table", hv.getSTCPTable())
+				r.Post("/visors/{pk}/stcp-table", hv.postSTCPEntry())
+				r.Delete("/visors/{pk}/stcp-table/{remote}", hv.deleteSTCPEntry())
 				r.Get("/visors/{pk}/routes", hv.getRoutes())
 				r.Post("/visors/{pk}/routes", hv.postRoute())
 				r.Get("/visors/{pk}/routes/{rid}", hv.getRoute())
@@ -358,6 +368,85 @@ func (hv *Hypervisor) getApps() http.HandlerFunc {
 	})
 }
 
+func (hv *Hypervisor) postApp() http.HandlerFunc {
+	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var reqBody struct {
+			App       string       `json:"app"`
+			Port      routing.Port `json:"port"`
+			Args      []string     `json:"args,omitempty"`
+			AutoStart bool         `json:"autostart"`
+		}
+
+		if err := httputil.ReadJSON(r, &reqBody); err != nil {
+			if err != io.EOF {
+				log.Warnf("postApp request: %v", err)
+			}
+
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrMalformedRequest)
+
+			return
+		}
+
+		conf := visor.AppConfig{
+			App:       reqBody.App,
+			AutoStart: reqBody.AutoStart,
+			Port:      reqBody.Port,
+			Args:      reqBody.Args,
+		}
+
+		if err := ctx.RPC.AddApp(conf); err != nil {
+			httputil.WriteJSON(w, r, appErrStatus(err), err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, &visor.AppState{
+			Name:      conf.App,
+			AutoStart: conf.AutoStart,
+			Port:      conf.Port,
+			Status:    visor.AppStatusStopped,
+		})
+	})
+}
+
+func (hv *Hypervisor) deleteApp() http.HandlerFunc {
+	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		if err := ctx.RPC.RemoveApp(ctx.App.Name); err != nil {
+			httputil.WriteJSON(w, r, appErrStatus(err), err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	})
+}
+
+// putAppBinary saves the request body as binary of an app.
+// The binary may be uploaded before the app is added, hence the app does not have to exist.
+// Hex-encoded SHA256 of the binary is expected in the 'checksum' query parameter.
+func (hv *Hypervisor) putAppBinary() http.HandlerFunc {
+	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		appName := chi.URLParam(r, "app")
+
+		checksum := r.URL.Query().Get("checksum")
+		if checksum == "" {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, errors.New("'checksum' query parameter is required"))
+			return
+		}
+
+		binary, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, visor.MaxAppBinarySize))
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusRequestEntityTooLarge, visor.ErrAppBinaryTooLarge)
+			return
+		}
+
+		if err := ctx.RPC.UploadApp(appName, binary, checksum); err != nil {
+			httputil.WriteJSON(w, r, appErrStatus(err), err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	})
+}
+
 // returns an app summary of a given visor's pk and app name
 func (hv *Hypervisor) getApp() http.HandlerFunc {
 	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
@@ -369,12 +458,7 @@ func (hv *Hypervisor) getApp() http.HandlerFunc {
 // nolint: funlen,gocognit,godox
 func (hv *Hypervisor) putApp() http.HandlerFunc {
 	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
-		var reqBody struct {
-			AutoStart *bool          `json:"autostart,omitempty"`
-			Status    *int           `json:"status,omitempty"`
-			Passcode  *string        `json:"passcode,omitempty"`
-			PK        *cipher.PubKey `json:"pk,omitempty"`
-		}
+		var reqBody map[string]json.RawMessage
 
 		if err := httputil.ReadJSON(r, &reqBody); err != nil {
 			if err != io.EOF {
@@ -386,38 +470,61 @@ func (hv *Hypervisor) putApp() http.HandlerFunc {
 			return
 		}
 
-		if reqBody.AutoStart != nil {
-			if *reqBody.AutoStart != ctx.App.AutoStart {
-				if err := ctx.RPC.SetAutoStart(ctx.App.Name, *reqBody.AutoStart); err != nil {
-					httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
-					return
-				}
+		var autoStart *bool
+		var status *int
+		settings := make(map[string]string)
+
+		for k, v := range reqBody {
+			var err error
+
+			switch k {
+			case "autostart":
+				err = json.Unmarshal(v, &autoStart)
+			case "status":
+				err = json.Unmarshal(v, &status)
+			default:
+				settings[k], err = settingFromJSON(v)
+			}
+
+			if err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, ErrMalformedRequest)
+				return
 			}
 		}
 
-		const (
-			skysocksName       = "skysocks"
-			skysocksClientName = "skysocks-client"
-		)
+		// Everything is validated before any change is made, so that a rejected request changes nothing.
+		if status != nil && *status != statusStop && *status != statusStart {
+			errMsg := fmt.Errorf("value of 'status' field is %d when expecting 0 or 1", *status)
+			httputil.WriteJSON(w, r, http.StatusBadRequest, errMsg)
+			return
+		}
 
-		if reqBody.Passcode != nil && ctx.App.Name == skysocksName {
-			if err := ctx.RPC.SetSocksPassword(*reqBody.Passcode); err != nil {
-				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		if len(settings) > 0 {
+			if err := validateAppSettings(ctx.RPC, ctx.App.Name, settings); err != nil {
+				httputil.WriteJSON(w, r, appErrStatus(err), err)
 				return
 			}
 		}
 
-		if reqBody.PK != nil && ctx.App.Name == skysocksClientName {
-			log.Errorf("SETTING PK: %s", *reqBody.PK)
-			if err := ctx.RPC.SetSocksClientPK(*reqBody.PK); err != nil {
-				log.Errorf("ERROR SETTING PK")
-				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+		if autoStart != nil {
+			if *autoStart != ctx.App.AutoStart {
+				if err := ctx.RPC.SetAutoStart(ctx.App.Name, *autoStart); err != nil {
+					httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+					return
+				}
+			}
+		}
+
+		// Any other fields are app settings.
+		if len(settings) > 0 {
+			if err := ctx.RPC.SetAppSettings(ctx.App.Name, settings, true); err != nil {
+				httputil.WriteJSON(w, r, appErrStatus(err), err)
 				return
 			}
 		}
 
-		if reqBody.Status != nil {
-			switch *reqBody.Status {
+		if status != nil {
+			switch *status {
 			case statusStop:
 				if err := ctx.RPC.StopApp(ctx.App.Name); err != nil {
 					httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
@@ -429,10 +536,6 @@ func (hv *Hypervisor) putApp() http.HandlerFunc {
 					httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 					return
 				}
-			default:
-				errMsg := fmt.Errorf("value of 'status' field is %d when expecting 0 or 1", *reqBody.Status)
-				httputil.WriteJSON(w, r, http.StatusBadRequest, errMsg)
-				return
 			}
 		}
 
@@ -440,41 +543,249 @@ func (hv *Hypervisor) putApp() http.HandlerFunc {
 	})
 }
 
+// validateAppSettings checks 'settings' against the settings declared by the manifest of app 'appName'.
+func validateAppSettings(rpc visor.RPCClient, appName string, settings map[string]string) error {
+	values, err := rpc.GetAppSettings(appName)
+	if err != nil {
+		return err
+	}
+
+	manifest := visor.AppManifest{Settings: make([]visor.AppSetting, len(values))}
+	for i, v := range values {
+		manifest.Settings[i] = v.AppSetting
+	}
+
+	return manifest.ValidateSettings(settings)
+}
+
+func (hv *Hypervisor) getAppSettings() http.HandlerFunc {
+	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		settings, err := ctx.RPC.GetAppSettings(ctx.App.Name)
+		if err != nil {
+			httputil.WriteJSON(w, r, appErrStatus(err), err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, settings)
+	})
+}
+
+func (hv *Hypervisor) putAppSettings() http.HandlerFunc {
+	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var reqBody struct {
+			Settings map[string]json.RawMessage `json:"settings"`
+			Restart  bool                       `json:"restart"`
+		}
+
+		if err := httputil.ReadJSON(r, &reqBody); err != nil {
+			if err != io.EOF {
+				log.Warnf("putAppSettings request: %v", err)
+			}
+
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrMalformedRequest)
+
+			return
+		}
+
+		settings := make(map[string]string, len(reqBody.Settings))
+
+		for k, v := range reqBody.Settings {
+			s, err := settingFromJSON(v)
+			if err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, ErrMalformedRequest)
+				return
+			}
+
+			settings[k] = s
+		}
+
+		if err := ctx.RPC.SetAppSettings(ctx.App.Name, settings, reqBody.Restart); err != nil {
+			httputil.WriteJSON(w, r, appErrStatus(err), err)
+			return
+		}
+
+		settingValues, err := ctx.RPC.GetAppSettings(ctx.App.Name)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, settingValues)
+	})
+}
+
+// settingFromJSON returns the value of an app setting given as any JSON scalar.
+func settingFromJSON(v json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s, nil
+	}
+
+	var scalar interface{}
+	if err := json.Unmarshal(v, &scalar); err != nil {
+		return "", err
+	}
+
+	switch scalar.(type) {
+	case bool, float64:
+		return string(v), nil
+	default:
+		return "", fmt.Errorf("value %s is not a string, number or boolean", v)
+	}
+}
+
+// appErrStatus returns HTTP status for an error of app management RPCs.
+// Errors lose their type over RPC, so they are compared by message.
+func appErrStatus(err error) int {
+	hasPrefix := func(errs ...error) bool {
+		for _, e := range errs {
+			if strings.HasPrefix(err.Error(), e.Error()) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch {
+	case hasPrefix(visor.ErrUnknownAppSetting, visor.ErrInvalidAppSetting, visor.ErrInvalidAppName,
+		visor.ErrChecksumMismatch, visor.ErrAppBinaryTooLarge):
+		return http.StatusBadRequest
+	case hasPrefix(visor.ErrAppExists, visor.ErrAppPortInUse):
+		return http.StatusConflict
+	case err.Error() == visor.ErrNoAppSettings.Error(), err.Error() == visor.ErrUnknownApp.Error():
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// timeoutUnlessFollowing is middleware.Timeout, except for requests which follow a stream, such as app logs.
+func timeoutUnlessFollowing(timeout time.Duration) func(next http.Handler) http.Handler {
+	withTimeout := middleware.Timeout(timeout)
+
+	return func(next http.Handler) http.Handler {
+		timed := withTimeout(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("follow") == "true" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			timed.ServeHTTP(w, r)
+		})
+	}
+}
+
 // LogsRes parses logs as json, along with the last obtained timestamp for use on subsequent requests
 type LogsRes struct {
 	LastLogTimestamp string   `json:"last_log_timestamp"`
 	Logs             []string `json:"logs"`
+	More             bool     `json:"more"` // there are more logs after the last one
 }
 
+// appLogsSince returns logs of an app stored after the 'since' query parameter.
+// Logs may be filtered by minimum 'level' and paged with 'limit'.
+// With 'follow=true', logs are streamed as server-sent events as they are stored.
 func (hv *Hypervisor) appLogsSince() http.HandlerFunc {
 	return hv.withCtx(hv.appCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
 		since := r.URL.Query().Get("since")
 		since = strings.Replace(since, " ", "+", 1) // we need to put '+' again that was replaced in the query string
 
+		// Clients of a log stream resume from the last received event when reconnecting.
+		if id := r.Header.Get("Last-Event-ID"); id != "" {
+			since = id
+		}
+
 		// if time is not parsable or empty default to return all logs
 		t, err := time.Parse(time.RFC3339Nano, since)
 		if err != nil {
 			t = time.Unix(0, 0)
 		}
 
-		logs, err := ctx.RPC.LogsSince(t, ctx.App.Name)
+		q := app.LogQuery{Since: t, Level: r.URL.Query().Get("level")}
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			if q.Limit, err = strconv.Atoi(limit); err != nil {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if r.URL.Query().Get("follow") == "true" {
+			hv.followAppLogs(w, r, ctx, q)
+			return
+		}
+
+		page, err := ctx.RPC.AppLogs(ctx.App.Name, q)
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		if len(logs) == 0 {
+		if len(page.Logs) == 0 {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, fmt.Errorf("no new available logs"))
 			return
 		}
 
-		httputil.WriteJSON(w, r, http.StatusOK, &LogsRes{
-			LastLogTimestamp: app.TimestampFromLog(logs[len(logs)-1]),
-			Logs:             logs,
-		})
+		res := &LogsRes{
+			LastLogTimestamp: page.Logs[len(page.Logs)-1].Time.Format(time.RFC3339Nano),
+			Logs:             make([]string, len(page.Logs)),
+			More:             page.More,
+		}
+
+		for i, e := range page.Logs {
+			res.Logs[i] = e.Line
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, res)
 	})
 }
 
+// followAppLogs streams logs as server-sent events, each holding a JSON-encoded app.LogEntry.
+// The time of the last sent log is the event ID, for clients to resume from.
+func (hv *Hypervisor) followAppLogs(w http.ResponseWriter, r *http.Request, ctx *httpCtx, q app.LogQuery) {
+	const pollTimeout = 30 * time.Second
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httputil.WriteJSON(w, r, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for r.Context().Err() == nil {
+		page, err := ctx.RPC.FollowAppLogs(ctx.App.Name, q, pollTimeout)
+		if err != nil {
+			log.WithError(err).WithField("app", ctx.App.Name).Warn("Failed to follow app logs")
+			fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error()) // nolint: errcheck
+			flusher.Flush()
+
+			return
+		}
+
+		for _, e := range page.Logs {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", e.Time.Format(time.RFC3339Nano), data); err != nil {
+				return
+			}
+
+			q.Since = e.Time
+		}
+
+		flusher.Flush()
+	}
+}
+
 func (hv *Hypervisor) getTransportTypes() http.HandlerFunc {
 	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
 		types, err := ctx.RPC.TransportTypes()
@@ -558,6 +869,58 @@ func (hv *Hypervisor) deleteTransport() http.HandlerFunc {
 	})
 }
 
+func (hv *Hypervisor) getSTCPTable() http.HandlerFunc {
+	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		entries, err := ctx.RPC.STCPTable()
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, entries)
+	})
+}
+
+func (hv *Hypervisor) postSTCPEntry() http.HandlerFunc {
+	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		var reqBody visor.STCPEntry
+
+		if err := httputil.ReadJSON(r, &reqBody); err != nil {
+			if err != io.EOF {
+				log.Warnf("postSTCPEntry request: %v", err)
+			}
+
+			httputil.WriteJSON(w, r, http.StatusBadRequest, ErrMalformedRequest)
+
+			return
+		}
+
+		if err := ctx.RPC.AddSTCPEntry(reqBody.PK, reqBody.Addr); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, reqBody)
+	})
+}
+
+func (hv *Hypervisor) deleteSTCPEntry() http.HandlerFunc {
+	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
+		remote, err := pkFromParam(r, "remote")
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := ctx.RPC.RemoveSTCPEntry(remote); err != nil {
+			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, true)
+	})
+}
+
 type routingRuleResp struct {
 	Key     routing.RouteID      `json:"key"`
 	Rule    string               `json:"rule"`
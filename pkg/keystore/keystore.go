@@ -0,0 +1,219 @@
+// Package keystore implements passphrase-encrypted storage of a visor's secret key.
+//
+// The secret key is encrypted with chacha20poly1305, using a key derived from the passphrase via scrypt.
+// The public key is authenticated as additional data, so a keystore cannot be tampered to claim another identity.
+package keystore
+
+import (
+	gocipher "crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version is the current version of the keystore file format.
+	Version = 1
+
+	kdfScrypt        = "scrypt"
+	cipherChaCha20   = "chacha20poly1305"
+	saltSize         = 32
+	filePerm         = 0600
+	minPassphraseLen = 1
+
+	// limits of scrypt parameters, which keep a crafted keystore from exhausting memory or CPU
+	maxN = 1 << 20
+	maxR = 32
+	maxP = 16
+)
+
+var (
+	// ErrWrongPassphrase occurs when the keystore cannot be decrypted with the provided passphrase.
+	ErrWrongPassphrase = errors.New("keystore: wrong passphrase or corrupted keystore")
+
+	// ErrEmptyPassphrase occurs on attempt to encrypt a keystore with an empty passphrase.
+	ErrEmptyPassphrase = errors.New("keystore: passphrase cannot be empty")
+
+	// ErrAlreadyExists occurs on attempt to overwrite an existing keystore file.
+	ErrAlreadyExists = errors.New("keystore: file already exists")
+
+	// ErrInvalidParams occurs when scrypt parameters are out of the supported range.
+	ErrInvalidParams = errors.New("keystore: invalid scrypt parameters")
+)
+
+// Params are the scrypt cost parameters.
+type Params struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// DefaultParams are the recommended scrypt parameters for interactive use.
+var DefaultParams = Params{N: 1 << 15, R: 8, P: 1}
+
+// Validate checks that N is a power of 2 greater than 1 and that none of the parameters exceeds it's limit.
+func (p Params) Validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 || p.N > maxN {
+		return fmt.Errorf("%w: n should be a power of 2 in range [2, %d], got %d", ErrInvalidParams, maxN, p.N)
+	}
+	if p.R < 1 || p.R > maxR {
+		return fmt.Errorf("%w: r should be in range [1, %d], got %d", ErrInvalidParams, maxR, p.R)
+	}
+	if p.P < 1 || p.P > maxP {
+		return fmt.Errorf("%w: p should be in range [1, %d], got %d", ErrInvalidParams, maxP, p.P)
+	}
+	return nil
+}
+
+// KeyStore is the on-disk representation of an encrypted secret key.
+type KeyStore struct {
+	Version    int           `json:"version"`
+	PubKey     cipher.PubKey `json:"public_key"`
+	KDF        string        `json:"kdf"`
+	KDFParams  Params        `json:"kdf_params"`
+	Salt       string        `json:"salt"`
+	Cipher     string        `json:"cipher"`
+	Nonce      string        `json:"nonce"`
+	CipherText string        `json:"ciphertext"`
+}
+
+// Encrypt encrypts 'sk' with 'passphrase'.
+func Encrypt(sk cipher.SecKey, passphrase []byte, params Params) (*KeyStore, error) {
+	if len(passphrase) < minPassphraseLen {
+		return nil, ErrEmptyPassphrase
+	}
+	pk, err := sk.PubKey()
+	if err != nil {
+		return nil, fmt.Errorf("keystore: invalid secret key: %v", err)
+	}
+
+	salt := cipher.RandByte(saltSize)
+	aead, err := newAEAD(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := cipher.RandByte(aead.NonceSize())
+
+	return &KeyStore{
+		Version:    Version,
+		PubKey:     pk,
+		KDF:        kdfScrypt,
+		KDFParams:  params,
+		Salt:       hex.EncodeToString(salt),
+		Cipher:     cipherChaCha20,
+		Nonce:      hex.EncodeToString(nonce),
+		CipherText: hex.EncodeToString(aead.Seal(nil, nonce, sk[:], pk[:])),
+	}, nil
+}
+
+// Decrypt decrypts the secret key with 'passphrase'.
+func (ks *KeyStore) Decrypt(passphrase []byte) (cipher.SecKey, error) {
+	if ks.Version != Version {
+		return cipher.SecKey{}, fmt.Errorf("keystore: unsupported version %d", ks.Version)
+	}
+	if ks.KDF != kdfScrypt || ks.Cipher != cipherChaCha20 {
+		return cipher.SecKey{}, fmt.Errorf("keystore: unsupported kdf %q or cipher %q", ks.KDF, ks.Cipher)
+	}
+
+	salt, err := hex.DecodeString(ks.Salt)
+	if err != nil {
+		return cipher.SecKey{}, fmt.Errorf("keystore: invalid salt: %v", err)
+	}
+	nonce, err := hex.DecodeString(ks.Nonce)
+	if err != nil {
+		return cipher.SecKey{}, fmt.Errorf("keystore: invalid nonce: %v", err)
+	}
+	ct, err := hex.DecodeString(ks.CipherText)
+	if err != nil {
+		return cipher.SecKey{}, fmt.Errorf("keystore: invalid ciphertext: %v", err)
+	}
+
+	aead, err := newAEAD(passphrase, salt, ks.KDFParams)
+	if err != nil {
+		return cipher.SecKey{}, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return cipher.SecKey{}, errors.New("keystore: invalid nonce size")
+	}
+	plain, err := aead.Open(nil, nonce, ct, ks.PubKey[:])
+	if err != nil {
+		return cipher.SecKey{}, ErrWrongPassphrase
+	}
+
+	var sk cipher.SecKey
+	if err := sk.UnmarshalBinary(plain); err != nil {
+		return cipher.SecKey{}, fmt.Errorf("keystore: invalid secret key: %v", err)
+	}
+	if pk, err := sk.PubKey(); err != nil || pk != ks.PubKey {
+		return cipher.SecKey{}, errors.New("keystore: secret key does not match public key")
+	}
+	return sk, nil
+}
+
+func newAEAD(passphrase, salt []byte, params Params) (gocipher.AEAD, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %v", err)
+	}
+	return chacha20poly1305.New(key)
+}
+
+// Load reads a KeyStore from 'path'.
+func Load(path string) (*KeyStore, error) {
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	var ks KeyStore
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, fmt.Errorf("keystore: invalid file %s: %v", path, err)
+	}
+	return &ks, nil
+}
+
+// Save writes the KeyStore to 'path', readable by the owner only.
+// An existing file is only overwritten if 'replace' is set.
+func (ks *KeyStore) Save(path string, replace bool) error {
+	if _, err := os.Stat(path); err == nil && !replace {
+		return ErrAlreadyExists
+	}
+
+	b, err := json.MarshalIndent(ks, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that an existing keystore is never left half-written.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, filePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Unlock loads the KeyStore at 'path' and decrypts it's secret key with 'passphrase'.
+func Unlock(path string, passphrase []byte) (cipher.PubKey, cipher.SecKey, error) {
+	ks, err := Load(path)
+	if err != nil {
+		return cipher.PubKey{}, cipher.SecKey{}, err
+	}
+	sk, err := ks.Decrypt(passphrase)
+	if err != nil {
+		return cipher.PubKey{}, cipher.SecKey{}, err
+	}
+	return ks.PubKey, sk, nil
+}
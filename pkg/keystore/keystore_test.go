@@ -0,0 +1,139 @@
+package keystore
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams keep the tests fast.
+var testParams = Params{N: 1 << 4, R: 8, P: 1}
+
+func TestKeyStore(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	passphrase := []byte("correct horse battery staple")
+
+	ks, err := Encrypt(sk, passphrase, testParams)
+	require.NoError(t, err)
+	assert.Equal(t, pk, ks.PubKey)
+
+	got, err := ks.Decrypt(passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, sk, got)
+
+	_, err = ks.Decrypt([]byte("wrong"))
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	t.Run("empty passphrase", func(t *testing.T) {
+		_, err := Encrypt(sk, nil, testParams)
+		assert.Equal(t, ErrEmptyPassphrase, err)
+	})
+
+	t.Run("tampered public key", func(t *testing.T) {
+		tampered := *ks
+		tampered.PubKey, _ = cipher.GenerateKeyPair()
+		_, err := tampered.Decrypt(passphrase)
+		assert.Equal(t, ErrWrongPassphrase, err)
+	})
+}
+
+func TestKeyStore_Decrypt_params(t *testing.T) {
+	_, sk := cipher.GenerateKeyPair()
+	passphrase := []byte("passphrase")
+
+	ks, err := Encrypt(sk, passphrase, testParams)
+	require.NoError(t, err)
+
+	for _, params := range []Params{
+		{N: 1 << 30, R: 8, P: 1},
+		{N: 1000, R: 8, P: 1},
+		{N: 1 << 4, R: 1 << 20, P: 1},
+		{N: 1 << 4, R: 8, P: 1 << 20},
+		{N: 1 << 4, R: 0, P: 1},
+	} {
+		tampered := *ks
+		tampered.KDFParams = params
+		_, err := tampered.Decrypt(passphrase)
+		assert.True(t, errors.Is(err, ErrInvalidParams), params)
+	}
+}
+
+func TestKeyStore_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	pk, sk := cipher.GenerateKeyPair()
+	passphrase := []byte("passphrase")
+	path := filepath.Join(dir, "keys", "visor.key")
+
+	ks, err := Encrypt(sk, passphrase, testParams)
+	require.NoError(t, err)
+	require.NoError(t, ks.Save(path, false))
+	assert.Equal(t, ErrAlreadyExists, ks.Save(path, false))
+	require.NoError(t, ks.Save(path, true))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(filePerm), info.Mode().Perm())
+
+	gotPK, gotSK, err := Unlock(path, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, pk, gotPK)
+	assert.Equal(t, sk, gotSK)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		require.NoError(t, os.Setenv(EnvPassphrase, "from-env"))
+		defer func() { require.NoError(t, os.Unsetenv(EnvPassphrase)) }()
+
+		p, err := ReadPassphrase(-1, "")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("from-env"), p)
+	})
+
+	t.Run("fd", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		_, err = w.WriteString("from-fd\nignored\n")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		p, err := ReadPassphrase(int(r.Fd()), "")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("from-fd"), p)
+	})
+}
+
+func TestReadNewPassphrase(t *testing.T) {
+	require.NoError(t, os.Setenv(EnvPassphrase, "current"))
+	defer func() { require.NoError(t, os.Unsetenv(EnvPassphrase)) }()
+
+	t.Run("current passphrase is ignored", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		_, err = w.WriteString("from-fd\n")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		p, err := ReadNewPassphrase(int(r.Fd()), EnvNewPassphrase)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("from-fd"), p)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		require.NoError(t, os.Setenv(EnvNewPassphrase, "new"))
+		defer func() { require.NoError(t, os.Unsetenv(EnvNewPassphrase)) }()
+
+		p, err := ReadNewPassphrase(-1, EnvNewPassphrase)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("new"), p)
+	})
+}
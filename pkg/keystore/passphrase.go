@@ -0,0 +1,90 @@
+package keystore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	// EnvPassphrase is the environment variable which the keystore passphrase is read from.
+	EnvPassphrase = "SW_KEYSTORE_PASSPHRASE"
+
+	// EnvNewPassphrase is the environment variable which a replacement keystore passphrase is read from.
+	EnvNewPassphrase = "SW_KEYSTORE_NEW_PASSPHRASE"
+)
+
+// ErrNoPassphrase occurs when no passphrase source is available.
+var ErrNoPassphrase = errors.New("keystore: no passphrase provided: set " + EnvPassphrase +
+	", provide a passphrase file descriptor or run in a terminal")
+
+// ReadPassphrase obtains a passphrase from the first available source of:
+// the EnvPassphrase environment variable, the file descriptor 'fd' (ignored if negative),
+// or an interactive prompt on the terminal.
+func ReadPassphrase(fd int, prompt string) ([]byte, error) {
+	if p, ok := os.LookupEnv(EnvPassphrase); ok {
+		return []byte(p), nil
+	}
+	if fd >= 0 {
+		return readPassphraseFD(fd)
+	}
+	return promptPassphrase(prompt)
+}
+
+// ReadNewPassphrase obtains a passphrase for a new keystore from the first available source of:
+// the environment variable 'env', the file descriptor 'fd' (ignored if negative),
+// or an interactive prompt on the terminal, which asks for confirmation.
+// When changing the passphrase of an existing keystore, 'env' should be EnvNewPassphrase,
+// so that the current passphrase is not taken for the new one.
+func ReadNewPassphrase(fd int, env string) ([]byte, error) {
+	if p, ok := os.LookupEnv(env); ok {
+		return []byte(p), nil
+	}
+	if fd >= 0 {
+		return readPassphraseFD(fd)
+	}
+	p, err := promptPassphrase("New keystore passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	confirm, err := promptPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(p, confirm) {
+		return nil, errors.New("keystore: passphrases do not match")
+	}
+	return p, nil
+}
+
+// readPassphraseFD reads the first line from the file descriptor.
+func readPassphraseFD(fd int) ([]byte, error) {
+	f := os.NewFile(uintptr(fd), "passphrase")
+	if f == nil {
+		return nil, fmt.Errorf("keystore: invalid passphrase file descriptor %d", fd)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, fmt.Errorf("keystore: failed to read passphrase from file descriptor %d: %v", fd, err)
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func promptPassphrase(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, ErrNoPassphrase
+	}
+	if _, err := fmt.Fprint(os.Stderr, prompt); err != nil {
+		return nil, err
+	}
+	p, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr) //nolint:errcheck
+	return p, err
+}
@@ -11,6 +11,7 @@ import (
 // Recorder records request metrics.
 type Recorder interface {
 	Record(resTime time.Duration, hasErr bool)
+	RecordRejected(reason string)
 }
 
 type dummy struct{}
@@ -22,10 +23,13 @@ func NewDummy() Recorder {
 
 func (m *dummy) Record(resTime time.Duration, hasErr bool) {}
 
+func (m *dummy) RecordRejected(reason string) {}
+
 type prom struct {
-	reqCount prometheus.Counter
-	errCount prometheus.Counter
-	resTime  prometheus.Summary
+	reqCount      prometheus.Counter
+	errCount      prometheus.Counter
+	rejectedCount *prometheus.CounterVec
+	resTime       prometheus.Summary
 }
 
 // NewPrometheus constructs a new Prometheus metrics recorder.
@@ -39,6 +43,10 @@ func NewPrometheus(service string) Recorder {
 			Name: service + "_errors_total",
 			Help: "The total number of 500 responses",
 		}),
+		rejectedCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: service + "_rejected_total",
+			Help: "The total number of requests rejected due to limits",
+		}, []string{"reason"}),
 		resTime: promauto.NewSummary(prometheus.SummaryOpts{
 			Name: service + "_response_time",
 			Help: "Response times",
@@ -54,6 +62,10 @@ func (m *prom) Record(resTime time.Duration, hasErr bool) {
 	}
 }
 
+func (m *prom) RecordRejected(reason string) {
+	m.rejectedCount.WithLabelValues(reason).Inc()
+}
+
 // Handler provides metrics middleware.
 func Handler(m Recorder, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,154 @@
+package rfclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// DefaultCacheTTL is the default duration for which found routes are cached.
+const DefaultCacheTTL = time.Minute
+
+// Invalidator is implemented by clients which cache routes.
+type Invalidator interface {
+	// InvalidateTransport drops cached routes which go over the transport of 'id'.
+	InvalidateTransport(id uuid.UUID)
+}
+
+// CachedClient caches routes found by another Client.
+// Cached routes expire after a TTL, and are dropped once any of their transports is reported to be down.
+// Failed requests, and responses without routes for any of the requested edges, are not cached.
+type CachedClient struct {
+	client Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	entries map[string]cacheEntry
+	mx      sync.Mutex
+}
+
+type cacheEntry struct {
+	paths   map[routing.PathEdges][]routing.Path
+	expires time.Time
+}
+
+// NewCached constructs a new CachedClient, which caches routes found by 'client' for 'ttl'.
+func NewCached(client Client, ttl time.Duration) *CachedClient {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &CachedClient{
+		client:  client,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// FindRoutes implements Client.
+func (c *CachedClient) FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *RouteOptions) (map[routing.PathEdges][]routing.Path, error) {
+	key := cacheKey(rts, opts)
+
+	c.mx.Lock()
+	c.prune()
+	entry, ok := c.entries[key]
+	c.mx.Unlock()
+
+	if ok {
+		return copyPaths(entry.paths), nil
+	}
+
+	paths, err := c.client.FindRoutes(ctx, rts, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing routes may appear once new transports are up, so the route finder is asked again next time.
+	if !hasAllEdges(rts, paths) {
+		return paths, nil
+	}
+
+	c.mx.Lock()
+	c.entries[key] = cacheEntry{paths: copyPaths(paths), expires: c.now().Add(c.ttl)}
+	c.mx.Unlock()
+
+	return paths, nil
+}
+
+// InvalidateTransport implements Invalidator.
+func (c *CachedClient) InvalidateTransport(id uuid.UUID) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for key, entry := range c.entries {
+		if usesTransport(entry.paths, id) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// prune drops expired entries. It is to be called with the lock held.
+func (c *CachedClient) prune() {
+	now := c.now()
+
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func cacheKey(rts []routing.PathEdges, opts *RouteOptions) string {
+	if opts == nil {
+		return fmt.Sprint(rts)
+	}
+
+	return fmt.Sprintf("%v %d-%d", rts, opts.MinHops, opts.MaxHops)
+}
+
+// hasAllEdges tells whether 'paths' has routes for each of 'rts'.
+func hasAllEdges(rts []routing.PathEdges, paths map[routing.PathEdges][]routing.Path) bool {
+	for _, edges := range rts {
+		if len(paths[edges]) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func usesTransport(paths map[routing.PathEdges][]routing.Path, id uuid.UUID) bool {
+	for _, edgePaths := range paths {
+		for _, path := range edgePaths {
+			for _, hop := range path {
+				if hop.TpID == id {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// copyPaths copies 'paths', so that callers may not modify cached routes.
+func copyPaths(paths map[routing.PathEdges][]routing.Path) map[routing.PathEdges][]routing.Path {
+	out := make(map[routing.PathEdges][]routing.Path, len(paths))
+
+	for edges, edgePaths := range paths {
+		cp := make([]routing.Path, len(edgePaths))
+		for i, path := range edgePaths {
+			cp[i] = append(routing.Path(nil), path...)
+		}
+
+		out[edges] = cp
+	}
+
+	return out
+}
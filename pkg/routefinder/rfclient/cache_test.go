@@ -0,0 +1,117 @@
+package rfclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+type countingClient struct {
+	Client
+	calls int
+}
+
+func (c *countingClient) FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *RouteOptions) (map[routing.PathEdges][]routing.Path, error) {
+	c.calls++
+	return c.Client.FindRoutes(ctx, rts, opts)
+}
+
+func TestCachedClient(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	edges := []routing.PathEdges{{pk1, pk2}}
+	opts := &RouteOptions{MaxHops: 5}
+
+	now := time.Now()
+
+	client := &countingClient{Client: NewMock()}
+	c := NewCached(client, time.Minute)
+	c.now = func() time.Time { return now }
+
+	find := func() map[routing.PathEdges][]routing.Path {
+		paths, err := c.FindRoutes(context.TODO(), edges, opts)
+		require.NoError(t, err)
+
+		return paths
+	}
+
+	paths := find()
+	tpID := paths[edges[0]][0][0].TpID
+
+	// Cached routes are returned as copies.
+	paths[edges[0]][0][0].TpID = uuid.New()
+	require.Equal(t, tpID, find()[edges[0]][0][0].TpID)
+	require.Equal(t, 1, client.calls)
+
+	// Other options are not served from cache.
+	_, err := c.FindRoutes(context.TODO(), edges, &RouteOptions{MaxHops: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, client.calls)
+
+	// Routes over other transports are kept.
+	c.InvalidateTransport(uuid.New())
+	find()
+	require.Equal(t, 2, client.calls)
+
+	c.InvalidateTransport(tpID)
+	find()
+	require.Equal(t, 3, client.calls)
+
+	// Routes expire.
+	now = now.Add(time.Minute)
+	find()
+	require.Equal(t, 4, client.calls)
+
+	// Errors are not cached.
+	client.Client.(*mockClient).SetError(testhelpers.Err)
+	now = now.Add(time.Minute)
+
+	_, err = c.FindRoutes(context.TODO(), edges, opts)
+	require.Equal(t, testhelpers.Err, err)
+
+	_, err = c.FindRoutes(context.TODO(), edges, opts)
+	require.Equal(t, testhelpers.Err, err)
+	require.Equal(t, 6, client.calls)
+}
+
+// noRoutesClient finds no routes for the second requested edges.
+type noRoutesClient struct {
+	Client
+}
+
+func (c noRoutesClient) FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *RouteOptions) (map[routing.PathEdges][]routing.Path, error) {
+	paths, err := c.Client.FindRoutes(ctx, rts, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths[rts[1]] = nil
+
+	return paths, nil
+}
+
+func TestCachedClient_noRoutes(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	edges := []routing.PathEdges{{pk1, pk2}, {pk2, pk1}}
+
+	client := &countingClient{Client: noRoutesClient{Client: NewMock()}}
+	c := NewCached(client, time.Minute)
+
+	for i := 1; i <= 2; i++ {
+		paths, err := c.FindRoutes(context.TODO(), edges, nil)
+		require.NoError(t, err)
+		require.Len(t, paths[edges[0]], 1)
+		require.Empty(t, paths[edges[1]])
+		require.Equal(t, i, client.calls)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/url"
 	"strings"
@@ -44,6 +43,11 @@ type HTTPError struct {
 	Code    int    `json:"code"`
 }
 
+// Error implements error.
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
 // Client implements route finding operations.
 type Client interface {
 	FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *RouteOptions) (map[routing.PathEdges][]routing.Path, error)
@@ -110,7 +114,15 @@ func (c *apiClient) FindRoutes(ctx context.Context, rts []routing.PathEdges, opt
 			return nil, err
 		}
 
-		return nil, errors.New(apiErr.Error.Message)
+		if apiErr.Error == nil {
+			apiErr.Error = &HTTPError{Message: res.Status}
+		}
+
+		if apiErr.Error.Code == 0 {
+			apiErr.Error.Code = res.StatusCode
+		}
+
+		return nil, apiErr.Error
 	}
 
 	var paths map[routing.PathEdges][]routing.Path
@@ -0,0 +1,90 @@
+package rfclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// ErrNoEndpoints is returned when a client is created without route finder addresses.
+var ErrNoEndpoints = errors.New("no route finder addresses")
+
+// failoverClient queries the first available of several route finders.
+// The route finder which answered last is queried first, so that a failed route finder
+// is only retried after the others fail.
+type failoverClient struct {
+	clients []Client
+	addrs   []string
+	current int
+	mx      sync.Mutex
+}
+
+// NewHTTPFailover constructs a new Client that communicates with route finders of 'addrs' over http.
+// A route finder is failed over to the next one if it is unreachable, or fails with a server error.
+func NewHTTPFailover(addrs []string, apiTimeout time.Duration) (Client, error) {
+	if len(addrs) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	if len(addrs) == 1 {
+		return NewHTTP(addrs[0], apiTimeout), nil
+	}
+
+	clients := make([]Client, len(addrs))
+	for i, addr := range addrs {
+		clients[i] = NewHTTP(addr, apiTimeout)
+	}
+
+	return &failoverClient{clients: clients, addrs: addrs}, nil
+}
+
+// FindRoutes implements Client.
+func (c *failoverClient) FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *RouteOptions) (map[routing.PathEdges][]routing.Path, error) {
+	c.mx.Lock()
+	first := c.current
+	c.mx.Unlock()
+
+	var err error
+
+	for i := range c.clients {
+		idx := (first + i) % len(c.clients)
+
+		var paths map[routing.PathEdges][]routing.Path
+
+		paths, err = c.clients[idx].FindRoutes(ctx, rts, opts)
+		if !shouldFailOver(err) {
+			c.mx.Lock()
+			c.current = idx
+			c.mx.Unlock()
+
+			return paths, err
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		log.WithError(err).Warnf("Route finder %s failed, failing over.", c.addrs[idx])
+	}
+
+	return nil, err
+}
+
+// shouldFailOver returns whether 'err' means that the route finder is unavailable,
+// as opposed to a route finder answering that there are no routes.
+func shouldFailOver(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= http.StatusInternalServerError
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package rfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+func TestNewHTTPFailover(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	edges := []routing.PathEdges{{pk1, pk2}}
+
+	var requests []string
+
+	serve := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, name)
+
+			w.WriteHeader(status)
+
+			var body interface{} = map[routing.PathEdges][]routing.Path{edges[0]: {{{From: pk1, To: pk2}}}}
+			if status != http.StatusOK {
+				body = HTTPResponse{Error: &HTTPError{Message: http.StatusText(status), Code: status}}
+			}
+
+			require.NoError(t, json.NewEncoder(w).Encode(body))
+		}))
+	}
+
+	failing := serve("failing", http.StatusInternalServerError)
+	defer failing.Close()
+
+	notFound := serve("not found", http.StatusNotFound)
+	defer notFound.Close()
+
+	ok := serve("ok", http.StatusOK)
+	defer ok.Close()
+
+	_, err := NewHTTPFailover(nil, time.Second)
+	require.Equal(t, ErrNoEndpoints, err)
+
+	t.Run("fails over on server errors", func(t *testing.T) {
+		requests = nil
+
+		c, err := NewHTTPFailover([]string{failing.URL, ok.URL}, time.Second)
+		require.NoError(t, err)
+
+		paths, err := c.FindRoutes(context.TODO(), edges, nil)
+		require.NoError(t, err)
+		require.Len(t, paths[edges[0]], 1)
+
+		// The route finder which answered is preferred.
+		_, err = c.FindRoutes(context.TODO(), edges, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"failing", "ok", "ok"}, requests)
+	})
+
+	t.Run("does not fail over on client errors", func(t *testing.T) {
+		requests = nil
+
+		c, err := NewHTTPFailover([]string{notFound.URL, ok.URL}, time.Second)
+		require.NoError(t, err)
+
+		_, err = c.FindRoutes(context.TODO(), edges, nil)
+		require.Equal(t, &HTTPError{Message: http.StatusText(http.StatusNotFound), Code: http.StatusNotFound}, err)
+		require.Equal(t, []string{"not found"}, requests)
+	})
+
+	t.Run("unreachable route finders", func(t *testing.T) {
+		requests = nil
+
+		c, err := NewHTTPFailover([]string{"http://127.0.0.1:1", failing.URL}, time.Second)
+		require.NoError(t, err)
+
+		_, err = c.FindRoutes(context.TODO(), edges, nil)
+		require.Error(t, err)
+		require.Equal(t, []string{"failing"}, requests)
+	})
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+const (
+	gatewayName = "RPCGateway"
+
+	// directRollbackTimeout is the timeout of releasing route IDs reserved by a remote visor for a failed route setup.
+	directRollbackTimeout = 10 * time.Second
+)
+
+// ErrNoDirectTransport is returned when routes are to be set up directly, but there is no transport to the remote visor.
+var ErrNoDirectTransport = errors.New("no transport to remote visor")
+
+// directTransport returns a transport to 'rPK' which is up, if any.
+// Transports of the most preferred of 'tpTypes' are returned first.
+func (r *router) directTransport(rPK cipher.PubKey, tpTypes []string) *transport.ManagedTransport {
+	var (
+		out     *transport.ManagedTransport
+		outRank = len(tpTypes)
+	)
+
+	r.tm.WalkTransports(func(tp *transport.ManagedTransport) bool {
+		if tp.Remote() != rPK || !tp.IsUp() {
+			return true
+		}
+
+		rank := len(tpTypes)
+
+		for i, tpType := range tpTypes {
+			if tp.Type() == tpType {
+				rank = i
+				break
+			}
+		}
+
+		if out == nil || rank < outRank {
+			out, outRank = tp, rank
+		}
+
+		return outRank != 0
+	})
+
+	return out
+}
+
+// setupDirect sets up routes over transport 'tpID', which connects the edges of 'desc', without a setup node.
+// The remote visor reserves route IDs and adds its rules when requested over its setup port,
+// in the same way as when it is requested by a setup node.
+// The returned rules are to be saved locally.
+func (r *router) setupDirect(ctx context.Context, desc routing.RouteDescriptor, tpID uuid.UUID) (routing.EdgeRules, error) {
+	traceID := uuid.New().String()
+	rPK := desc.DstPK()
+
+	log := r.logger.WithField("trace_id", traceID)
+	log.Infof("Setting up routes directly to %s over transport %s", rPK, tpID)
+
+	conn, err := r.n.Dial(ctx, snet.DmsgType, rPK, snet.AwaitSetupPort)
+	if err != nil {
+		return routing.EdgeRules{}, fmt.Errorf("failed to dial remote: %v", err)
+	}
+
+	client := rpc.NewClient(conn)
+
+	defer func() {
+		if err := client.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close RPC client.")
+		}
+	}()
+
+	lIDs, err := r.ReserveKeys(2)
+	if err != nil {
+		return routing.EdgeRules{}, fmt.Errorf("failed to reserve local route IDs: %v", err)
+	}
+
+	var rIDs []routing.RouteID
+
+	err = callRPC(ctx, client, gatewayName+".ReserveIDs", ReserveIDsRequest{TraceID: traceID, N: 2}, &rIDs)
+	if err == nil && len(rIDs) != 2 {
+		err = fmt.Errorf("unexpected number of route IDs: %d", len(rIDs))
+	}
+
+	if err != nil {
+		r.DelRules(lIDs)
+		return routing.EdgeRules{}, fmt.Errorf("failed to reserve remote route IDs: %v", err)
+	}
+
+	localRules, remoteRules := directRules(desc, tpID, lIDs, rIDs)
+
+	var ok bool
+
+	err = callRPC(ctx, client, gatewayName+".AddEdgeRules", AddEdgeRulesRequest{TraceID: traceID, Rules: remoteRules}, &ok)
+	if err == nil && !ok {
+		err = errors.New("rules were not accepted")
+	}
+
+	if err != nil {
+		r.DelRules(lIDs)
+		rollbackDirect(log, client, traceID, rIDs)
+
+		return routing.EdgeRules{}, fmt.Errorf("failed to add remote rules: %v", err)
+	}
+
+	return localRules, nil
+}
+
+// directRules generates the rules of the edges of a route group with routes of a single hop over 'tpID',
+// from the route IDs reserved by the local and remote visors, in the same way as setup nodes do.
+func directRules(desc routing.RouteDescriptor, tpID uuid.UUID, lIDs, rIDs []routing.RouteID) (local, remote routing.EdgeRules) {
+	lPK, rPK := desc.SrcPK(), desc.DstPK()
+	lPort, rPort := desc.SrcPort(), desc.DstPort()
+
+	local = routing.EdgeRules{
+		Desc:    desc.Invert(),
+		Forward: routing.ForwardRule(DefaultRouteKeepAlive, lIDs[0], rIDs[0], tpID, lPK, rPK, lPort, rPort),
+		Reverse: routing.ConsumeRule(DefaultRouteKeepAlive, lIDs[1], rPK, lPK, rPort, lPort),
+	}
+
+	remote = routing.EdgeRules{
+		Desc:    desc,
+		Forward: routing.ForwardRule(DefaultRouteKeepAlive, rIDs[1], lIDs[1], tpID, rPK, lPK, rPort, lPort),
+		Reverse: routing.ConsumeRule(DefaultRouteKeepAlive, rIDs[0], lPK, rPK, lPort, rPort),
+	}
+
+	return local, remote
+}
+
+func rollbackDirect(log logrus.FieldLogger, client *rpc.Client, traceID string, ids []routing.RouteID) {
+	ctx, cancel := context.WithTimeout(context.Background(), directRollbackTimeout)
+	defer cancel()
+
+	var ok bool
+
+	if err := callRPC(ctx, client, gatewayName+".DeleteRules", DeleteRulesRequest{TraceID: traceID, IDs: ids}, &ok); err != nil {
+		log.WithError(err).Warnf("Failed to release remote route IDs %v.", ids)
+	}
+}
+
+func callRPC(ctx context.Context, client *rpc.Client, serviceMethod string, args, reply interface{}) error {
+	call := client.Go(serviceMethod, args, reply, nil)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		return call.Error
+	}
+}
@@ -0,0 +1,202 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
+)
+
+func Test_router_DialRoutes_direct(t *testing.T) {
+	keys := snettest.GenKeyPairs(3)
+	pk1, pk2 := keys[0].PK, keys[1].PK
+
+	nEnv := snettest.NewEnv(t, keys, []string{dmsg.Type})
+	defer nEnv.Teardown()
+
+	rEnv := NewTestEnv(t, nEnv.Nets)
+	defer rEnv.Teardown()
+
+	r0Ifc, err := New(nEnv.Nets[0], rEnv.GenRouterConfig(0))
+	require.NoError(t, err)
+
+	r1Ifc, err := New(nEnv.Nets[1], rEnv.GenRouterConfig(1))
+	require.NoError(t, err)
+
+	r0, r1 := r0Ifc.(*router), r1Ifc.(*router)
+
+	r1.wg.Add(1)
+
+	go func() {
+		defer r1.wg.Done()
+		r1.serveSetup()
+	}()
+
+	defer func() {
+		require.NoError(t, r0.Close())
+		require.NoError(t, r1.Close())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("no transport", func(t *testing.T) {
+		_, err := r0.DialRoutes(ctx, pk2, 1, 2, &DialOptions{MaxHops: 0})
+		require.Equal(t, ErrNoDirectTransport, err)
+	})
+
+	tp, err := rEnv.TpMngrs[0].SaveTransport(ctx, pk2, dmsg.Type)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return r0.directTransport(pk2, nil) != nil && r1.directTransport(pk1, nil) != nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	rg0, err := r0.DialRoutes(ctx, pk2, 1, 2, &DialOptions{MaxHops: 0})
+	require.NoError(t, err)
+
+	rg1, err := r1.AcceptRoutes(ctx)
+	require.NoError(t, err)
+
+	desc := routing.NewRouteDescriptor(pk1, pk2, 1, 2)
+	require.Equal(t, desc.Invert(), rg0.desc)
+	require.Equal(t, desc, rg1.desc)
+
+	// Each forward rule leads to the consume rule of the other visor over the transport.
+	require.Equal(t, tp.Entry.ID, rg0.fwd[0].NextTransportID())
+	require.Equal(t, tp.Entry.ID, rg1.fwd[0].NextTransportID())
+	require.Equal(t, rg1.rvs[0].KeyRouteID(), rg0.fwd[0].NextRouteID())
+	require.Equal(t, rg0.rvs[0].KeyRouteID(), rg1.fwd[0].NextRouteID())
+
+	require.Len(t, r0.Rules(), 2)
+	require.Len(t, r1.Rules(), 2)
+}
+
+func TestPeerGateway(t *testing.T) {
+	keys := snettest.GenKeyPairs(2)
+	pk1, pk2 := keys[0].PK, keys[1].PK
+
+	nEnv := snettest.NewEnv(t, keys, []string{dmsg.Type})
+	defer nEnv.Teardown()
+
+	rEnv := NewTestEnv(t, nEnv.Nets)
+	defer rEnv.Teardown()
+
+	rIfc, err := New(nEnv.Nets[1], rEnv.GenRouterConfig(1))
+	require.NoError(t, err)
+
+	r := rIfc.(*router)
+
+	defer func() {
+		require.NoError(t, r.Close())
+	}()
+
+	tp, err := rEnv.TpMngrs[0].SaveTransport(context.TODO(), pk2, dmsg.Type)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return r.directTransport(pk1, nil) != nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	desc := routing.NewRouteDescriptor(pk1, pk2, 1, 2)
+
+	reserve := func(t *testing.T, gw *peerGateway) []routing.RouteID {
+		var ids []routing.RouteID
+		require.NoError(t, gw.ReserveIDs(ReserveIDsRequest{N: 2}, &ids))
+		require.Len(t, ids, 2)
+
+		return ids
+	}
+
+	edgeRules := func(ids []routing.RouteID, tpID uuid.UUID) routing.EdgeRules {
+		_, remote := directRules(desc, tpID, []routing.RouteID{10, 11}, ids)
+		return remote
+	}
+
+	t.Run("reserve limit", func(t *testing.T) {
+		gw := newPeerGateway(r, pk1)
+		defer gw.close()
+
+		reserve(t, gw)
+		reserve(t, gw)
+
+		var ids []routing.RouteID
+		err := gw.ReserveIDs(ReserveIDsRequest{N: 1}, &ids)
+		require.Equal(t, routing.FailureReserveRtIDs, err.(routing.Failure).Code)
+	})
+
+	tests := []struct {
+		name  string
+		peer  cipher.PubKey
+		rules func(ids []routing.RouteID) routing.EdgeRules
+	}{
+		{
+			name:  "other peer",
+			peer:  pk2,
+			rules: func(ids []routing.RouteID) routing.EdgeRules { return edgeRules(ids, tp.Entry.ID) },
+		},
+		{
+			name: "unreserved route IDs",
+			peer: pk1,
+			rules: func(ids []routing.RouteID) routing.EdgeRules {
+				return edgeRules([]routing.RouteID{100, 101}, tp.Entry.ID)
+			},
+		},
+		{
+			name:  "unknown transport",
+			peer:  pk1,
+			rules: func(ids []routing.RouteID) routing.EdgeRules { return edgeRules(ids, uuid.New()) },
+		},
+		{
+			name: "malformed rule",
+			peer: pk1,
+			rules: func(ids []routing.RouteID) routing.EdgeRules {
+				rules := edgeRules(ids, tp.Entry.ID)
+				rules.Forward = rules.Forward[:routing.RuleHeaderSize]
+
+				return rules
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gw := newPeerGateway(r, tc.peer)
+			ids := reserve(t, gw)
+
+			var ok bool
+			err := gw.AddEdgeRules(AddEdgeRulesRequest{Rules: tc.rules(ids)}, &ok)
+			require.Equal(t, routing.FailureAddRules, err.(routing.Failure).Code)
+			require.False(t, ok)
+
+			// Route IDs are released when the connection closes.
+			gw.close()
+			require.Empty(t, gw.reserved)
+		})
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		gw := newPeerGateway(r, pk1)
+		ids := reserve(t, gw)
+
+		var ok bool
+		require.NoError(t, gw.AddEdgeRules(AddEdgeRulesRequest{Rules: edgeRules(ids, tp.Entry.ID)}, &ok))
+		require.True(t, ok)
+		require.Empty(t, gw.reserved)
+
+		rg, err := r.AcceptRoutes(context.TODO())
+		require.NoError(t, err)
+		require.Equal(t, desc, rg.desc)
+
+		// Route IDs which are in use may not be deleted by peer.
+		require.NoError(t, gw.DeleteRules(DeleteRulesRequest{IDs: ids}, &ok))
+		require.Len(t, r.Rules(), 2)
+	})
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/sirupsen/logrus"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// peerReserveLimit is the maximum number of route IDs a peer may have reserved over a single connection.
+const peerReserveLimit = 4
+
+// ErrPeerReserveLimit is returned when a peer requests more route IDs than it is allowed to reserve.
+var ErrPeerReserveLimit = errors.New("too many route IDs requested")
+
+// peerGateway is a RPC interface for router, served to visors which set up routes directly
+// over a transport to this visor, without a setup node.
+// Unlike RPCGateway, a peer may only add edge rules of route groups between itself and this visor,
+// using the route IDs it reserved over the same connection.
+type peerGateway struct {
+	log    logrus.FieldLogger
+	router *router
+	peer   cipher.PubKey
+
+	reserved map[routing.RouteID]struct{} // reserved IDs which have no rules yet
+	mx       sync.Mutex
+}
+
+// newPeerGateway creates a new peerGateway for a connection from 'peer'.
+func newPeerGateway(r *router, peer cipher.PubKey) *peerGateway {
+	return &peerGateway{
+		log:      r.logger.WithField("peer", peer),
+		router:   r,
+		peer:     peer,
+		reserved: make(map[routing.RouteID]struct{}),
+	}
+}
+
+// ReserveIDs reserves route IDs.
+func (g *peerGateway) ReserveIDs(req ReserveIDsRequest, routeIDs *[]routing.RouteID) error {
+	log := g.log.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to reserve %d route IDs.", req.N)
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if len(g.reserved)+int(req.N) > peerReserveLimit {
+		return routing.Failure{Code: routing.FailureReserveRtIDs, Msg: ErrPeerReserveLimit.Error()}
+	}
+
+	ids, err := g.router.ReserveKeys(int(req.N))
+	if err != nil {
+		log.WithError(err).Warnf("Request completed with error.")
+		return routing.Failure{Code: routing.FailureReserveRtIDs, Msg: err.Error()}
+	}
+
+	for _, id := range ids {
+		g.reserved[id] = struct{}{}
+	}
+
+	*routeIDs = ids
+
+	return nil
+}
+
+// AddEdgeRules adds edge rules.
+func (g *peerGateway) AddEdgeRules(req AddEdgeRulesRequest, ok *bool) error {
+	log := g.log.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to add edge rules: %s", req.Rules)
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if err := g.checkEdgeRules(req.Rules); err != nil {
+		log.WithError(err).Warnf("Rejected edge rules.")
+		return routing.Failure{Code: routing.FailureAddRules, Msg: err.Error()}
+	}
+
+	if err := g.router.IntroduceRules(req.Rules); err != nil {
+		log.WithError(err).Warnf("Request completed with error.")
+		return routing.Failure{Code: routing.FailureAddRules, Msg: err.Error()}
+	}
+
+	delete(g.reserved, req.Rules.Forward.KeyRouteID())
+	delete(g.reserved, req.Rules.Reverse.KeyRouteID())
+
+	*ok = true
+
+	return nil
+}
+
+// DeleteRules releases route IDs which were reserved over the same connection, and have no rules yet.
+// Other route IDs are ignored.
+func (g *peerGateway) DeleteRules(req DeleteRulesRequest, ok *bool) error {
+	log := g.log.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to release route IDs %v.", req.IDs)
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	ids := make([]routing.RouteID, 0, len(req.IDs))
+
+	for _, id := range req.IDs {
+		if _, ok := g.reserved[id]; ok {
+			ids = append(ids, id)
+			delete(g.reserved, id)
+		}
+	}
+
+	if len(ids) > 0 {
+		g.router.DelRules(ids)
+	}
+
+	*ok = true
+
+	return nil
+}
+
+// close releases the route IDs which were reserved, but have no rules.
+func (g *peerGateway) close() {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if len(g.reserved) == 0 {
+		return
+	}
+
+	ids := make([]routing.RouteID, 0, len(g.reserved))
+	for id := range g.reserved {
+		ids = append(ids, id)
+	}
+
+	g.router.DelRules(ids)
+	g.reserved = make(map[routing.RouteID]struct{})
+}
+
+// checkEdgeRules checks that 'rules' are the edge rules of this visor for a route group initiated by the peer,
+// with routes over a transport to the peer, using route IDs reserved by the peer.
+func (g *peerGateway) checkEdgeRules(rules routing.EdgeRules) error {
+	if rules.Desc.SrcPK() != g.peer || rules.Desc.DstPK() != g.router.conf.PubKey {
+		return errors.New("route group is not between peer and visor")
+	}
+
+	fwd, cnsm := rules.Forward, rules.Reverse
+
+	if err := fwd.Validate(); err != nil {
+		return fmt.Errorf("forward rule: %v", err)
+	}
+
+	if err := cnsm.Validate(); err != nil {
+		return fmt.Errorf("consume rule: %v", err)
+	}
+
+	if fwd.Type() != routing.RuleForward || cnsm.Type() != routing.RuleConsume {
+		return errors.New("unexpected rule types")
+	}
+
+	if fwd.RouteDescriptor() != rules.Desc.Invert() || cnsm.RouteDescriptor() != rules.Desc {
+		return errors.New("route descriptors of rules do not match route group")
+	}
+
+	for _, id := range []routing.RouteID{fwd.KeyRouteID(), cnsm.KeyRouteID()} {
+		if _, ok := g.reserved[id]; !ok {
+			return fmt.Errorf("route ID %d was not reserved", id)
+		}
+	}
+
+	if fwd.KeyRouteID() == cnsm.KeyRouteID() {
+		return errors.New("rules share route ID")
+	}
+
+	if tp := g.router.tm.Transport(fwd.NextTransportID()); tp == nil || tp.Remote() != g.peer {
+		return fmt.Errorf("transport %s does not lead to peer", fwd.NextTransportID())
+	}
+
+	return nil
+}
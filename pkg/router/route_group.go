@@ -99,6 +99,10 @@ type RouteGroup struct {
 	// used to wait for all the `Close` packets to run through the loop and come back
 	closeDone sync.WaitGroup
 	once      sync.Once
+
+	// deleteRemote, if set, asks setup nodes to remove the rules of the route group from remote visors.
+	// It is called if close packets do not come back, as they may not have reached all visors of the routes.
+	deleteRemote func(desc routing.RouteDescriptor)
 }
 
 // NewRouteGroup creates a new RouteGroup.
@@ -245,23 +249,53 @@ func (rg *RouteGroup) read(p []byte) (int, error) {
 	}
 	rg.mu.Unlock()
 
+	data, err := rg.nextPayload()
+	if err != nil {
+		return 0, err
+	}
+
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	return ioutil.BufRead(&rg.readBuf, data, p)
+}
+
+// ReadPacket reads payload of the next data packet of a RouteGroup into 'p'.
+// Unlike Read, payloads are never merged or split: if 'p' is shorter than the payload, the rest of it is discarded.
+// Reads of a RouteGroup should not mix Read and ReadPacket.
+func (rg *RouteGroup) ReadPacket(p []byte) (int, error) {
+	if rg.isClosed() {
+		return 0, io.ErrClosedPipe
+	}
+
+	if rg.readDeadline.Closed() {
+		return 0, timeoutError{}
+	}
+
+	data, err := rg.nextPayload()
+	if err != nil {
+		return 0, err
+	}
+
+	return copy(p, data), nil
+}
+
+// nextPayload blocks until a data packet is received, and returns its payload.
+func (rg *RouteGroup) nextPayload() ([]byte, error) {
 	select {
 	case <-rg.readDeadline.Wait():
-		return 0, timeoutError{}
+		return nil, timeoutError{}
 	case <-rg.closed:
-		return 0, io.ErrClosedPipe
+		return nil, io.ErrClosedPipe
 	case data, ok := <-rg.readCh:
 		if !ok || len(data) == 0 {
 			// route group got closed or empty data received. Behavior on the empty
 			// data is equivalent to the behavior of `read()` unix syscall as described here:
 			// https://www.ibm.com/support/knowledgecenter/en/SSLTBW_2.4.0/com.ibm.zos.v2r4.bpxbd00/rtrea.htm
-			return 0, io.EOF
+			return nil, io.EOF
 		}
 
-		rg.mu.Lock()
-		defer rg.mu.Unlock()
-
-		return ioutil.BufRead(&rg.readBuf, data, p)
+		return data, nil
 	}
 }
 
@@ -429,13 +463,21 @@ func (rg *RouteGroup) close(code routing.CloseCode) error {
 		// the network
 		if err := rg.waitForCloseRouteGroup(closeRoutineTimeout); err != nil {
 			rg.logger.Errorf("Error during close route group: %v", err)
+
+			if rg.deleteRemote != nil {
+				go rg.deleteRemote(rg.desc)
+			}
 		}
 	}
 
-	rules := make([]routing.RouteID, 0, len(rg.fwd))
+	// Reverse rules are deleted too, as close packets of the remote may never arrive.
+	rules := make([]routing.RouteID, 0, len(rg.fwd)+len(rg.rvs))
 	for _, r := range rg.fwd {
 		rules = append(rules, r.KeyRouteID())
 	}
+	for _, r := range rg.rvs {
+		rules = append(rules, r.KeyRouteID())
+	}
 
 	rg.rt.DelRules(rules)
 
@@ -64,6 +64,38 @@ func TestRouteGroup_Close(t *testing.T) {
 	teardown()
 }
 
+func TestRouteGroup_CloseDeletesRules(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+
+	ids, err := rg.rt.ReserveKeys(1)
+	require.NoError(t, err)
+
+	cnsmRule := routing.ConsumeRule(ruleKeepAlive, ids[0], rg.desc.DstPK(), rg.desc.SrcPK(), 0, 0)
+	require.NoError(t, rg.rt.SaveRule(cnsmRule))
+	rg.rvs = append(rg.rvs, cnsmRule)
+
+	require.NoError(t, rg.Close())
+	require.Equal(t, 0, rg.rt.Count())
+}
+
+func TestRouteGroup_CloseTimeoutDeletesRemoteRules(t *testing.T) {
+	rg1, _, _, _, teardown := setupEnv(t)
+	defer teardown()
+
+	deleted := make(chan routing.RouteDescriptor, 1)
+	rg1.deleteRemote = func(desc routing.RouteDescriptor) { deleted <- desc }
+
+	// close packets are not pushed back to the route group, so closing times out
+	require.NoError(t, rg1.Close())
+
+	select {
+	case desc := <-deleted:
+		require.Equal(t, rg1.desc, desc)
+	case <-time.After(time.Second):
+		t.Fatal("rules of remote visors were not deleted")
+	}
+}
+
 func TestRouteGroup_Read(t *testing.T) {
 	rg1, rg2, m1, m2, teardown := setupEnv(t)
 
@@ -116,6 +148,42 @@ func TestRouteGroup_Read(t *testing.T) {
 	teardown()
 }
 
+func TestRouteGroup_ReadPacket(t *testing.T) {
+	rg1, rg2, m1, m2, teardown := setupEnv(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go pushPackets(ctx, m2, rg2)
+	go pushPackets(ctx, m1, rg1)
+
+	msg1 := []byte("hello1")
+	msg2 := []byte("hello2")
+
+	rg1.readCh <- msg1
+	rg1.readCh <- msg2
+
+	// Payloads are not merged.
+	buf := make([]byte, 2*len(msg1))
+	n, err := rg1.ReadPacket(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg1, buf[:n])
+
+	// Rest of a payload is discarded on short reads.
+	buf = make([]byte, len(msg2)/2)
+	n, err = rg1.ReadPacket(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg2[:len(msg2)/2], buf[:n])
+
+	require.NoError(t, rg1.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, err = rg1.ReadPacket(buf)
+	require.Equal(t, timeoutError{}, err)
+
+	require.NoError(t, rg1.Close())
+	require.NoError(t, rg2.Close())
+	cancel()
+	teardown()
+}
+
 func TestRouteGroup_Write(t *testing.T) {
 	rg1, rg2, m1, m2, teardown := setupEnv(t)
 
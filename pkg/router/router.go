@@ -34,6 +34,12 @@ const (
 
 	minHops = 0
 	maxHops = 50
+
+	findRoutesTimeout = 10 * time.Second // total duration of retrying to find routes
+	findRoutesInitBO  = 100 * time.Millisecond
+	findRoutesMaxBO   = 2 * time.Second
+
+	deleteRoutesTimeout = 30 * time.Second // timeout of asking setup nodes to delete rules of a route group
 )
 
 var (
@@ -42,6 +48,13 @@ var (
 
 	// ErrRemoteEmptyPK occurs when the specified remote public key is empty.
 	ErrRemoteEmptyPK = errors.New("empty remote public key")
+
+	// ErrInvalidDialOptions is returned when dial options are inconsistent.
+	ErrInvalidDialOptions = errors.New("invalid dial options")
+
+	// ErrMultipleRoutes is returned when dial options require more than one route in a direction,
+	// as route groups are set up with a single route in each direction.
+	ErrMultipleRoutes = errors.New("multiple routes per direction are not supported")
 )
 
 // Config configures Router.
@@ -77,6 +90,39 @@ type DialOptions struct {
 	MaxForwardRts int
 	MinConsumeRts int
 	MaxConsumeRts int
+
+	// MinHops is the minimum number of intermediary visors of routes.
+	// A non-zero value prevents routes from being set up directly over a transport to the remote visor.
+	MinHops int
+
+	// MaxHops is the maximum number of intermediary visors of routes.
+	// Zero requires routes to be set up directly over a transport to the remote visor.
+	// Pass DefaultDialOptions, or nil options, to allow the default maximum.
+	MaxHops int
+
+	// TransportTypes are types of transports preferred for routes, most preferred first.
+	// Routes over other transports are still used if there are no routes over preferred ones.
+	TransportTypes []string
+}
+
+// Validate checks that options are consistent.
+func (o *DialOptions) Validate() error {
+	switch {
+	case o.MinForwardRts < 0 || o.MaxForwardRts < 0 || o.MinConsumeRts < 0 || o.MaxConsumeRts < 0:
+		return fmt.Errorf("%w: number of routes should not be negative", ErrInvalidDialOptions)
+	case o.MinForwardRts > o.MaxForwardRts || o.MinConsumeRts > o.MaxConsumeRts:
+		return fmt.Errorf("%w: minimum number of routes exceeds maximum", ErrInvalidDialOptions)
+	case o.MinHops < 0 || o.MaxHops < 0:
+		return fmt.Errorf("%w: number of hops should not be negative", ErrInvalidDialOptions)
+	case o.MinHops > o.MaxHops:
+		return fmt.Errorf("%w: minimum number of hops exceeds maximum", ErrInvalidDialOptions)
+	case o.MaxHops > maxHops:
+		return fmt.Errorf("%w: number of hops should not exceed %d", ErrInvalidDialOptions, maxHops)
+	case o.MinForwardRts > 1 || o.MinConsumeRts > 1:
+		return ErrMultipleRoutes
+	}
+
+	return nil
 }
 
 // DefaultDialOptions returns default dial options.
@@ -87,6 +133,7 @@ func DefaultDialOptions() *DialOptions {
 		MaxForwardRts: 1,
 		MinConsumeRts: 1,
 		MaxConsumeRts: 1,
+		MaxHops:       maxHops,
 	}
 }
 
@@ -100,7 +147,7 @@ type Router interface {
 	// A nil 'opts' input results in a value of '1' for all DialOptions fields.
 	// A single call to DialRoutes should perform the following:
 	// - Find routes via RouteFinder (in one call).
-	// - Setup routes via SetupNode (in one call).
+	// - Setup routes via SetupNode (in one call), or directly over a transport to 'rPK' if there is one.
 	// - Save to routing.Table and internal RouteGroup map.
 	// - Return RouteGroup if successful.
 	DialRoutes(ctx context.Context, rPK cipher.PubKey, lPort, rPort routing.Port, opts *DialOptions) (*RouteGroup, error)
@@ -189,7 +236,7 @@ func New(n *snet.Network, config *Config) (Router, error) {
 // A nil 'opts' input results in a value of '1' for all DialOptions fields.
 // A single call to DialRoutes should perform the following:
 // - Find routes via RouteFinder (in one call).
-// - Setup routes via SetupNode (in one call).
+// - Setup routes via SetupNode (in one call), or directly over a transport to 'rPK' if there is one.
 // - Save to routing.Table and internal RouteGroup map.
 // - Return RouteGroup if successful.
 func (r *router) DialRoutes(
@@ -205,16 +252,70 @@ func (r *router) DialRoutes(
 		return nil, fmt.Errorf("failed to dial routes: %v", err)
 	}
 
+	if opts == nil {
+		opts = DefaultDialOptions()
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
 	lPK := r.conf.PubKey
 	forwardDesc := routing.NewRouteDescriptor(lPK, rPK, lPort, rPort)
 
-	forwardPath, reversePath, err := r.fetchBestRoutes(lPK, rPK, opts)
+	rules, err := r.dialRouteGroup(ctx, forwardDesc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.SaveRoutingRules(rules.Forward, rules.Reverse); err != nil {
+		r.logger.WithError(err).Error("Error saving routing rules")
+		return nil, err
+	}
+
+	rg := r.saveRouteGroupRules(rules)
+
+	r.logger.Infof("Created new routes to %s on port %d", rPK, lPort)
+
+	return rg, nil
+}
+
+// dialRouteGroup sets up routes of a route group described by 'desc', and returns the local rules.
+// If there is a transport to the remote visor, routes are set up directly over it, without a setup node.
+// Otherwise, or if that fails, routes are found by route finder and set up by a setup node,
+// unless 'opts' requires direct routes.
+func (r *router) dialRouteGroup(ctx context.Context, desc routing.RouteDescriptor, opts *DialOptions) (routing.EdgeRules, error) {
+	rPK := desc.DstPK()
+
+	if opts.MinHops > 0 {
+		return r.dialRouteGroupViaSetup(ctx, desc, opts)
+	}
+
+	if tp := r.directTransport(rPK, opts.TransportTypes); tp != nil {
+		rules, err := r.setupDirect(ctx, desc, tp.Entry.ID)
+		if err == nil || opts.MaxHops == 0 {
+			return rules, err
+		}
+
+		r.logger.WithError(err).Warnf("Failed to set up routes directly to %s, using setup node", rPK)
+	} else if opts.MaxHops == 0 {
+		return routing.EdgeRules{}, ErrNoDirectTransport
+	}
+
+	return r.dialRouteGroupViaSetup(ctx, desc, opts)
+}
+
+// dialRouteGroupViaSetup sets up routes of a route group described by 'desc' using route finder and a setup node.
+func (r *router) dialRouteGroupViaSetup(ctx context.Context, desc routing.RouteDescriptor, opts *DialOptions) (routing.EdgeRules, error) {
+	rPK := desc.DstPK()
+
+	forwardPath, reversePath, err := r.fetchBestRoutes(ctx, desc.SrcPK(), rPK, opts)
 	if err != nil {
-		return nil, fmt.Errorf("route finder: %s", err)
+		return routing.EdgeRules{}, fmt.Errorf("route finder: %s", err)
 	}
 
 	req := routing.BidirectionalRoute{
-		Desc:      forwardDesc,
+		Desc:      desc,
 		KeepAlive: DefaultRouteKeepAlive,
 		Forward:   forwardPath,
 		Reverse:   reversePath,
@@ -223,19 +324,26 @@ func (r *router) DialRoutes(
 	rules, err := r.conf.RouteGroupDialer.Dial(ctx, r.logger, r.n, r.conf.SetupNodes, req)
 	if err != nil {
 		r.logger.WithError(err).Error("Error dialing route group")
-		return nil, err
-	}
+		r.invalidateRoutes(forwardPath, reversePath)
 
-	if err := r.SaveRoutingRules(rules.Forward, rules.Reverse); err != nil {
-		r.logger.WithError(err).Error("Error saving routing rules")
-		return nil, err
+		return routing.EdgeRules{}, err
 	}
 
-	rg := r.saveRouteGroupRules(rules)
+	return rules, nil
+}
 
-	r.logger.Infof("Created new routes to %s on port %d", rPK, lPort)
+// deleteRouteGroup asks setup nodes to remove the rules of the route group described by 'desc' from the
+// visors of its routes. Failures are only logged, as the rules expire eventually anyway.
+func (r *router) deleteRouteGroup(desc routing.RouteDescriptor) {
+	ctx, cancel := context.WithTimeout(context.Background(), deleteRoutesTimeout)
+	defer cancel()
 
-	return rg, nil
+	if err := r.conf.RouteGroupDialer.Delete(ctx, r.logger, r.n, r.conf.SetupNodes, desc); err != nil {
+		r.logger.WithError(err).Warnf("Failed to delete rules of route group %s from remote visors.", &desc)
+		return
+	}
+
+	r.logger.Infof("Deleted rules of route group %s from remote visors.", &desc)
 }
 
 // AcceptsRoutes should block until we receive an AddRules packet from SetupNode
@@ -330,17 +438,43 @@ func (r *router) serveSetup() {
 			return
 		}
 
-		if !r.SetupIsTrusted(conn.RemotePK()) {
-			r.logger.Warnf("closing conn from untrusted setup node: %v", conn.Close())
+		if r.SetupIsTrusted(conn.RemotePK()) {
+			r.logger.Infof("handling setup request: setupPK(%s)", conn.RemotePK())
+
+			go r.rpcSrv.ServeConn(conn)
+
 			continue
 		}
 
-		r.logger.Infof("handling setup request: setupPK(%s)", conn.RemotePK())
+		// Visors with a transport to this visor may set up routes over it directly.
+		if r.directTransport(conn.RemotePK(), nil) != nil {
+			r.logger.Infof("handling direct setup request: remotePK(%s)", conn.RemotePK())
+
+			go r.servePeer(conn)
+
+			continue
+		}
 
-		go r.rpcSrv.ServeConn(conn)
+		r.logger.Warnf("closing conn from untrusted setup node: %v", conn.Close())
 	}
 }
 
+// servePeer serves direct route setup requests of the remote visor of 'conn'.
+func (r *router) servePeer(conn *snet.Conn) {
+	gw := newPeerGateway(r, conn.RemotePK())
+	defer gw.close()
+
+	rpcS := rpc.NewServer()
+	if err := rpcS.RegisterName(gatewayName, gw); err != nil {
+		r.logger.WithError(err).Error("Failed to register peer RPC gateway.")
+		r.logger.Warnf("closing conn from remote visor: %v", conn.Close())
+
+		return
+	}
+
+	rpcS.ServeConn(conn)
+}
+
 func (r *router) saveRouteGroupRules(rules routing.EdgeRules) *RouteGroup {
 	r.logger.Infof("Saving route group rules with desc: %s", &rules.Desc)
 	r.mx.Lock()
@@ -360,6 +494,7 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules) *RouteGroup {
 	r.logger.Infof("Creating new route group rule with desc: %s", &rules.Desc)
 
 	rg = NewRouteGroup(DefaultRouteGroupConfig(), r.rt, rules.Desc)
+	rg.deleteRemote = r.deleteRouteGroup
 	r.rgs[rules.Desc] = rg
 
 	rg.fwd = append(rg.fwd, rules.Forward)
@@ -621,38 +756,114 @@ func (r *router) RemoveRouteDescriptor(desc routing.RouteDescriptor) {
 	}
 }
 
-func (r *router) fetchBestRoutes(src, dst cipher.PubKey, opts *DialOptions) (fwd, rev routing.Path, err error) {
-	// TODO(nkryuchkov): use opts
+func (r *router) fetchBestRoutes(
+	ctx context.Context,
+	src, dst cipher.PubKey,
+	opts *DialOptions,
+) (fwd, rev routing.Path, err error) {
 	if opts == nil {
-		opts = DefaultDialOptions() // nolint
+		opts = DefaultDialOptions()
 	}
 
 	r.logger.Infof("Requesting new routes from %s to %s", src, dst)
 
-	timer := time.NewTimer(time.Second * 10)
-	defer timer.Stop()
+	ctx, cancel := context.WithTimeout(ctx, findRoutesTimeout)
+	defer cancel()
 
 	forward := [2]cipher.PubKey{src, dst}
 	backward := [2]cipher.PubKey{dst, src}
 
-fetchRoutesAgain:
-	ctx := context.Background()
+	bo := findRoutesInitBO
 
-	paths, err := r.conf.RouteFinder.FindRoutes(ctx, []routing.PathEdges{forward, backward},
-		&rfclient.RouteOptions{MinHops: minHops, MaxHops: maxHops})
+	for {
+		var paths map[routing.PathEdges][]routing.Path
+
+		paths, err = r.conf.RouteFinder.FindRoutes(ctx, []routing.PathEdges{forward, backward}, routeOptions(opts))
+		if err == nil {
+			if len(paths[forward]) == 0 || len(paths[backward]) == 0 {
+				return nil, nil, errors.New("no routes found")
+			}
+
+			r.logger.Infof("Found routes Forward: %s. Reverse %s", paths[forward], paths[backward])
+
+			fwd = r.preferredPath(paths[forward], 0, opts.TransportTypes)
+			rev = r.preferredPath(paths[backward], -1, opts.TransportTypes)
+
+			return fwd, rev, nil
+		}
+
+		r.logger.WithError(err).Warnf("Failed to find routes, retrying in %s", bo)
 
-	if err != nil {
 		select {
-		case <-timer.C:
+		case <-ctx.Done():
 			return nil, nil, err
-		default:
-			goto fetchRoutesAgain
+		case <-time.After(bo):
+		}
+
+		if bo *= 2; bo > findRoutesMaxBO {
+			bo = findRoutesMaxBO
+		}
+	}
+}
+
+// preferredPath returns the first of 'paths' which local transport is of the most preferred of 'tpTypes'.
+// The local transport is the hop at index 'localHop' of a path, negative indexes are counted from the end.
+// Types of other transports of paths are not known locally.
+// routeOptions converts dial options to route finder options.
+// Dial options count intermediary visors, while the route finder counts transports,
+// so a route over n intermediary visors is n+1 route finder hops.
+func routeOptions(opts *DialOptions) *rfclient.RouteOptions {
+	return &rfclient.RouteOptions{
+		MinHops: uint16(opts.MinHops + 1),
+		MaxHops: uint16(opts.MaxHops + 1),
+	}
+}
+
+func (r *router) preferredPath(paths []routing.Path, localHop int, tpTypes []string) routing.Path {
+	best, bestRank := paths[0], len(tpTypes)
+	if bestRank == 0 {
+		return best
+	}
+
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+
+		i := localHop
+		if i < 0 {
+			i += len(path)
+		}
+
+		tp := r.tm.Transport(path[i].TpID)
+		if tp == nil {
+			continue
+		}
+
+		for rank, tpType := range tpTypes[:bestRank] {
+			if tp.Type() == tpType {
+				best, bestRank = path, rank
+				break
+			}
 		}
 	}
 
-	r.logger.Infof("Found routes Forward: %s. Reverse %s", paths[forward], paths[backward])
+	return best
+}
 
-	return paths[forward][0], paths[backward][0], nil
+// invalidateRoutes drops cached routes of 'paths', if route finder client caches routes.
+// It is used once routes fail to be set up, as they may be outdated.
+func (r *router) invalidateRoutes(paths ...routing.Path) {
+	inv, ok := r.conf.RouteFinder.(rfclient.Invalidator)
+	if !ok {
+		return
+	}
+
+	for _, path := range paths {
+		for _, hop := range path {
+			inv.InvalidateTransport(hop.TpID)
+		}
+	}
 }
 
 // SetupIsTrusted checks if setup node is trusted.
@@ -752,12 +963,14 @@ func (r *router) SaveRule(rule routing.Rule) error {
 }
 
 // DelRules removes rules associated with `ids` from the routing table.
+// IDs which are only reserved are released.
 func (r *router) DelRules(ids []routing.RouteID) {
 	rules := make([]routing.Rule, 0, len(ids))
 	for _, id := range ids {
 		rule, err := r.rt.Rule(id)
 		if err != nil {
-			r.logger.WithError(err).Errorf("Failed to get rule with ID %d on rule removal", id)
+			// Reserved IDs have no rule yet.
+			r.logger.WithError(err).Debugf("Failed to get rule with ID %d on rule removal", id)
 			continue
 		}
 
@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -43,6 +44,79 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+func TestDialOptions_Validate(t *testing.T) {
+	require.NoError(t, DefaultDialOptions().Validate())
+
+	tests := []struct {
+		name string
+		opts DialOptions
+		err  error
+	}{
+		{"negative routes", DialOptions{MinForwardRts: -1}, ErrInvalidDialOptions},
+		{"min routes exceed max", DialOptions{MinConsumeRts: 1}, ErrInvalidDialOptions},
+		{"min hops exceed max", DialOptions{MinHops: 2, MaxHops: 1}, ErrInvalidDialOptions},
+		{"too many hops", DialOptions{MaxHops: maxHops + 1}, ErrInvalidDialOptions},
+		{"direct with hops", DialOptions{MinHops: 1}, ErrInvalidDialOptions},
+		{"multiple routes", DialOptions{MinForwardRts: 2, MaxForwardRts: 2}, ErrMultipleRoutes},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, errors.Is(tc.opts.Validate(), tc.err))
+		})
+	}
+}
+
+// recordingRouteFinder records route options it is queried with.
+type recordingRouteFinder struct {
+	rfclient.Client
+	opts []rfclient.RouteOptions
+}
+
+func (rf *recordingRouteFinder) FindRoutes(ctx context.Context, rts []routing.PathEdges, opts *rfclient.RouteOptions) (map[routing.PathEdges][]routing.Path, error) {
+	rf.opts = append(rf.opts, *opts)
+
+	paths := make(map[routing.PathEdges][]routing.Path, len(rts))
+	for _, edges := range rts {
+		paths[edges] = []routing.Path{{{From: edges[0], To: edges[1]}}}
+	}
+
+	return paths, nil
+}
+
+func Test_router_fetchBestRoutes(t *testing.T) {
+	src, _ := cipher.GenerateKeyPair()
+	dst, _ := cipher.GenerateKeyPair()
+
+	tests := []struct {
+		name string
+		opts *DialOptions
+		want rfclient.RouteOptions
+	}{
+		{"nil options", nil, rfclient.RouteOptions{MinHops: 1, MaxHops: maxHops + 1}},
+		{"default options", DefaultDialOptions(), rfclient.RouteOptions{MinHops: 1, MaxHops: maxHops + 1}},
+		{"one intermediary visor", &DialOptions{MinHops: 1, MaxHops: 1}, rfclient.RouteOptions{MinHops: 2, MaxHops: 2}},
+		{"up to three intermediary visors", &DialOptions{MaxHops: 3}, rfclient.RouteOptions{MinHops: 1, MaxHops: 4}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rf := &recordingRouteFinder{Client: rfclient.NewMock()}
+			r := &router{
+				conf:   &Config{RouteFinder: rf},
+				logger: logging.MustGetLogger("router"),
+			}
+
+			fwd, rev, err := r.fetchBestRoutes(context.TODO(), src, dst, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, src, fwd[0].From)
+			assert.Equal(t, dst, rev[0].From)
+
+			assert.Equal(t, []rfclient.RouteOptions{tc.want}, rf.opts)
+		})
+	}
+}
+
 func Test_router_DialRoutes(t *testing.T) {
 	// We are generating two key pairs - one for the a `Router`, the other to send packets to `Router`.
 	keys := snettest.GenKeyPairs(3)
@@ -3,9 +3,12 @@ package routerclient
 import (
 	"context"
 	"net/rpc"
+	"strings"
+	"sync/atomic"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 )
@@ -14,7 +17,8 @@ const rpcName = "RPCGateway"
 
 // Client is an RPC client for router.
 type Client struct {
-	rpc *rpc.Client
+	rpc    *rpc.Client
+	legacy int32 // set once the remote router turns out to lack the Traced methods
 }
 
 // NewClient creates a new Client.
@@ -45,29 +49,71 @@ func (c *Client) Close() error {
 }
 
 // AddEdgeRules adds forward and consume rules to router (forward and reverse).
-func (c *Client) AddEdgeRules(ctx context.Context, rules routing.EdgeRules) (bool, error) {
+func (c *Client) AddEdgeRules(ctx context.Context, traceID string, rules routing.EdgeRules) (bool, error) {
 	var ok bool
-	err := c.call(ctx, rpcName+".AddEdgeRules", rules, &ok)
+	req := router.AddEdgeRulesRequest{TraceID: traceID, Rules: rules}
+	err := c.callTraced(ctx, "AddEdgeRules", req, rules, &ok)
 
 	return ok, err
 }
 
 // AddIntermediaryRules adds intermediary rules to router.
-func (c *Client) AddIntermediaryRules(ctx context.Context, rules []routing.Rule) (bool, error) {
+func (c *Client) AddIntermediaryRules(ctx context.Context, traceID string, rules []routing.Rule) (bool, error) {
 	var ok bool
-	err := c.call(ctx, rpcName+".AddIntermediaryRules", rules, &ok)
+	req := router.AddIntermediaryRulesRequest{TraceID: traceID, Rules: rules}
+	err := c.callTraced(ctx, "AddIntermediaryRules", req, rules, &ok)
 
 	return ok, err
 }
 
 // ReserveIDs reserves n IDs and returns them.
-func (c *Client) ReserveIDs(ctx context.Context, n uint8) ([]routing.RouteID, error) {
+func (c *Client) ReserveIDs(ctx context.Context, traceID string, n uint8) ([]routing.RouteID, error) {
 	var routeIDs []routing.RouteID
-	err := c.call(ctx, rpcName+".ReserveIDs", n, &routeIDs)
+	req := router.ReserveIDsRequest{TraceID: traceID, N: n}
+	err := c.callTraced(ctx, "ReserveIDs", req, n, &routeIDs)
 
 	return routeIDs, err
 }
 
+// DeleteRules deletes the rules of the given route IDs from router and releases the IDs.
+func (c *Client) DeleteRules(ctx context.Context, traceID string, ids []routing.RouteID) (bool, error) {
+	var ok bool
+	req := router.DeleteRulesRequest{TraceID: traceID, IDs: ids}
+	err := c.call(ctx, rpcName+".DeleteRules", req, &ok)
+
+	return ok, err
+}
+
+// DeleteStoredRules deletes the given rules from router if they are still stored as is.
+func (c *Client) DeleteStoredRules(ctx context.Context, traceID string, rules []routing.Rule) (bool, error) {
+	var ok bool
+	req := router.DeleteRulesRequest{TraceID: traceID, Rules: rules}
+	err := c.call(ctx, rpcName+".DeleteRules", req, &ok)
+
+	return ok, err
+}
+
+// callTraced calls the Traced variant of 'method' with 'req'. Routers of older versions lack it,
+// so it falls back to 'method' called with 'legacyArgs', which are the same as 'req' without the trace ID.
+func (c *Client) callTraced(ctx context.Context, method string, req, legacyArgs, reply interface{}) error {
+	if atomic.LoadInt32(&c.legacy) == 0 {
+		err := c.call(ctx, rpcName+"."+method+"Traced", req, reply)
+		if !isMethodNotFound(err) {
+			return err
+		}
+
+		atomic.StoreInt32(&c.legacy, 1)
+	}
+
+	return c.call(ctx, rpcName+"."+method, legacyArgs, reply)
+}
+
+// isMethodNotFound tells whether 'err' is returned by a RPC server which does not serve the called method.
+func isMethodNotFound(err error) bool {
+	_, ok := err.(rpc.ServerError)
+	return ok && strings.HasPrefix(err.Error(), "rpc: can't find method")
+}
+
 func (c *Client) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
 	call := c.rpc.Go(serviceMethod, args, reply, nil)
 
@@ -50,7 +50,7 @@ func TestClient_AddEdgeRules(t *testing.T) {
 	_, cl, cleanup := prepRPCServerAndClient(t, r)
 	defer cleanup()
 
-	ok, err := cl.AddEdgeRules(context.Background(), rules)
+	ok, err := cl.AddEdgeRules(context.Background(), "", rules)
 	require.NoError(t, err)
 	require.True(t, ok)
 }
@@ -67,7 +67,7 @@ func TestClient_AddIntermediaryRules(t *testing.T) {
 	_, cl, cleanup := prepRPCServerAndClient(t, r)
 	defer cleanup()
 
-	ok, err := cl.AddIntermediaryRules(context.Background(), rules)
+	ok, err := cl.AddIntermediaryRules(context.Background(), "", rules)
 	require.NoError(t, err)
 	require.True(t, ok)
 }
@@ -82,11 +82,62 @@ func TestClient_ReserveIDs(t *testing.T) {
 	_, cl, cleanup := prepRPCServerAndClient(t, r)
 	defer cleanup()
 
-	gotIDs, err := cl.ReserveIDs(context.Background(), n)
+	gotIDs, err := cl.ReserveIDs(context.Background(), "", n)
 	require.NoError(t, err)
 	require.Equal(t, ids, gotIDs)
 }
 
+// legacyGateway is RPCGateway of routers which do not support trace IDs.
+type legacyGateway struct {
+	ids []routing.RouteID
+}
+
+func (g *legacyGateway) ReserveIDs(n uint8, routeIDs *[]routing.RouteID) error {
+	*routeIDs = g.ids[:n]
+	return nil
+}
+
+func TestClient_ReserveIDs_legacy(t *testing.T) {
+	ids := []routing.RouteID{1, 2, 3}
+
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, l.Close()) }()
+
+	s := rpc.NewServer()
+	require.NoError(t, s.RegisterName(rpcName, &legacyGateway{ids: ids}))
+
+	go s.Accept(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	cl := &Client{rpc: rpc.NewClient(conn)}
+	defer func() { require.NoError(t, cl.Close()) }()
+
+	for i := 0; i < 2; i++ {
+		gotIDs, err := cl.ReserveIDs(context.Background(), "trace", 2)
+		require.NoError(t, err)
+		require.Equal(t, ids[:2], gotIDs)
+	}
+}
+
+func TestClient_DeleteRules(t *testing.T) {
+	ids := []routing.RouteID{1, 2, 3}
+
+	r := &router.MockRouter{}
+	r.On("DelRules", ids).Return()
+
+	_, cl, cleanup := prepRPCServerAndClient(t, r)
+	defer cleanup()
+
+	ok, err := cl.DeleteRules(context.Background(), "", ids)
+	require.NoError(t, err)
+	require.True(t, ok)
+	r.AssertExpectations(t)
+}
+
 // nolint:unparam
 func prepRPCServerAndClient(t *testing.T, r router.Router) (s *rpc.Server, cl *Client, cleanup func()) {
 	l, err := nettest.NewLocalListener("tcp")
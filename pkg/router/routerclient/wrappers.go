@@ -45,6 +45,7 @@ func AddEdgeRules(
 	log *logging.Logger,
 	dmsgC *dmsg.Client,
 	pk cipher.PubKey,
+	traceID string,
 	rules routing.EdgeRules,
 ) (bool, error) {
 	client, err := NewClient(ctx, wrapDmsgC(dmsgC), pk)
@@ -54,7 +55,7 @@ func AddEdgeRules(
 
 	defer closeClient(log, client)
 
-	ok, err := client.AddEdgeRules(ctx, rules)
+	ok, err := client.AddEdgeRules(ctx, traceID, rules)
 	if err != nil {
 		return false, fmt.Errorf("failed to add rules: %v", err)
 	}
@@ -68,6 +69,7 @@ func AddIntermediaryRules(
 	log *logging.Logger,
 	dmsgC *dmsg.Client,
 	pk cipher.PubKey,
+	traceID string,
 	rules []routing.Rule,
 ) (bool, error) {
 	client, err := NewClient(ctx, wrapDmsgC(dmsgC), pk)
@@ -77,7 +79,7 @@ func AddIntermediaryRules(
 
 	defer closeClient(log, client)
 
-	routeIDs, err := client.AddIntermediaryRules(ctx, rules)
+	routeIDs, err := client.AddIntermediaryRules(ctx, traceID, rules)
 	if err != nil {
 		return false, fmt.Errorf("failed to add rules: %v", err)
 	}
@@ -91,6 +93,7 @@ func ReserveIDs(
 	log *logging.Logger,
 	dmsgC *dmsg.Client,
 	pk cipher.PubKey,
+	traceID string,
 	n uint8,
 ) ([]routing.RouteID, error) {
 	client, err := NewClient(ctx, wrapDmsgC(dmsgC), pk)
@@ -100,14 +103,62 @@ func ReserveIDs(
 
 	defer closeClient(log, client)
 
-	routeIDs, err := client.ReserveIDs(ctx, n)
+	routeIDs, err := client.ReserveIDs(ctx, traceID, n)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add rules: %v", err)
+		return nil, fmt.Errorf("failed to reserve route IDs: %v", err)
 	}
 
 	return routeIDs, nil
 }
 
+// DeleteRules is a wrapper for (*Client).DeleteRules.
+func DeleteRules(
+	ctx context.Context,
+	log *logging.Logger,
+	dmsgC *dmsg.Client,
+	pk cipher.PubKey,
+	traceID string,
+	ids []routing.RouteID,
+) (bool, error) {
+	client, err := NewClient(ctx, wrapDmsgC(dmsgC), pk)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial remote: %v", err)
+	}
+
+	defer closeClient(log, client)
+
+	ok, err := client.DeleteRules(ctx, traceID, ids)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete rules: %v", err)
+	}
+
+	return ok, nil
+}
+
+// DeleteStoredRules is a wrapper for (*Client).DeleteStoredRules.
+func DeleteStoredRules(
+	ctx context.Context,
+	log *logging.Logger,
+	dmsgC *dmsg.Client,
+	pk cipher.PubKey,
+	traceID string,
+	rules []routing.Rule,
+) (bool, error) {
+	client, err := NewClient(ctx, wrapDmsgC(dmsgC), pk)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial remote: %v", err)
+	}
+
+	defer closeClient(log, client)
+
+	ok, err := client.DeleteStoredRules(ctx, traceID, rules)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete rules: %v", err)
+	}
+
+	return ok, nil
+}
+
 func closeClient(log *logging.Logger, client *Client) {
 	if err := client.Close(); err != nil {
 		log.Warn(err)
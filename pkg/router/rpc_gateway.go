@@ -1,12 +1,41 @@
 package router
 
 import (
+	"bytes"
+
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
+// AddEdgeRulesRequest is the argument of RPCGateway.AddEdgeRulesTraced.
+type AddEdgeRulesRequest struct {
+	TraceID string
+	Rules   routing.EdgeRules
+}
+
+// AddIntermediaryRulesRequest is the argument of RPCGateway.AddIntermediaryRulesTraced.
+type AddIntermediaryRulesRequest struct {
+	TraceID string
+	Rules   []routing.Rule
+}
+
+// ReserveIDsRequest is the argument of RPCGateway.ReserveIDsTraced.
+type ReserveIDsRequest struct {
+	TraceID string
+	N       uint8
+}
+
+// DeleteRulesRequest is the argument of RPCGateway.DeleteRules.
+type DeleteRulesRequest struct {
+	TraceID string
+	IDs     []routing.RouteID // rules and reservations to delete
+	Rules   []routing.Rule    // rules to delete only if they are still stored as is
+}
+
 // RPCGateway is a RPC interface for router.
+// Requests of the Traced methods carry the trace ID of the route setup they belong to, which is logged.
+// The methods without trace IDs are kept for setup nodes of older versions.
 type RPCGateway struct {
 	logger *logging.Logger
 	router Router
@@ -22,10 +51,18 @@ func NewRPCGateway(router Router) *RPCGateway {
 
 // AddEdgeRules adds edge rules.
 func (r *RPCGateway) AddEdgeRules(rules routing.EdgeRules, ok *bool) error {
-	if err := r.router.IntroduceRules(rules); err != nil {
+	return r.AddEdgeRulesTraced(AddEdgeRulesRequest{Rules: rules}, ok)
+}
+
+// AddEdgeRulesTraced adds edge rules.
+func (r *RPCGateway) AddEdgeRulesTraced(req AddEdgeRulesRequest, ok *bool) error {
+	log := r.logger.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to add edge rules: %s", req.Rules)
+
+	if err := r.router.IntroduceRules(req.Rules); err != nil {
 		*ok = false
 
-		r.logger.WithError(err).Warnf("Request completed with error.")
+		log.WithError(err).Warnf("Request completed with error.")
 
 		return routing.Failure{Code: routing.FailureAddRules, Msg: err.Error()}
 	}
@@ -37,10 +74,18 @@ func (r *RPCGateway) AddEdgeRules(rules routing.EdgeRules, ok *bool) error {
 
 // AddIntermediaryRules adds intermediary rules.
 func (r *RPCGateway) AddIntermediaryRules(rules []routing.Rule, ok *bool) error {
-	if err := r.router.SaveRoutingRules(rules...); err != nil {
+	return r.AddIntermediaryRulesTraced(AddIntermediaryRulesRequest{Rules: rules}, ok)
+}
+
+// AddIntermediaryRulesTraced adds intermediary rules.
+func (r *RPCGateway) AddIntermediaryRulesTraced(req AddIntermediaryRulesRequest, ok *bool) error {
+	log := r.logger.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to add %d intermediary rules.", len(req.Rules))
+
+	if err := r.router.SaveRoutingRules(req.Rules...); err != nil {
 		*ok = false
 
-		r.logger.WithError(err).Warnf("Request completed with error.")
+		log.WithError(err).Warnf("Request completed with error.")
 
 		return routing.Failure{Code: routing.FailureAddRules, Msg: err.Error()}
 	}
@@ -52,9 +97,17 @@ func (r *RPCGateway) AddIntermediaryRules(rules []routing.Rule, ok *bool) error
 
 // ReserveIDs reserves route IDs.
 func (r *RPCGateway) ReserveIDs(n uint8, routeIDs *[]routing.RouteID) error {
-	ids, err := r.router.ReserveKeys(int(n))
+	return r.ReserveIDsTraced(ReserveIDsRequest{N: n}, routeIDs)
+}
+
+// ReserveIDsTraced reserves route IDs.
+func (r *RPCGateway) ReserveIDsTraced(req ReserveIDsRequest, routeIDs *[]routing.RouteID) error {
+	log := r.logger.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to reserve %d route IDs.", req.N)
+
+	ids, err := r.router.ReserveKeys(int(req.N))
 	if err != nil {
-		r.logger.WithError(err).Warnf("Request completed with error.")
+		log.WithError(err).Warnf("Request completed with error.")
 		return routing.Failure{Code: routing.FailureReserveRtIDs, Msg: err.Error()}
 	}
 
@@ -62,3 +115,32 @@ func (r *RPCGateway) ReserveIDs(n uint8, routeIDs *[]routing.RouteID) error {
 
 	return nil
 }
+
+// DeleteRules deletes the rules of the given route IDs and releases the IDs, including the ones
+// which are only reserved. It is used by setup nodes to roll back a failed route setup.
+// The given rules are deleted only if they are still stored, so that route IDs which were
+// released and reused since are not affected. They are used to tear down abandoned routes.
+func (r *RPCGateway) DeleteRules(req DeleteRulesRequest, ok *bool) error {
+	log := r.logger.WithField("trace_id", req.TraceID)
+	log.Infof("Received request to delete rules of route IDs %v and %d rules.", req.IDs, len(req.Rules))
+
+	ids := append([]routing.RouteID(nil), req.IDs...)
+
+	for _, rule := range req.Rules {
+		stored, err := r.router.Rule(rule.KeyRouteID())
+		if err != nil || !bytes.Equal(stored, rule) {
+			log.Infof("Rule with route ID %d is not stored, skipping.", rule.KeyRouteID())
+			continue
+		}
+
+		ids = append(ids, rule.KeyRouteID())
+	}
+
+	if len(ids) > 0 {
+		r.router.DelRules(ids)
+	}
+
+	*ok = true
+
+	return nil
+}
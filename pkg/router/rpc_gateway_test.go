@@ -2,8 +2,10 @@ package router
 
 import (
 	"testing"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
@@ -32,7 +34,7 @@ func TestRPCGateway_AddEdgeRules(t *testing.T) {
 		gateway := NewRPCGateway(r)
 
 		var ok bool
-		err := gateway.AddEdgeRules(rules, &ok)
+		err := gateway.AddEdgeRulesTraced(AddEdgeRulesRequest{Rules: rules}, &ok)
 		require.NoError(t, err)
 		require.True(t, ok)
 	})
@@ -44,7 +46,7 @@ func TestRPCGateway_AddEdgeRules(t *testing.T) {
 		gateway := NewRPCGateway(r)
 
 		var ok bool
-		err := gateway.AddEdgeRules(rules, &ok)
+		err := gateway.AddEdgeRulesTraced(AddEdgeRulesRequest{Rules: rules}, &ok)
 
 		wantErr := routing.Failure{
 			Code: routing.FailureAddRules,
@@ -67,7 +69,7 @@ func TestRPCGateway_AddEdgeRules(t *testing.T) {
 		}
 
 		var ok bool
-		err := gateway.AddEdgeRules(rules, &ok)
+		err := gateway.AddEdgeRulesTraced(AddEdgeRulesRequest{Rules: rules}, &ok)
 		require.Equal(t, wantErr, err)
 		require.False(t, ok)
 	})
@@ -86,7 +88,7 @@ func TestRPCGateway_AddIntermediaryRules(t *testing.T) {
 		gateway := NewRPCGateway(r)
 
 		var ok bool
-		err := gateway.AddIntermediaryRules(rules, &ok)
+		err := gateway.AddIntermediaryRulesTraced(AddIntermediaryRulesRequest{Rules: rules}, &ok)
 		require.NoError(t, err)
 		require.True(t, ok)
 	})
@@ -103,7 +105,7 @@ func TestRPCGateway_AddIntermediaryRules(t *testing.T) {
 		}
 
 		var ok bool
-		err := gateway.AddIntermediaryRules(rules, &ok)
+		err := gateway.AddIntermediaryRulesTraced(AddIntermediaryRulesRequest{Rules: rules}, &ok)
 		require.Equal(t, wantErr, err)
 		require.False(t, ok)
 	})
@@ -120,7 +122,7 @@ func TestRPCGateway_ReserveIDs(t *testing.T) {
 		gateway := NewRPCGateway(r)
 
 		var gotIds []routing.RouteID
-		err := gateway.ReserveIDs(uint8(n), &gotIds)
+		err := gateway.ReserveIDsTraced(ReserveIDsRequest{N: uint8(n)}, &gotIds)
 		require.NoError(t, err)
 		require.Equal(t, ids, gotIds)
 	})
@@ -137,8 +139,45 @@ func TestRPCGateway_ReserveIDs(t *testing.T) {
 		}
 
 		var gotIds []routing.RouteID
-		err := gateway.ReserveIDs(uint8(n), &gotIds)
+		err := gateway.ReserveIDsTraced(ReserveIDsRequest{N: uint8(n)}, &gotIds)
 		require.Equal(t, wantErr, err)
 		require.Nil(t, gotIds)
 	})
 }
+
+func TestRPCGateway_DeleteRules(t *testing.T) {
+	t.Run("ids", func(t *testing.T) {
+		ids := []routing.RouteID{1, 2, 3}
+
+		r := &MockRouter{}
+		r.On("DelRules", ids).Return()
+
+		gateway := NewRPCGateway(r)
+
+		var ok bool
+		err := gateway.DeleteRules(DeleteRulesRequest{TraceID: "trace", IDs: ids}, &ok)
+		require.NoError(t, err)
+		require.True(t, ok)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("stored rules", func(t *testing.T) {
+		stored := routing.IntermediaryForwardRule(time.Hour, 1, 2, uuid.New())
+		replaced := routing.IntermediaryForwardRule(time.Hour, 3, 4, uuid.New())
+		missing := routing.IntermediaryForwardRule(time.Hour, 5, 6, uuid.New())
+
+		r := &MockRouter{}
+		r.On("Rule", routing.RouteID(1)).Return(stored, testhelpers.NoErr)
+		r.On("Rule", routing.RouteID(3)).Return(routing.IntermediaryForwardRule(time.Hour, 3, 7, uuid.New()), testhelpers.NoErr)
+		r.On("Rule", routing.RouteID(5)).Return(nil, testhelpers.Err)
+		r.On("DelRules", []routing.RouteID{1}).Return()
+
+		gateway := NewRPCGateway(r)
+
+		var ok bool
+		req := DeleteRulesRequest{TraceID: "trace", Rules: []routing.Rule{stored, replaced, missing}}
+		require.NoError(t, gateway.DeleteRules(req, &ok))
+		require.True(t, ok)
+		r.AssertExpectations(t)
+	})
+}
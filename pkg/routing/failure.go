@@ -24,6 +24,9 @@ const (
 	FailureCreateRoutes
 	FailureRoutesCreated
 	FailureReserveRtIDs
+	FailureInvalidPath
+	FailureCheckPath
+	FailureLimitExceeded
 )
 
 func (fc FailureCode) String() string {
@@ -38,6 +41,12 @@ func (fc FailureCode) String() string {
 		return "FailureRoutesCreated"
 	case FailureReserveRtIDs:
 		return "FailureReserveRtIDs"
+	case FailureInvalidPath:
+		return "FailureInvalidPath"
+	case FailureCheckPath:
+		return "FailureCheckPath"
+	case FailureLimitExceeded:
+		return "FailureLimitExceeded"
 	default:
 		return fmt.Sprintf("unknown(%d)", fc)
 	}
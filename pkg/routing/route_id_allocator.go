@@ -0,0 +1,123 @@
+package routing
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultIDQuarantine is the default duration a released route ID is kept out of circulation,
+	// so that late packets of an old route cannot hit the rule of a new route.
+	DefaultIDQuarantine = time.Minute * 2
+
+	// DefaultReservationTimeout is the default duration after which a reserved route ID
+	// with no rule saved for it is released.
+	DefaultReservationTimeout = time.Minute
+
+	maxRouteID = RouteID(math.MaxUint32 - 1)
+)
+
+// releasedID is a route ID in quarantine.
+type releasedID struct {
+	id RouteID
+	at time.Time
+}
+
+// idAllocator allocates route IDs, reusing released ones after a quarantine period.
+// It is not thread-safe.
+type idAllocator struct {
+	quarantineTime     time.Duration
+	reservationTimeout time.Duration
+
+	next       RouteID               // last ID which was allocated for the first time
+	free       []RouteID             // released IDs past quarantine, available for reuse
+	quarantine []releasedID          // released IDs in quarantine, ordered by release time
+	released   map[RouteID]struct{}  // IDs in either free or quarantine
+	reserved   map[RouteID]time.Time // reserved IDs with no rule saved yet
+	inUse      func(id RouteID) bool // reports whether a rule is stored for the ID
+}
+
+func newIDAllocator(quarantineTime, reservationTimeout time.Duration, inUse func(RouteID) bool) *idAllocator {
+	return &idAllocator{
+		quarantineTime:     quarantineTime,
+		reservationTimeout: reservationTimeout,
+		released:           make(map[RouteID]struct{}),
+		reserved:           make(map[RouteID]time.Time),
+		inUse:              inUse,
+	}
+}
+
+// reserve allocates 'n' route IDs. Either all of them are allocated or none.
+func (a *idAllocator) reserve(n int, now time.Time) ([]RouteID, error) {
+	a.expire(now)
+
+	if n < 0 || int64(n) > int64(len(a.free))+int64(maxRouteID-a.next) {
+		return nil, ErrNoAvailableRoutes
+	}
+
+	ids := make([]RouteID, 0, n)
+	for len(ids) < n && len(a.free) > 0 {
+		id := a.free[0]
+		a.free = a.free[1:]
+		delete(a.released, id)
+
+		// The ID may have been taken by a rule saved without reservation.
+		if a.inUse(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	for len(ids) < n {
+		if a.next == maxRouteID {
+			a.releaseAll(ids, now)
+			return nil, ErrNoAvailableRoutes
+		}
+		a.next++
+		ids = append(ids, a.next)
+	}
+
+	for _, id := range ids {
+		a.reserved[id] = now
+	}
+	return ids, nil
+}
+
+// used marks a reserved ID as used by a rule.
+func (a *idAllocator) used(id RouteID) {
+	delete(a.reserved, id)
+}
+
+// release puts the ID in quarantine. Unknown or already released IDs are ignored.
+func (a *idAllocator) release(id RouteID, now time.Time) {
+	if id == 0 || id > a.next {
+		return
+	}
+	if _, ok := a.released[id]; ok {
+		return
+	}
+
+	delete(a.reserved, id)
+	a.released[id] = struct{}{}
+	a.quarantine = append(a.quarantine, releasedID{id: id, at: now})
+}
+
+func (a *idAllocator) releaseAll(ids []RouteID, now time.Time) {
+	for _, id := range ids {
+		a.release(id, now)
+	}
+}
+
+// expire releases timed out reservations and frees IDs which are past quarantine.
+func (a *idAllocator) expire(now time.Time) {
+	for id, at := range a.reserved {
+		if now.Sub(at) > a.reservationTimeout {
+			a.release(id, now)
+		}
+	}
+
+	i := 0
+	for ; i < len(a.quarantine) && now.Sub(a.quarantine[i].at) >= a.quarantineTime; i++ {
+		a.free = append(a.free, a.quarantine[i].id)
+	}
+	a.quarantine = a.quarantine[i:]
+}
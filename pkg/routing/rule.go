@@ -61,6 +61,33 @@ func (r Rule) assertLen(l int) {
 	}
 }
 
+// Validate checks that the rule is of a known type, and is long enough for it.
+// Rules received from remote visors are to be validated before use, as accessors panic on short rules.
+func (r Rule) Validate() error {
+	if len(r) < RuleHeaderSize {
+		return errors.New("bad rule length")
+	}
+
+	size := RuleHeaderSize
+
+	switch t := r.Type(); t {
+	case RuleConsume:
+		size += routeDescriptorSize
+	case RuleForward:
+		size += routeDescriptorSize + 4 + uuidSize
+	case RuleIntermediaryForward:
+		size += 4 + uuidSize
+	default:
+		return fmt.Errorf("invalid rule type: %v", t)
+	}
+
+	if len(r) < size {
+		return fmt.Errorf("bad length of %v rule", r.Type())
+	}
+
+	return nil
+}
+
 // KeepAlive returns rule's keep-alive timeout.
 func (r Rule) KeepAlive() time.Duration {
 	r.assertLen(RuleHeaderSize)
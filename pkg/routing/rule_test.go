@@ -67,3 +67,24 @@ func TestIntermediaryForwardRule(t *testing.T) {
 	rule.SetKeyRouteID(3)
 	assert.Equal(t, RouteID(3), rule.KeyRouteID())
 }
+
+func TestRule_Validate(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	rules := []Rule{
+		ConsumeRule(time.Minute, 1, pk, pk, 2, 3),
+		ForwardRule(time.Minute, 1, 2, uuid.New(), pk, pk, 3, 4),
+		IntermediaryForwardRule(time.Minute, 1, 2, uuid.New()),
+	}
+
+	for _, rule := range rules {
+		assert.NoError(t, rule.Validate())
+		assert.Error(t, rule[:RuleHeaderSize+1].Validate())
+	}
+
+	assert.Error(t, Rule{}.Validate())
+
+	unknown := ConsumeRule(time.Minute, 1, pk, pk, 2, 3)
+	unknown.setType(RuleType(3))
+	assert.Error(t, unknown.Validate())
+}
@@ -3,7 +3,6 @@ package routing
 import (
 	"errors"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 )
@@ -41,54 +40,62 @@ type Table interface {
 	Count() int
 
 	// CollectGarbage checks all the stored rules, removes and returns ones that timed out.
+	// It also releases reserved route IDs which no rule was saved for in time.
 	CollectGarbage() []Rule
 }
 
+// TableConfig configures the allocation of route IDs of a Table.
+type TableConfig struct {
+	// IDQuarantine is the duration a route ID is not reused after it is released.
+	IDQuarantine time.Duration
+	// ReservationTimeout is the duration after which a reserved route ID is released if no rule is saved for it.
+	ReservationTimeout time.Duration
+}
+
+// DefaultTableConfig returns the default TableConfig.
+func DefaultTableConfig() TableConfig {
+	return TableConfig{
+		IDQuarantine:       DefaultIDQuarantine,
+		ReservationTimeout: DefaultReservationTimeout,
+	}
+}
+
 type memTable struct {
 	sync.RWMutex
 
-	nextID   RouteID
+	ids      *idAllocator
 	rules    map[RouteID]Rule
 	activity map[RouteID]time.Time
+	now      func() time.Time
 }
 
-// NewTable returns an in-memory routing table implementation with a specified configuration.
+// NewTable returns an in-memory routing table implementation with the default configuration.
 func NewTable() Table {
+	return NewTableWithConfig(DefaultTableConfig())
+}
+
+// NewTableWithConfig returns an in-memory routing table implementation with a specified configuration.
+func NewTableWithConfig(conf TableConfig) Table {
 	mt := &memTable{
 		rules:    map[RouteID]Rule{},
 		activity: make(map[RouteID]time.Time),
+		now:      time.Now,
 	}
+	mt.ids = newIDAllocator(conf.IDQuarantine, conf.ReservationTimeout, func(id RouteID) bool {
+		_, ok := mt.rules[id]
+		return ok
+	})
 
 	return mt
 }
 
+// ReserveKeys reserves n route IDs, reusing released IDs which are past quarantine.
+// Reserved IDs are released if no rule is saved for them within the reservation timeout.
 func (mt *memTable) ReserveKeys(n int) ([]RouteID, error) {
-	first, last, err := mt.reserveKeysImpl(n)
-	if err != nil {
-		return nil, err
-	}
-
-	routes := make([]RouteID, 0, n)
-	for id := first; id <= last; id++ {
-		routes = append(routes, id)
-	}
-
-	return routes, nil
-}
-
-func (mt *memTable) reserveKeysImpl(n int) (first, last RouteID, err error) {
 	mt.Lock()
 	defer mt.Unlock()
 
-	if int64(mt.nextID)+int64(n) >= math.MaxUint32 {
-		return 0, 0, ErrNoAvailableRoutes
-	}
-
-	first = mt.nextID + 1
-	mt.nextID += RouteID(n)
-	last = mt.nextID
-
-	return first, last, nil
+	return mt.ids.reserve(n, mt.now())
 }
 
 func (mt *memTable) SaveRule(rule Rule) error {
@@ -101,6 +108,7 @@ func (mt *memTable) SaveRule(rule Rule) error {
 	mt.rules[key] = rule
 	fmt.Printf("ROUTING TABLE CONTENTS: %v\n", mt.rules)
 	mt.activity[key] = now
+	mt.ids.used(key)
 
 	return nil
 }
@@ -184,9 +192,12 @@ func (mt *memTable) DelRules(keys []RouteID) {
 	}
 }
 
+// delRule deletes the rule of 'key' and releases the route ID.
+// The ID is also released if it is only reserved.
 func (mt *memTable) delRule(key RouteID) {
 	delete(mt.rules, key)
 	delete(mt.activity, key)
+	mt.ids.release(key, mt.now())
 }
 
 func (mt *memTable) Count() int {
@@ -208,6 +219,8 @@ func (mt *memTable) CollectGarbage() []Rule {
 		}
 	}
 
+	mt.ids.expire(mt.now())
+
 	return timedOutRules
 }
 
@@ -72,3 +72,66 @@ func RoutingTableSuite(t *testing.T, tbl Table) {
 func TestRoutingTable(t *testing.T) {
 	RoutingTableSuite(t, NewTable())
 }
+
+func TestRoutingTable_RouteIDRecycling(t *testing.T) {
+	now := time.Unix(0, 0)
+	tbl := NewTableWithConfig(TableConfig{IDQuarantine: time.Minute, ReservationTimeout: time.Minute}).(*memTable)
+	tbl.now = func() time.Time { return now }
+
+	ids, err := tbl.ReserveKeys(3)
+	require.NoError(t, err)
+	require.Equal(t, []RouteID{1, 2, 3}, ids)
+
+	for _, id := range ids[:2] {
+		require.NoError(t, tbl.SaveRule(IntermediaryForwardRule(time.Hour, id, 2, uuid.New())))
+	}
+	tbl.DelRules(ids[:1])
+
+	// Released IDs are quarantined.
+	now = now.Add(30 * time.Second)
+	ids, err = tbl.ReserveKeys(1)
+	require.NoError(t, err)
+	assert.Equal(t, []RouteID{4}, ids)
+
+	// Route ID 3 had no rule saved for it, so its reservation expires with ID 4's still pending.
+	now = now.Add(45 * time.Second)
+	tbl.CollectGarbage()
+	assert.Contains(t, tbl.ids.released, RouteID(3))
+	assert.NotContains(t, tbl.ids.released, RouteID(4))
+
+	// ID 1 is past quarantine and reused first.
+	now = now.Add(40 * time.Second)
+	ids, err = tbl.ReserveKeys(2)
+	require.NoError(t, err)
+	assert.Equal(t, []RouteID{1, 5}, ids)
+
+	// IDs 3 and 4 are reused after their quarantine.
+	// The reservations of IDs 1 and 5 expire meanwhile, but are quarantined themselves.
+	now = now.Add(2 * time.Minute)
+	ids, err = tbl.ReserveKeys(3)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []RouteID{3, 4, 6}, ids)
+}
+
+func TestRoutingTable_RouteIDExhaustion(t *testing.T) {
+	tbl := NewTable().(*memTable)
+	tbl.ids.next = maxRouteID - 2
+
+	_, err := tbl.ReserveKeys(3)
+	assert.Equal(t, ErrNoAvailableRoutes, err)
+
+	ids, err := tbl.ReserveKeys(2)
+	require.NoError(t, err)
+	assert.Equal(t, []RouteID{maxRouteID - 1, maxRouteID}, ids)
+
+	_, err = tbl.ReserveKeys(1)
+	assert.Equal(t, ErrNoAvailableRoutes, err)
+
+	// A released ID becomes available again once its quarantine is over.
+	tbl.DelRules(ids[:1])
+	tbl.now = func() time.Time { return time.Now().Add(DefaultIDQuarantine) }
+
+	ids, err = tbl.ReserveKeys(1)
+	require.NoError(t, err)
+	assert.Equal(t, []RouteID{maxRouteID - 1}, ids)
+}
@@ -12,6 +12,14 @@ const (
 	ReadTimeout    = time.Second * 30
 )
 
+// Default limits of setup node.
+const (
+	DefaultRequesterRate          = 1.0
+	DefaultRequesterBurst         = 10
+	DefaultMaxRequesterConcurrent = 4
+	DefaultMaxConcurrent          = 256
+)
+
 // Config defines configuration parameters for setup Node.
 type Config struct {
 	PubKey cipher.PubKey `json:"public_key"`
@@ -24,5 +32,35 @@ type Config struct {
 
 	TransportDiscovery string `json:"transport_discovery"`
 
+	// Limits are the limits on requests. Default limits apply if nil.
+	Limits *LimitsConfig `json:"limits,omitempty"`
+
 	LogLevel string `json:"log_level"`
 }
+
+// LimitsConfig configures the limits on requests served by setup node,
+// which protect visors from route setups flooded by a single requester.
+// A zero value of a field disables the respective limit.
+type LimitsConfig struct {
+	// RequesterRate is the average number of requests per second a requester may make.
+	RequesterRate float64 `json:"requester_rate"`
+
+	// RequesterBurst is the number of requests a requester may make at once, above RequesterRate.
+	RequesterBurst int `json:"requester_burst"`
+
+	// MaxRequesterConcurrent is the number of requests of a single requester which are served concurrently.
+	MaxRequesterConcurrent int `json:"max_requester_concurrent"`
+
+	// MaxConcurrent is the number of requests which are served concurrently.
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+// DefaultLimitsConfig returns the default limits of setup node.
+func DefaultLimitsConfig() LimitsConfig {
+	return LimitsConfig{
+		RequesterRate:          DefaultRequesterRate,
+		RequesterBurst:         DefaultRequesterBurst,
+		MaxRequesterConcurrent: DefaultMaxRequesterConcurrent,
+		MaxConcurrent:          DefaultMaxConcurrent,
+	}
+}
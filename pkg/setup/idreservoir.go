@@ -18,9 +18,11 @@ import (
 var ErrNoKey = errors.New("id reservoir has no key")
 
 type idReservoir struct {
-	rec map[cipher.PubKey]uint8
-	ids map[cipher.PubKey][]routing.RouteID
-	mx  sync.Mutex
+	rec      map[cipher.PubKey]uint8
+	ids      map[cipher.PubKey][]routing.RouteID
+	reserved map[cipher.PubKey][]routing.RouteID // all IDs reserved, unlike 'ids' not consumed by PopID
+	total    int
+	mx       sync.Mutex
 }
 
 func newIDReservoir(paths ...routing.Path) (*idReservoir, int) {
@@ -43,16 +45,24 @@ func newIDReservoir(paths ...routing.Path) (*idReservoir, int) {
 	}
 
 	return &idReservoir{
-		rec: rec,
-		ids: make(map[cipher.PubKey][]routing.RouteID),
+		rec:      rec,
+		ids:      make(map[cipher.PubKey][]routing.RouteID),
+		reserved: make(map[cipher.PubKey][]routing.RouteID),
+		total:    total,
 	}, total
 }
 
+// Total returns the number of route IDs to reserve.
+func (idr *idReservoir) Total() int {
+	return idr.total
+}
+
 type reserveFunc func(
 	ctx context.Context,
 	log *logging.Logger,
 	dmsgC *dmsg.Client,
 	pk cipher.PubKey,
+	traceID string,
 	n uint8,
 ) ([]routing.RouteID, error)
 
@@ -60,6 +70,7 @@ func (idr *idReservoir) ReserveIDs(
 	ctx context.Context,
 	log *logging.Logger,
 	dmsgC *dmsg.Client,
+	traceID string,
 	reserve reserveFunc,
 ) error {
 	ctx, cancel := context.WithCancel(ctx)
@@ -70,13 +81,14 @@ func (idr *idReservoir) ReserveIDs(
 
 	for pk, n := range idr.rec {
 		go func(pk cipher.PubKey, n uint8) {
-			ids, err := reserve(ctx, log, dmsgC, pk, n)
+			ids, err := reserve(ctx, log, dmsgC, pk, traceID, n)
 			if err != nil {
 				errCh <- fmt.Errorf("reserve routeID from %s failed: %v", pk, err)
 				return
 			}
 			idr.mx.Lock()
 			idr.ids[pk] = ids
+			idr.reserved[pk] = ids
 			idr.mx.Unlock()
 			errCh <- nil
 		}(pk, n)
@@ -85,6 +97,19 @@ func (idr *idReservoir) ReserveIDs(
 	return finalError(len(idr.rec), errCh)
 }
 
+// ReservedIDs returns the IDs which were successfully reserved, including the ones already popped.
+func (idr *idReservoir) ReservedIDs() map[cipher.PubKey][]routing.RouteID {
+	idr.mx.Lock()
+	defer idr.mx.Unlock()
+
+	out := make(map[cipher.PubKey][]routing.RouteID, len(idr.reserved))
+	for pk, ids := range idr.reserved {
+		out[pk] = append([]routing.RouteID(nil), ids...)
+	}
+
+	return out
+}
+
 func (idr *idReservoir) PopID(pk cipher.PubKey) (routing.RouteID, bool) {
 	idr.mx.Lock()
 	defer idr.mx.Unlock()
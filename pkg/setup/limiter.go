@@ -0,0 +1,153 @@
+package setup
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// Reasons of rejecting requests, as recorded by metrics.
+const (
+	RejectRequesterRate          = "requester_rate"
+	RejectMaxRequesterConcurrent = "max_requester_concurrent"
+	RejectMaxConcurrent          = "max_concurrent"
+)
+
+var (
+	// ErrRequesterRate is returned when a requester exceeds its request rate.
+	ErrRequesterRate = errors.New("request rate limit exceeded")
+
+	// ErrMaxRequesterConcurrent is returned when a requester has too many requests being served.
+	ErrMaxRequesterConcurrent = errors.New("too many concurrent requests from requester")
+
+	// ErrMaxConcurrent is returned when setup node serves too many requests.
+	ErrMaxConcurrent = errors.New("too many concurrent requests")
+)
+
+// limiterSweepInterval is the interval of removing the state of requesters which are idle.
+const limiterSweepInterval = time.Minute
+
+// limiter enforces LimitsConfig.
+// Request rates are limited with a token bucket per requester.
+type limiter struct {
+	conf LimitsConfig
+	now  func() time.Time
+
+	buckets   map[cipher.PubKey]*tokenBucket
+	active    map[cipher.PubKey]int
+	total     int
+	lastSweep time.Time
+	mx        sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(conf LimitsConfig) *limiter {
+	if conf.RequesterRate > 0 && conf.RequesterBurst < 1 {
+		conf.RequesterBurst = 1
+	}
+
+	return &limiter{
+		conf:    conf,
+		now:     time.Now,
+		buckets: make(map[cipher.PubKey]*tokenBucket),
+		active:  make(map[cipher.PubKey]int),
+	}
+}
+
+// acquire reserves a concurrency slot for a request of 'pk'. The slot is to be released by calling 'release'.
+// On rejection, the reason of the rejection is returned along with the error.
+// A nil limiter does not limit requests.
+func (l *limiter) acquire(pk cipher.PubKey) (release func(), reason string, err error) {
+	if l == nil {
+		return func() {}, "", nil
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if max := l.conf.MaxConcurrent; max > 0 && l.total >= max {
+		return nil, RejectMaxConcurrent, ErrMaxConcurrent
+	}
+
+	if max := l.conf.MaxRequesterConcurrent; max > 0 && l.active[pk] >= max {
+		return nil, RejectMaxRequesterConcurrent, ErrMaxRequesterConcurrent
+	}
+
+	l.total++
+	l.active[pk]++
+
+	var once sync.Once
+
+	release = func() {
+		once.Do(func() {
+			l.mx.Lock()
+			defer l.mx.Unlock()
+
+			l.total--
+			if l.active[pk]--; l.active[pk] <= 0 {
+				delete(l.active, pk)
+			}
+		})
+	}
+
+	return release, "", nil
+}
+
+// allow takes a token from the bucket of 'pk', if the request rate of 'pk' is not exceeded.
+func (l *limiter) allow(pk cipher.PubKey) (reason string, err error) {
+	if l == nil || l.conf.RequesterRate <= 0 {
+		return "", nil
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[pk]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.conf.RequesterBurst), last: now}
+		l.buckets[pk] = b
+	}
+
+	l.refill(b, now)
+
+	if b.tokens < 1 {
+		return RejectRequesterRate, ErrRequesterRate
+	}
+
+	b.tokens--
+
+	return "", nil
+}
+
+func (l *limiter) refill(b *tokenBucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * l.conf.RequesterRate
+	if burst := float64(l.conf.RequesterBurst); b.tokens > burst {
+		b.tokens = burst
+	}
+
+	b.last = now
+}
+
+// sweep removes buckets which are full, as they are equal to new buckets.
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < limiterSweepInterval {
+		return
+	}
+
+	for pk, b := range l.buckets {
+		if l.refill(b, now); b.tokens >= float64(l.conf.RequesterBurst) {
+			delete(l.buckets, pk)
+		}
+	}
+
+	l.lastSweep = now
+}
@@ -0,0 +1,126 @@
+package setup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+func TestLimiter_allow(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	now := time.Now()
+
+	l := newLimiter(LimitsConfig{RequesterRate: 2, RequesterBurst: 3})
+	l.now = func() time.Time { return now }
+
+	// The burst is available at once.
+	for i := 0; i < 3; i++ {
+		_, err := l.allow(pk1)
+		require.NoError(t, err)
+	}
+
+	reason, err := l.allow(pk1)
+	require.Equal(t, ErrRequesterRate, err)
+	require.Equal(t, RejectRequesterRate, reason)
+
+	// Other requesters are not affected.
+	_, err = l.allow(pk2)
+	require.NoError(t, err)
+
+	// Tokens are refilled at the rate.
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		_, err := l.allow(pk1)
+		require.NoError(t, err)
+	}
+
+	_, err = l.allow(pk1)
+	require.Equal(t, ErrRequesterRate, err)
+
+	// Idle requesters are swept.
+	now = now.Add(limiterSweepInterval)
+	_, err = l.allow(pk1)
+	require.NoError(t, err)
+	require.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_acquire(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	pk3, _ := cipher.GenerateKeyPair()
+
+	l := newLimiter(LimitsConfig{MaxRequesterConcurrent: 2, MaxConcurrent: 3})
+
+	release1, _, err := l.acquire(pk1)
+	require.NoError(t, err)
+
+	_, _, err = l.acquire(pk1)
+	require.NoError(t, err)
+
+	_, reason, err := l.acquire(pk1)
+	require.Equal(t, ErrMaxRequesterConcurrent, err)
+	require.Equal(t, RejectMaxRequesterConcurrent, reason)
+
+	_, _, err = l.acquire(pk2)
+	require.NoError(t, err)
+
+	_, reason, err = l.acquire(pk3)
+	require.Equal(t, ErrMaxConcurrent, err)
+	require.Equal(t, RejectMaxConcurrent, reason)
+
+	// Releasing twice frees a single slot.
+	release1()
+	release1()
+
+	_, _, err = l.acquire(pk3)
+	require.NoError(t, err)
+
+	_, _, err = l.acquire(pk1)
+	require.Equal(t, ErrMaxConcurrent, err)
+}
+
+func TestLimiter_nil(t *testing.T) {
+	var l *limiter
+
+	pk, _ := cipher.GenerateKeyPair()
+
+	release, _, err := l.acquire(pk)
+	require.NoError(t, err)
+	release()
+
+	_, err = l.allow(pk)
+	require.NoError(t, err)
+}
+
+func TestRPCGateway_begin(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	sn := &Node{
+		metrics: metrics.NewDummy(),
+		limiter: newLimiter(LimitsConfig{MaxConcurrent: 1}),
+	}
+
+	g1 := NewRPCGateway(pk1, sn, time.Second)
+	g2 := NewRPCGateway(pk2, sn, time.Second)
+
+	// A slot is only taken while a call is served, not for the lifetime of the stream.
+	end, err := g1.begin()
+	require.NoError(t, err)
+
+	_, err = g2.begin()
+	require.Equal(t, routing.FailureLimitExceeded, err.(routing.Failure).Code)
+
+	end()
+
+	end, err = g2.begin()
+	require.NoError(t, err)
+	end()
+}
@@ -2,19 +2,25 @@ package setup
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/rpc"
 	"sync"
 	"time"
 
 	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/dmsg/disc"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/sirupsen/logrus"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/metrics"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/router/routerclient"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+	trClient "github.com/SkycoinProject/skywire-mainnet/pkg/transport-discovery/client"
 )
 
 // Node performs routes setup operations over messaging channel.
@@ -24,6 +30,15 @@ type Node struct {
 	dmsgL         *dmsg.Listener
 	sessionsCount int
 	metrics       metrics.Recorder
+	limiter       *limiter
+	routeGroups   routeGroupRecords
+
+	// The transport discovery client is created on first use, as it needs transport discovery to be reachable.
+	newTpDisc     func() (transport.DiscoveryClient, error) // nil if transports of paths are not to be checked
+	tpDisc        transport.DiscoveryClient
+	tpDiscErr     error     // error of the last attempt to create tpDisc
+	tpDiscRetryAt time.Time // time until which tpDiscErr is returned instead of retrying
+	tpDiscMx      sync.Mutex
 }
 
 // NewNode constructs a new SetupNode.
@@ -36,6 +51,23 @@ func NewNode(conf *Config, metrics metrics.Recorder) (*Node, error) {
 
 	log := logger.PackageLogger("setup_node")
 
+	var newTpDisc func() (transport.DiscoveryClient, error)
+
+	if conf.TransportDiscovery != "" {
+		newTpDisc = func() (transport.DiscoveryClient, error) {
+			return trClient.NewHTTP(conf.TransportDiscovery, conf.PubKey, conf.SecKey)
+		}
+	} else {
+		log.Warn("No transport discovery configured, transports of paths are not checked.")
+	}
+
+	limits := DefaultLimitsConfig()
+	if conf.Limits != nil {
+		limits = *conf.Limits
+	}
+
+	log.Infof("Limits: %+v", limits)
+
 	// Prepare dmsg.
 	dmsgC := dmsg.NewClient(
 		conf.PubKey,
@@ -62,6 +94,8 @@ func NewNode(conf *Config, metrics metrics.Recorder) (*Node, error) {
 		dmsgL:         dmsgL,
 		sessionsCount: conf.Dmsg.SessionsCount,
 		metrics:       metrics,
+		limiter:       newLimiter(limits),
+		newTpDisc:     newTpDisc,
 	}
 
 	return node, nil
@@ -91,39 +125,97 @@ func (sn *Node) Serve() error {
 
 		const timeout = 30 * time.Second
 
+		// Concurrency limits are enforced per call by the gateway, so a stream only takes a slot while its
+		// call is being served. Streams without calls are closed after streamIdleTimeout.
 		rpcS := rpc.NewServer()
 		if err := rpcS.Register(NewRPCGateway(remote.PK, sn, timeout)); err != nil {
 			return err
 		}
 
-		go rpcS.ServeConn(conn)
+		go rpcS.ServeConn(&idleConn{Conn: conn, timeout: streamIdleTimeout})
 	}
 }
 
-func (sn *Node) handleDialRouteGroup(ctx context.Context, route routing.BidirectionalRoute) (routing.EdgeRules, error) {
-	sn.logger.Infof("Setup route from %s to %s", route.Desc.SrcPK(), route.Desc.DstPK())
+// streamIdleTimeout is the duration a stream may wait for the next request before it is closed.
+const streamIdleTimeout = time.Minute
 
-	idr, err := sn.reserveRouteIDs(ctx, route)
-	if err != nil {
+// idleConn is a connection whose reads fail if nothing is read within 'timeout'.
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(b)
+}
+
+// Steps of a route setup. They are reported when a route setup fails.
+const (
+	stepReserveIDs        = "reserve route IDs"
+	stepGenerateRules     = "generate rules"
+	stepIntermediaryRules = "add intermediary rules"
+	stepConfirmRoute      = "confirm route group with destination visor"
+)
+
+// rollbackTimeout is the timeout of undoing the changes of a failed route setup.
+// It does not depend on the timeout of the request, as that may be the reason of the failure.
+const rollbackTimeout = 30 * time.Second
+
+// handleDialRouteGroup validates the route, reserves route IDs on every visor of the route, installs the rules on intermediary
+// visors and confirms the route group with the destination visor. If any step fails, the rules installed
+// and the route IDs reserved on all visors so far are deleted.
+func (sn *Node) handleDialRouteGroup(ctx context.Context, traceID string, route routing.BidirectionalRoute) (routing.EdgeRules, error) {
+	log := sn.logger.WithField("trace_id", traceID)
+	log.Infof("Setup route from %s to %s", route.Desc.SrcPK(), route.Desc.DstPK())
+
+	if err := sn.validateRoute(ctx, route); err != nil {
+		log.WithError(err).Warn("Rejected route.")
 		return routing.EdgeRules{}, err
 	}
 
+	idr, _ := newIDReservoir(route.Forward, route.Reverse)
+
+	rules, step, err := sn.setupRouteGroup(ctx, log, traceID, idr, route)
+	if err != nil {
+		log.WithError(err).Warnf("Route setup failed on step %q, rolling back.", step)
+		sn.rollback(log, traceID, idr.ReservedIDs())
+
+		return routing.EdgeRules{}, fmt.Errorf("failed to %s: %v", step, err)
+	}
+
+	return rules, nil
+}
+
+// setupRouteGroup performs the steps of a route setup. On failure, it returns the step which failed.
+func (sn *Node) setupRouteGroup(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	traceID string,
+	idr *idReservoir,
+	route routing.BidirectionalRoute,
+) (routing.EdgeRules, string, error) {
+	if err := sn.reserveRouteIDs(ctx, log, traceID, idr); err != nil {
+		return routing.EdgeRules{}, stepReserveIDs, err
+	}
+
 	forwardRoute, reverseRoute := route.ForwardAndReverse()
 
 	// Determine the rules to send to visors using route group descriptor and reserved route IDs.
 	forwardRules, consumeRules, intermediaryRules, err := idr.GenerateRules(forwardRoute, reverseRoute)
-
 	if err != nil {
-		sn.logger.WithError(err).Error("ERROR GENERATING RULES")
-		return routing.EdgeRules{}, err
+		return routing.EdgeRules{}, stepGenerateRules, err
 	}
 
-	sn.logger.Infof("generated forward rules: %v", forwardRules)
-	sn.logger.Infof("generated consume rules: %v", consumeRules)
-	sn.logger.Infof("generated intermediary rules: %v", intermediaryRules)
+	log.Infof("generated forward rules: %v", forwardRules)
+	log.Infof("generated consume rules: %v", consumeRules)
+	log.Infof("generated intermediary rules: %v", intermediaryRules)
 
-	if err := sn.addIntermediaryRules(ctx, intermediaryRules); err != nil {
-		return routing.EdgeRules{}, err
+	if err := sn.addIntermediaryRules(ctx, log, traceID, intermediaryRules); err != nil {
+		return routing.EdgeRules{}, stepIntermediaryRules, err
 	}
 
 	initRouteRules := routing.EdgeRules{
@@ -138,21 +230,39 @@ func (sn *Node) handleDialRouteGroup(ctx context.Context, route routing.Bidirect
 		Reverse: consumeRules[route.Desc.DstPK()],
 	}
 
-	sn.logger.Infof("initRouteRules: Desc(%s), %s", &initRouteRules.Desc, initRouteRules)
-	sn.logger.Infof("respRouteRules: Desc(%s), %s", &respRouteRules.Desc, respRouteRules)
+	log.Infof("initRouteRules: Desc(%s), %s", &initRouteRules.Desc, initRouteRules)
+	log.Infof("respRouteRules: Desc(%s), %s", &respRouteRules.Desc, respRouteRules)
 
 	// Confirm routes with responding visor.
-	ok, err := routerclient.AddEdgeRules(ctx, sn.logger, sn.dmsgC, route.Desc.DstPK(), respRouteRules)
-	if err != nil || !ok {
-		return routing.EdgeRules{}, fmt.Errorf("failed to confirm route group with destination visor: %v", err)
+	ok, err := routerclient.AddEdgeRules(ctx, sn.logger, sn.dmsgC, route.Desc.DstPK(), traceID, respRouteRules)
+	if err == nil && !ok {
+		err = errors.New("rules were not accepted")
 	}
+	if err != nil {
+		return routing.EdgeRules{}, stepConfirmRoute, err
+	}
+
+	rules := make(map[cipher.PubKey][]routing.Rule, len(intermediaryRules)+2)
+	for pk, pkRules := range intermediaryRules {
+		rules[pk] = append(rules[pk], pkRules...)
+	}
+	srcPK, dstPK := route.Desc.SrcPK(), route.Desc.DstPK()
+	rules[srcPK] = append(rules[srcPK], initRouteRules.Forward, initRouteRules.Reverse)
+	rules[dstPK] = append(rules[dstPK], respRouteRules.Forward, respRouteRules.Reverse)
 
-	sn.logger.Infof("Returning route rules to initiating visor: %v", initRouteRules)
+	sn.routeGroups.add(route.Desc, routeGroupRecord{traceID: traceID, rules: rules, created: time.Now()})
 
-	return initRouteRules, nil
+	log.Infof("Returning route rules to initiating visor: %v", initRouteRules)
+
+	return initRouteRules, "", nil
 }
 
-func (sn *Node) addIntermediaryRules(ctx context.Context, intermediaryRules RulesMap) error {
+func (sn *Node) addIntermediaryRules(
+	ctx context.Context,
+	log logrus.FieldLogger,
+	traceID string,
+	intermediaryRules RulesMap,
+) error {
 	errCh := make(chan error, len(intermediaryRules))
 
 	var wg sync.WaitGroup
@@ -160,14 +270,14 @@ func (sn *Node) addIntermediaryRules(ctx context.Context, intermediaryRules Rule
 	for pk, rules := range intermediaryRules {
 		pk, rules := pk, rules
 
-		sn.logger.WithField("remote", pk).Info("Adding rules to intermediary visor")
+		log.WithField("remote", pk).Info("Adding rules to intermediary visor")
 
 		wg.Add(1)
 
 		go func() {
 			defer wg.Done()
-			if _, err := routerclient.AddIntermediaryRules(ctx, sn.logger, sn.dmsgC, pk, rules); err != nil {
-				sn.logger.WithField("remote", pk).WithError(err).Warn("failed to add rules")
+			if _, err := routerclient.AddIntermediaryRules(ctx, sn.logger, sn.dmsgC, pk, traceID, rules); err != nil {
+				log.WithField("remote", pk).WithError(err).Warn("failed to add rules")
 				errCh <- err
 			}
 		}()
@@ -179,18 +289,79 @@ func (sn *Node) addIntermediaryRules(ctx context.Context, intermediaryRules Rule
 	return finalError(len(intermediaryRules), errCh)
 }
 
-func (sn *Node) reserveRouteIDs(ctx context.Context, route routing.BidirectionalRoute) (*idReservoir, error) {
-	reservoir, total := newIDReservoir(route.Forward, route.Reverse)
-	sn.logger.Infof("There are %d route IDs to reserve.", total)
+func (sn *Node) reserveRouteIDs(ctx context.Context, log logrus.FieldLogger, traceID string, idr *idReservoir) error {
+	log.Infof("There are %d route IDs to reserve.", idr.Total())
+
+	if err := idr.ReserveIDs(ctx, sn.logger, sn.dmsgC, traceID, routerclient.ReserveIDs); err != nil {
+		log.WithError(err).Warnf("Failed to reserve route IDs.")
+		return err
+	}
+
+	log.Infof("Successfully reserved route IDs.")
+
+	return nil
+}
+
+// rollback deletes the rules of the reserved route IDs and releases the IDs on every visor.
+// Failures are only logged, as the visors release the IDs eventually anyway.
+func (sn *Node) rollback(log logrus.FieldLogger, traceID string, reserved map[cipher.PubKey][]routing.RouteID) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	for pk, ids := range reserved {
+		pk, ids := pk, ids
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
 
-	err := reservoir.ReserveIDs(ctx, sn.logger, sn.dmsgC, routerclient.ReserveIDs)
+			log := log.WithField("remote", pk)
+			if _, err := routerclient.DeleteRules(ctx, sn.logger, sn.dmsgC, pk, traceID, ids); err != nil {
+				log.WithError(err).Warnf("Failed to roll back route IDs %v.", ids)
+				return
+			}
+
+			log.Infof("Rolled back route IDs %v.", ids)
+		}()
+	}
 
+	wg.Wait()
+}
+
+// handleDeleteRouteGroup removes the rules of a route group which was set up by the setup node from all
+// of its visors. It is requested by an edge of the route group, if the route group is abandoned
+// and close packets cannot traverse it.
+func (sn *Node) handleDeleteRouteGroup(
+	ctx context.Context,
+	traceID string,
+	reqPK cipher.PubKey,
+	desc routing.RouteDescriptor,
+) error {
+	log := sn.logger.WithField("trace_id", traceID)
+	log.Infof("Delete route group %s", &desc)
+
+	rec, err := sn.routeGroups.pop(desc, reqPK)
 	if err != nil {
-		sn.logger.WithError(err).Warnf("Failed to reserve route IDs.")
-		return nil, err
+		return err
 	}
 
-	sn.logger.Infof("Successfully reserved route IDs.")
+	log.Infof("Route group was set up with trace ID %s.", rec.traceID)
+
+	errCh := make(chan error, len(rec.rules))
+
+	for pk, rules := range rec.rules {
+		go func(pk cipher.PubKey, rules []routing.Rule) {
+			_, err := routerclient.DeleteStoredRules(ctx, sn.logger, sn.dmsgC, pk, traceID, rules)
+			if err != nil {
+				log.WithField("remote", pk).WithError(err).Warn("Failed to delete rules.")
+				err = fmt.Errorf("delete rules from %s failed: %v", pk, err)
+			}
+			errCh <- err
+		}(pk, rules)
+	}
 
-	return reservoir, err
+	return finalError(len(rec.rules), errCh)
 }
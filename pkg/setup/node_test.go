@@ -53,6 +53,18 @@ type clientWithDMSGAddrAndListener struct {
 	Listener                 *dmsg.Listener
 	AppliedIntermediaryRules []routing.Rule
 	AppliedEdgeRules         routing.EdgeRules
+	DeletedRouteIDs          []routing.RouteID
+}
+
+func (c *clientWithDMSGAddrAndListener) storedRule(id routing.RouteID) (routing.Rule, bool) {
+	rules := append([]routing.Rule{c.AppliedEdgeRules.Forward, c.AppliedEdgeRules.Reverse}, c.AppliedIntermediaryRules...)
+	for _, rule := range rules {
+		if rule != nil && rule.KeyRouteID() == id {
+			return rule, true
+		}
+	}
+
+	return nil, false
 }
 
 func TestNode(t *testing.T) {
@@ -71,12 +83,24 @@ func TestNode(t *testing.T) {
 	t.Run("DialRouteGroup", func(t *testing.T) {
 		testDialRouteGroup(t, keys, nEnv, reservedIDs)
 	})
+
+	// TEST: Emulates a route setup which fails on the destination visor,
+	// after which the changes on all visors are rolled back.
+	t.Run("DialRouteGroup rollback", func(t *testing.T) {
+		testDialRouteGroupRollback(t, nEnv, reservedIDs)
+	})
+
+	// TEST: Emulates tearing down a route group, after which the rules are deleted on all visors
+	// which store them.
+	t.Run("DeleteRouteGroup", func(t *testing.T) {
+		testDeleteRouteGroup(t, nEnv, reservedIDs)
+	})
 }
 
 func testDialRouteGroup(t *testing.T, keys []snettest.KeyPair, nEnv *snettest.Env, reservedIDs []routing.RouteID) {
 	// client index 0 is for setup node.
 	// clients index 1 to 4 are for visors.
-	clients, closeClients := prepClients(t, keys, nEnv, reservedIDs, 5)
+	clients, closeClients := prepClients(t, keys, nEnv, reservedIDs, 5, nil)
 	defer closeClients()
 
 	// prepare and serve setup node (using client 0).
@@ -117,6 +141,95 @@ func testDialRouteGroup(t *testing.T, keys []snettest.KeyPair, nEnv *snettest.En
 	}
 
 	require.Equal(t, respRouteRules, clients[4].AppliedEdgeRules)
+
+	for _, cl := range clients[1:] {
+		require.Empty(t, cl.DeletedRouteIDs)
+	}
+}
+
+func testDialRouteGroupRollback(t *testing.T, nEnv *snettest.Env, reservedIDs []routing.RouteID) {
+	clients, closeClients := prepClients(t, randKeyPairs(5), nEnv, reservedIDs, 5, testhelpers.Err)
+	defer closeClients()
+
+	sn, closeSetup := prepSetupNode(t, clients[0].Client, clients[0].Listener)
+	defer closeSetup()
+
+	waitReachable(t, clients[0].Client, clients[1:])
+
+	route := prepBidirectionalRoute(clients)
+
+	_, err := sn.handleDialRouteGroup(context.TODO(), "trace", route)
+	require.Error(t, err)
+
+	// Intermediary rules were installed before the failure, and are deleted along with all reserved IDs.
+	for _, cl := range clients[2:4] {
+		require.NotEmpty(t, cl.AppliedIntermediaryRules)
+	}
+	for _, cl := range clients[1:] {
+		require.Equal(t, reservedIDs, cl.DeletedRouteIDs)
+	}
+}
+
+func testDeleteRouteGroup(t *testing.T, nEnv *snettest.Env, reservedIDs []routing.RouteID) {
+	clients, closeClients := prepClients(t, randKeyPairs(5), nEnv, reservedIDs, 5, nil)
+	defer closeClients()
+
+	sn, closeSetup := prepSetupNode(t, clients[0].Client, clients[0].Listener)
+	defer closeSetup()
+
+	waitReachable(t, clients[0].Client, clients[1:])
+
+	route := prepBidirectionalRoute(clients)
+
+	_, err := sn.handleDialRouteGroup(context.TODO(), "dial", route)
+	require.NoError(t, err)
+
+	// Only edges may delete the route group.
+	err = sn.handleDeleteRouteGroup(context.TODO(), "delete", clients[2].Addr.PK, route.Desc)
+	require.Equal(t, ErrNotRouteGroupEdge, err)
+
+	// The destination visor describes the route group from its own perspective.
+	err = sn.handleDeleteRouteGroup(context.TODO(), "delete", clients[4].Addr.PK, route.Desc.Invert())
+	require.NoError(t, err)
+
+	// Rules of the initiating visor are not stored by its mock router, so they are not deleted.
+	require.Empty(t, clients[1].DeletedRouteIDs)
+	for _, cl := range clients[2:] {
+		require.ElementsMatch(t, reservedIDs, cl.DeletedRouteIDs)
+	}
+
+	err = sn.handleDeleteRouteGroup(context.TODO(), "delete", clients[1].Addr.PK, route.Desc)
+	require.Equal(t, ErrUnknownRouteGroup, err)
+}
+
+// randKeyPairs generates key pairs which are not used by the network env,
+// so that dmsg streams reach the clients of the test.
+func randKeyPairs(n int) []snettest.KeyPair {
+	keys := make([]snettest.KeyPair, n)
+	for i := range keys {
+		keys[i].PK, keys[i].SK = cipher.GenerateKeyPair()
+	}
+
+	return keys
+}
+
+// waitReachable waits until the dmsg server forwards streams from 'c' to all 'clients'.
+func waitReachable(t *testing.T, c *dmsg.Client, clients []clientWithDMSGAddrAndListener) {
+	for _, cl := range clients {
+		addr := cl.Addr
+
+		require.Eventually(t, func() bool {
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			conn, err := c.Dial(ctx, addr)
+			if err != nil {
+				return false
+			}
+
+			return conn.Close() == nil
+		}, 10*time.Second, 100*time.Millisecond)
+	}
 }
 
 func prepBidirectionalRoute(clients []clientWithDMSGAddrAndListener) routing.BidirectionalRoute {
@@ -173,6 +286,7 @@ func prepClients(
 	nEnv *snettest.Env,
 	reservedIDs []routing.RouteID,
 	n int,
+	destErr error,
 ) ([]clientWithDMSGAddrAndListener, func()) {
 	clients := make([]clientWithDMSGAddrAndListener, n)
 
@@ -193,6 +307,7 @@ func prepClients(
 		c.SetLogger(clientLogger)
 
 		go c.Serve()
+		<-c.Ready()
 
 		listener, err := c.Listen(port)
 		require.NoError(t, err)
@@ -213,7 +328,7 @@ func prepClients(
 			continue
 		}
 
-		r := prepRouter(&clients[i], reservedIDs, i == n-1)
+		r := prepRouter(&clients[i], reservedIDs, i == n-1, destErr)
 
 		startRPC(t, r, listener)
 	}
@@ -225,7 +340,12 @@ func prepClients(
 	}
 }
 
-func prepRouter(client *clientWithDMSGAddrAndListener, reservedIDs []routing.RouteID, last bool) *router.MockRouter {
+func prepRouter(
+	client *clientWithDMSGAddrAndListener,
+	reservedIDs []routing.RouteID,
+	last bool,
+	destErr error,
+) *router.MockRouter {
 	r := &router.MockRouter{}
 	// passing two rules to each visor (forward and reverse routes). Simulate
 	// applying intermediary rules.
@@ -238,9 +358,30 @@ func prepRouter(client *clientWithDMSGAddrAndListener, reservedIDs []routing.Rou
 	// simulate reserving IDs.
 	r.On("ReserveKeys", 2).Return(reservedIDs, testhelpers.NoErr)
 
-	// destination visor. Simulate applying edge rules.
+	// simulate rolling back and deleting rules.
+	r.On("DelRules", mock.Anything).Return().Run(func(args mock.Arguments) {
+		client.DeletedRouteIDs = append(client.DeletedRouteIDs, args.Get(0).([]routing.RouteID)...)
+	})
+
+	r.On("Rule", mock.Anything).Return(
+		func(id routing.RouteID) routing.Rule {
+			rule, _ := client.storedRule(id)
+			return rule
+		},
+		func(id routing.RouteID) error {
+			if _, ok := client.storedRule(id); !ok {
+				return testhelpers.Err
+			}
+			return nil
+		},
+	)
+
+	// destination visor. Simulate applying edge rules, failing with 'destErr' if set.
 	if last {
 		r.On("IntroduceRules", mock.Anything).Return(func(rules routing.EdgeRules) error {
+			if destErr != nil {
+				return destErr
+			}
 			client.AppliedEdgeRules = rules
 			return nil
 		})
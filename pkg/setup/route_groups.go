@@ -0,0 +1,80 @@
+package setup
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// routeGroupTTL is the duration the setup node remembers the rules of a route group it set up.
+// Visors only delete rules which are still stored as is, so stale records are harmless.
+const routeGroupTTL = 24 * time.Hour
+
+var (
+	// ErrUnknownRouteGroup is returned when the setup node has no record of a route group.
+	ErrUnknownRouteGroup = errors.New("unknown route group")
+
+	// ErrNotRouteGroupEdge is returned when a route group is to be deleted by a visor which is not one of its edges.
+	ErrNotRouteGroupEdge = errors.New("requester is not an edge of the route group")
+)
+
+// routeGroupRecord is the record of the rules installed on visors for a route group.
+type routeGroupRecord struct {
+	traceID string
+	rules   map[cipher.PubKey][]routing.Rule
+	created time.Time
+}
+
+// routeGroupRecords keeps records of route groups, so that they can be torn down if abandoned.
+// Records are only kept by the setup node which set up the route group, so visors send their requests
+// to delete route groups to all of their setup nodes. The zero value is ready to use.
+type routeGroupRecords struct {
+	m  map[routing.RouteDescriptor]routeGroupRecord
+	mx sync.Mutex
+}
+
+func (rr *routeGroupRecords) add(desc routing.RouteDescriptor, rec routeGroupRecord) {
+	rr.mx.Lock()
+	defer rr.mx.Unlock()
+
+	if rr.m == nil {
+		rr.m = make(map[routing.RouteDescriptor]routeGroupRecord)
+	}
+
+	rr.prune(rec.created)
+	rr.m[desc] = rec
+}
+
+// pop removes and returns the record of the route group described by 'desc' from either edge.
+// The record is only removed if 'reqPK' is one of the edges.
+func (rr *routeGroupRecords) pop(desc routing.RouteDescriptor, reqPK cipher.PubKey) (routeGroupRecord, error) {
+	rr.mx.Lock()
+	defer rr.mx.Unlock()
+
+	rr.prune(time.Now())
+
+	if reqPK != desc.SrcPK() && reqPK != desc.DstPK() {
+		return routeGroupRecord{}, ErrNotRouteGroupEdge
+	}
+
+	for _, d := range []routing.RouteDescriptor{desc, desc.Invert()} {
+		if rec, ok := rr.m[d]; ok {
+			delete(rr.m, d)
+			return rec, nil
+		}
+	}
+
+	return routeGroupRecord{}, ErrUnknownRouteGroup
+}
+
+func (rr *routeGroupRecords) prune(now time.Time) {
+	for desc, rec := range rr.m {
+		if now.Sub(rec.created) > routeGroupTTL {
+			delete(rr.m, desc)
+		}
+	}
+}
@@ -3,20 +3,24 @@ package setup
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/google/uuid"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 )
 
 // RPCGateway is a RPC interface for setup node.
+// A gateway serves a single stream, and serves its calls one at a time.
 type RPCGateway struct {
 	logger  *logging.Logger
 	reqPK   cipher.PubKey
 	sn      *Node
 	timeout time.Duration
+	mx      sync.Mutex
 }
 
 // NewRPCGateway returns a new RPCGateway.
@@ -31,18 +35,26 @@ func NewRPCGateway(reqPK cipher.PubKey, sn *Node, timeout time.Duration) *RPCGat
 
 // DialRouteGroup dials RouteGroups for route and rules.
 func (g *RPCGateway) DialRouteGroup(route routing.BidirectionalRoute, rules *routing.EdgeRules) (err error) {
+	end, err := g.begin()
+	if err != nil {
+		return err
+	}
+	defer end()
+
 	startTime := time.Now()
 
 	defer func() {
 		g.sn.metrics.Record(time.Since(startTime), err != nil)
 	}()
 
-	g.logger.Infof("Received RPC DialRouteGroup request")
+	// The trace ID identifies the request in the logs of the setup node and of all visors of the route.
+	traceID := uuid.New().String()
+	g.logger.WithField("trace_id", traceID).Infof("Received RPC DialRouteGroup request")
 
 	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
 	defer cancel()
 
-	initRules, err := g.sn.handleDialRouteGroup(ctx, route)
+	initRules, err := g.sn.handleDialRouteGroup(ctx, traceID, route)
 	if err != nil {
 		return err
 	}
@@ -52,3 +64,55 @@ func (g *RPCGateway) DialRouteGroup(route routing.BidirectionalRoute, rules *rou
 
 	return nil
 }
+
+// DeleteRouteGroup removes the rules of the route group described by 'desc' from all of its visors.
+// Only edges of the route group may request this.
+func (g *RPCGateway) DeleteRouteGroup(desc routing.RouteDescriptor, ok *bool) error {
+	end, err := g.begin()
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	traceID := uuid.New().String()
+	g.logger.WithField("trace_id", traceID).Infof("Received RPC DeleteRouteGroup request")
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if err := g.sn.handleDeleteRouteGroup(ctx, traceID, g.reqPK, desc); err != nil {
+		*ok = false
+		return err
+	}
+
+	*ok = true
+
+	return nil
+}
+
+// begin waits for the previous call of the stream to end, and enforces the limits of concurrent calls
+// and the request rate of the requester.
+func (g *RPCGateway) begin() (end func(), err error) {
+	g.mx.Lock()
+
+	release, reason, err := g.sn.limiter.acquire(g.reqPK)
+	if err == nil {
+		if reason, err = g.sn.limiter.allow(g.reqPK); err != nil {
+			release()
+		}
+	}
+
+	if err != nil {
+		g.mx.Unlock()
+
+		g.logger.WithError(err).Warn("Rejected request.")
+		g.sn.metrics.RecordRejected(reason)
+
+		return nil, routing.Failure{Code: routing.FailureLimitExceeded, Msg: err.Error()}
+	}
+
+	return func() {
+		release()
+		g.mx.Unlock()
+	}, nil
+}
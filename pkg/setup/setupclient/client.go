@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/rpc"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
@@ -19,16 +20,25 @@ type Client struct {
 	log        *logging.Logger
 	n          *snet.Network
 	setupNodes []cipher.PubKey
+	pool       *Pool
+	setupPK    cipher.PubKey
 	conn       *snet.Conn
 	rpc        *rpc.Client
 }
 
 // NewClient creates a new Client.
 func NewClient(ctx context.Context, log *logging.Logger, n *snet.Network, setupNodes []cipher.PubKey) (*Client, error) {
+	return NewClientWithPool(ctx, log, n, setupNodes, NewPool())
+}
+
+// NewClientWithPool creates a new Client which selects a setup node, and records its health, within 'pool'.
+func NewClientWithPool(ctx context.Context, log *logging.Logger, n *snet.Network, setupNodes []cipher.PubKey,
+	pool *Pool) (*Client, error) {
 	client := &Client{
 		log:        log,
 		n:          n,
 		setupNodes: setupNodes,
+		pool:       pool,
 	}
 
 	conn, err := client.dial(ctx)
@@ -43,20 +53,111 @@ func NewClient(ctx context.Context, log *logging.Logger, n *snet.Network, setupN
 	return client, nil
 }
 
+type dialResult struct {
+	pk   cipher.PubKey
+	conn *snet.Conn
+	err  error
+}
+
+// dial dials setup nodes in the order of the pool, "happy eyeballs" style: if a dial does not complete
+// within happyEyeballsDelay, or fails, the next setup node is dialed in parallel.
+// The first established connection is used, and the others are closed.
 func (c *Client) dial(ctx context.Context) (*snet.Conn, error) {
-	for _, sPK := range c.setupNodes {
-		conn, err := c.n.Dial(ctx, snet.DmsgType, sPK, snet.SetupPort)
-		if err != nil {
-			c.log.WithError(err).Warnf("failed to dial to setup node: setupPK(%s)", sPK)
-			continue
+	candidates := c.pool.order(c.setupNodes)
+	if len(candidates) == 0 {
+		return nil, errors.New("no setup nodes configured")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(candidates))
+
+	dialNext := func() {
+		pk := candidates[0]
+		candidates = candidates[1:]
+
+		go func() {
+			conn, err := c.dialNode(ctx, pk)
+			results <- dialResult{pk: pk, conn: conn, err: err}
+		}()
+	}
+
+	dialNext()
+
+	delay := time.NewTimer(happyEyeballsDelay)
+	defer delay.Stop()
+
+	for pending := 1; pending > 0; {
+		select {
+		case <-delay.C:
+			if len(candidates) > 0 {
+				dialNext()
+				pending++
+				delay.Reset(happyEyeballsDelay)
+			}
+
+		case res := <-results:
+			pending--
+
+			if res.err == nil {
+				c.setupPK = res.pk
+				go closeLateConns(c.log, results, pending)
+
+				return res.conn, nil
+			}
+
+			c.log.WithError(res.err).Warnf("failed to dial to setup node: setupPK(%s)", res.pk)
+
+			if len(candidates) > 0 {
+				dialNext()
+				pending++
+			}
 		}
+	}
 
-		return conn, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	return nil, errors.New("failed to dial to a setup node")
 }
 
+func (c *Client) dialNode(ctx context.Context, pk cipher.PubKey) (*snet.Conn, error) {
+	c.pool.begin(pk)
+	defer c.pool.end(pk)
+
+	start := time.Now()
+
+	conn, err := c.n.Dial(ctx, snet.DmsgType, pk, snet.SetupPort)
+	if err != nil {
+		// Dials canceled in favor of another setup node do not tell anything about this one.
+		if ctx.Err() == nil {
+			c.pool.failure(pk, time.Since(start), err)
+		}
+
+		return nil, err
+	}
+
+	c.pool.success(pk, time.Since(start))
+
+	return conn, nil
+}
+
+// closeLateConns closes connections which were established after another one had been chosen.
+func closeLateConns(log *logging.Logger, results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		res := <-results
+		if res.err != nil {
+			continue
+		}
+
+		if err := res.conn.Close(); err != nil {
+			log.WithError(err).Warnf("failed to close connection to setup node: setupPK(%s)", res.pk)
+		}
+	}
+}
+
 // Close closes a Client.
 func (c *Client) Close() error {
 	if c == nil {
@@ -77,11 +178,39 @@ func (c *Client) Close() error {
 // DialRouteGroup generates rules for routes from a visor and sends them to visors.
 func (c *Client) DialRouteGroup(ctx context.Context, req routing.BidirectionalRoute) (routing.EdgeRules, error) {
 	var resp routing.EdgeRules
-	err := c.call(ctx, rpcName+".DialRouteGroup", req, &resp)
+	err := c.observe(ctx, rpcName+".DialRouteGroup", req, &resp)
 
 	return resp, err
 }
 
+// DeleteRouteGroup requests the setup node to remove the rules of an abandoned route group
+// from all of its visors. The route group is described from the perspective of either edge.
+func (c *Client) DeleteRouteGroup(ctx context.Context, desc routing.RouteDescriptor) error {
+	var ok bool
+	return c.observe(ctx, rpcName+".DeleteRouteGroup", desc, &ok)
+}
+
+// observe performs the call, and records its outcome in the pool.
+// Errors returned by the setup node itself (such as a failed route setup) mean that the setup node is alive.
+func (c *Client) observe(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	c.pool.begin(c.setupPK)
+	defer c.pool.end(c.setupPK)
+
+	start := time.Now()
+	err := c.call(ctx, serviceMethod, args, reply)
+
+	var srvErr rpc.ServerError
+
+	switch {
+	case err == nil || errors.As(err, &srvErr):
+		c.pool.success(c.setupPK, time.Since(start))
+	case ctx.Err() == nil:
+		c.pool.failure(c.setupPK, time.Since(start), err)
+	}
+
+	return err
+}
+
 func (c *Client) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
 	call := c.rpc.Go(serviceMethod, args, reply, nil)
 
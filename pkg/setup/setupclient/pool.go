@@ -0,0 +1,219 @@
+package setupclient
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// Constants associated with selecting setup nodes.
+const (
+	happyEyeballsDelay = 300 * time.Millisecond // delay before dialing the next setup node in parallel
+	circuitThreshold   = 3                      // consecutive failures which open the circuit of a setup node
+	circuitInitBO      = 5 * time.Second
+	circuitMaxBO       = 2 * time.Minute
+	latencyWeight      = 0.3 // weight of the latest sample in the moving average of latency
+)
+
+// States of a setup node.
+const (
+	SetupNodeUnknown     = "unknown"      // not used yet
+	SetupNodeUp          = "up"           // the last request succeeded
+	SetupNodeFailing     = "failing"      // the last request failed
+	SetupNodeCircuitOpen = "circuit_open" // failed repeatedly, only used if no other setup node is available
+)
+
+// NodeStatus describes the health of a setup node as observed by the visor.
+type NodeStatus struct {
+	PK            cipher.PubKey `json:"pk"`
+	State         string        `json:"state"`
+	Latency       time.Duration `json:"latency"` // moving average of dial and request latencies
+	InFlight      int           `json:"in_flight"`
+	Requests      uint64        `json:"requests"`
+	Failures      int           `json:"failures"` // consecutive failures
+	TotalFailures uint64        `json:"total_failures"`
+	LastError     string        `json:"last_error,omitempty"`
+	RetryAt       *time.Time    `json:"retry_at,omitempty"` // when the circuit is half-open again, set while it is open
+}
+
+type nodeStats struct {
+	NodeStatus
+	bo time.Duration // next duration of an open circuit
+}
+
+// Pool keeps track of the health of setup nodes, and orders them for selection.
+// Setup nodes are preferred by their latency, weighted by the number of requests in flight, so that load is spread.
+// A setup node which fails repeatedly has its circuit opened, and is only used if no other one is available,
+// until its back-off passes.
+type Pool struct {
+	nodes map[cipher.PubKey]*nodeStats
+	now   func() time.Time
+	mx    sync.Mutex
+}
+
+// NewPool creates a new Pool.
+func NewPool() *Pool {
+	return &Pool{
+		nodes: make(map[cipher.PubKey]*nodeStats),
+		now:   time.Now,
+	}
+}
+
+// Status returns the status of the given setup nodes.
+func (p *Pool) Status(pks []cipher.PubKey) []NodeStatus {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	now := p.now()
+	out := make([]NodeStatus, 0, len(pks))
+
+	for _, pk := range pks {
+		s := p.stats(pk)
+		status := s.NodeStatus
+
+		if status.State == SetupNodeCircuitOpen && !now.Before(*status.RetryAt) {
+			status.State, status.RetryAt = SetupNodeFailing, nil
+		}
+
+		out = append(out, status)
+	}
+
+	return out
+}
+
+// Available returns whether any of the given setup nodes is not circuit broken.
+func (p *Pool) Available(pks []cipher.PubKey) bool {
+	for _, s := range p.Status(pks) {
+		if s.State != SetupNodeCircuitOpen {
+			return true
+		}
+	}
+
+	return false
+}
+
+// order returns the setup nodes in the order they are to be tried.
+func (p *Pool) order(pks []cipher.PubKey) []cipher.PubKey {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	now := p.now()
+
+	type candidate struct {
+		pk     cipher.PubKey
+		broken bool
+		score  float64
+	}
+
+	candidates := make([]candidate, 0, len(pks))
+	seen := make(map[cipher.PubKey]struct{}, len(pks))
+
+	for _, pk := range pks {
+		if _, ok := seen[pk]; ok {
+			continue
+		}
+		seen[pk] = struct{}{}
+
+		s := p.stats(pk)
+		candidates = append(candidates, candidate{
+			pk:     pk,
+			broken: s.State == SetupNodeCircuitOpen && now.Before(*s.RetryAt),
+			score:  float64(s.Latency) * float64(1+s.InFlight),
+		})
+	}
+
+	// Shuffled first, so that setup nodes with equal scores share the load.
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].broken != candidates[j].broken {
+			return !candidates[i].broken
+		}
+		return candidates[i].score < candidates[j].score
+	})
+
+	out := make([]cipher.PubKey, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.pk
+	}
+
+	return out
+}
+
+// begin records the start of a request to 'pk'. It is to be followed by a call of 'end'.
+func (p *Pool) begin(pk cipher.PubKey) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	s := p.stats(pk)
+	s.InFlight++
+	s.Requests++
+}
+
+func (p *Pool) end(pk cipher.PubKey) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if s := p.stats(pk); s.InFlight > 0 {
+		s.InFlight--
+	}
+}
+
+// success records a successful dial or request to 'pk' which took 'latency'.
+func (p *Pool) success(pk cipher.PubKey, latency time.Duration) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	s := p.stats(pk)
+	s.State, s.Failures, s.LastError, s.RetryAt = SetupNodeUp, 0, "", nil
+	s.bo = circuitInitBO
+	p.observeLatency(s, latency)
+}
+
+// failure records a failed dial or request to 'pk' which took 'latency'.
+func (p *Pool) failure(pk cipher.PubKey, latency time.Duration, err error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	s := p.stats(pk)
+	s.Failures++
+	s.TotalFailures++
+	s.LastError = err.Error()
+	p.observeLatency(s, latency)
+
+	if s.Failures < circuitThreshold {
+		s.State = SetupNodeFailing
+		return
+	}
+
+	retryAt := p.now().Add(s.bo)
+	s.State, s.RetryAt = SetupNodeCircuitOpen, &retryAt
+	if s.bo *= 2; s.bo > circuitMaxBO {
+		s.bo = circuitMaxBO
+	}
+}
+
+func (p *Pool) observeLatency(s *nodeStats, latency time.Duration) {
+	if s.Latency == 0 {
+		s.Latency = latency
+		return
+	}
+
+	s.Latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(s.Latency))
+}
+
+func (p *Pool) stats(pk cipher.PubKey) *nodeStats {
+	s, ok := p.nodes[pk]
+	if !ok {
+		s = &nodeStats{
+			NodeStatus: NodeStatus{PK: pk, State: SetupNodeUnknown},
+			bo:         circuitInitBO,
+		}
+		p.nodes[pk] = s
+	}
+
+	return s
+}
@@ -0,0 +1,94 @@
+package setupclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+)
+
+func TestPool_order(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	pk3, _ := cipher.GenerateKeyPair()
+	pks := []cipher.PubKey{pk1, pk2, pk3}
+
+	p := NewPool()
+
+	// Unknown setup nodes are tried first, and duplicates are dropped.
+	p.success(pk1, 10*time.Millisecond)
+	p.success(pk2, 20*time.Millisecond)
+	require.Equal(t, []cipher.PubKey{pk3, pk1, pk2}, p.order(append(pks, pk1)))
+
+	// Faster setup nodes are preferred.
+	p.success(pk3, 30*time.Millisecond)
+	require.Equal(t, pks, p.order(pks))
+
+	// Load is spread.
+	p.begin(pk1)
+	p.begin(pk1)
+	p.begin(pk1)
+	require.Equal(t, []cipher.PubKey{pk2, pk3, pk1}, p.order(pks))
+	p.end(pk1)
+	p.end(pk1)
+	p.end(pk1)
+	require.Equal(t, pks, p.order(pks))
+}
+
+func TestPool_circuitBreaker(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	pks := []cipher.PubKey{pk1, pk2}
+
+	now := time.Now()
+
+	p := NewPool()
+	p.now = func() time.Time { return now }
+
+	p.success(pk1, time.Millisecond)
+	p.success(pk2, time.Second)
+
+	for i := 0; i < circuitThreshold-1; i++ {
+		p.failure(pk1, time.Millisecond, testhelpers.Err)
+	}
+
+	require.Equal(t, SetupNodeFailing, p.Status(pks)[0].State)
+	require.Equal(t, pks, p.order(pks))
+
+	// The circuit opens once the threshold is reached.
+	p.failure(pk1, time.Millisecond, testhelpers.Err)
+
+	status := p.Status(pks)[0]
+	require.Equal(t, SetupNodeCircuitOpen, status.State)
+	require.Equal(t, testhelpers.Err.Error(), status.LastError)
+	require.Equal(t, now.Add(circuitInitBO), *status.RetryAt)
+	require.Equal(t, []cipher.PubKey{pk2, pk1}, p.order(pks))
+	require.True(t, p.Available(pks))
+
+	p.failure(pk2, time.Second, testhelpers.Err)
+	p.failure(pk2, time.Second, testhelpers.Err)
+	p.failure(pk2, time.Second, testhelpers.Err)
+	require.False(t, p.Available(pks))
+
+	// The circuit is half-open after the back-off, and a failure opens it for longer.
+	now = now.Add(circuitInitBO)
+	require.Equal(t, SetupNodeFailing, p.Status(pks)[0].State)
+	require.Nil(t, p.Status(pks)[0].RetryAt)
+	require.True(t, p.Available(pks))
+
+	p.failure(pk1, time.Millisecond, testhelpers.Err)
+	require.Equal(t, now.Add(2*circuitInitBO), *p.Status(pks)[0].RetryAt)
+
+	// A success closes the circuit.
+	p.success(pk1, time.Millisecond)
+
+	status = p.Status(pks)[0]
+	require.Equal(t, SetupNodeUp, status.State)
+	require.Nil(t, status.RetryAt)
+	require.Zero(t, status.Failures)
+	require.Equal(t, uint64(4), status.TotalFailures)
+	require.Equal(t, []cipher.PubKey{pk1, pk2}, p.order(pks))
+}
@@ -2,6 +2,7 @@ package setupclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -23,13 +24,30 @@ type RouteGroupDialer interface {
 		setupNodes []cipher.PubKey,
 		req routing.BidirectionalRoute,
 	) (routing.EdgeRules, error)
+
+	// Delete asks setup nodes to remove the rules of the route group described by 'desc' from all of its visors.
+	Delete(
+		ctx context.Context,
+		log *logging.Logger,
+		n *snet.Network,
+		setupNodes []cipher.PubKey,
+		desc routing.RouteDescriptor,
+	) error
 }
 
-type setupNodeDialer struct{}
+type setupNodeDialer struct {
+	pool *Pool
+}
 
 // NewSetupNodeDialer returns a wrapper for (*Client).DialRouteGroup.
 func NewSetupNodeDialer() RouteGroupDialer {
-	return new(setupNodeDialer)
+	return NewSetupNodeDialerWithPool(NewPool())
+}
+
+// NewSetupNodeDialerWithPool returns a wrapper for (*Client).DialRouteGroup,
+// which selects setup nodes by their health recorded in 'pool'.
+func NewSetupNodeDialerWithPool(pool *Pool) RouteGroupDialer {
+	return &setupNodeDialer{pool: pool}
 }
 
 // Dial dials RouteGroup.
@@ -40,7 +58,7 @@ func (d *setupNodeDialer) Dial(
 	setupNodes []cipher.PubKey,
 	req routing.BidirectionalRoute,
 ) (routing.EdgeRules, error) {
-	client, err := NewClient(ctx, log, n, setupNodes)
+	client, err := NewClientWithPool(ctx, log, n, setupNodes, d.pool)
 	if err != nil {
 		return routing.EdgeRules{}, err
 	}
@@ -59,6 +77,64 @@ func (d *setupNodeDialer) Dial(
 	return resp, nil
 }
 
+// Delete deletes RouteGroup.
+// Only the setup node which set up the route group keeps its record, and it may be any of 'setupNodes',
+// so all of them are asked. It succeeds if any of them deletes the route group.
+func (d *setupNodeDialer) Delete(
+	ctx context.Context,
+	log *logging.Logger,
+	n *snet.Network,
+	setupNodes []cipher.PubKey,
+	desc routing.RouteDescriptor,
+) error {
+	if len(setupNodes) == 0 {
+		return errors.New("no setup nodes configured")
+	}
+
+	errCh := make(chan error, len(setupNodes))
+
+	for _, pk := range setupNodes {
+		go func(pk cipher.PubKey) {
+			errCh <- d.deleteWith(ctx, log, n, pk, desc)
+		}(pk)
+	}
+
+	var err error
+
+	for range setupNodes {
+		if err = <-errCh; err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (d *setupNodeDialer) deleteWith(
+	ctx context.Context,
+	log *logging.Logger,
+	n *snet.Network,
+	setupPK cipher.PubKey,
+	desc routing.RouteDescriptor,
+) error {
+	client, err := NewClientWithPool(ctx, log, n, []cipher.PubKey{setupPK}, d.pool)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := client.Close(); err != nil {
+			log.Warn(err)
+		}
+	}()
+
+	if err := client.DeleteRouteGroup(ctx, desc); err != nil {
+		return fmt.Errorf("delete route group via %s: %v", setupPK, err)
+	}
+
+	return nil
+}
+
 type mockDialer struct{}
 
 // NewMockDialer returns a mock for (*Client).DialRouteGroup.
@@ -94,3 +170,14 @@ func (d *mockDialer) Dial(
 
 	return rules, nil
 }
+
+// Delete deletes RouteGroup.
+func (d *mockDialer) Delete(
+	context.Context,
+	*logging.Logger,
+	*snet.Network,
+	[]cipher.PubKey,
+	routing.RouteDescriptor,
+) error {
+	return nil
+}
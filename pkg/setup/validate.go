@@ -0,0 +1,147 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/httputil"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+// validateRoute checks that the paths of 'route' connect its edges, and that every hop is over a transport
+// which is registered in transport discovery between the hop's visors, and is up.
+// Transports are not checked if the setup node has no transport discovery configured.
+func (sn *Node) validateRoute(ctx context.Context, route routing.BidirectionalRoute) error {
+	forward, reverse := route.ForwardAndReverse()
+
+	for _, r := range []routing.Route{forward, reverse} {
+		if err := validatePath(r.Desc, r.Path); err != nil {
+			return invalidPath(err)
+		}
+	}
+
+	tpDisc, err := sn.transportDiscovery()
+	if err != nil {
+		return routing.Failure{
+			Code: routing.FailureCheckPath,
+			Msg:  fmt.Sprintf("failed to create transport discovery client: %v", err),
+		}
+	}
+
+	if tpDisc == nil {
+		return nil
+	}
+
+	// Transports are bidirectional, so the same transport is usually used by both paths.
+	checked := make(map[uuid.UUID]struct{})
+
+	for _, path := range []routing.Path{route.Forward, route.Reverse} {
+		for _, hop := range path {
+			if _, ok := checked[hop.TpID]; ok {
+				continue
+			}
+
+			if err := validateHop(ctx, tpDisc, hop); err != nil {
+				return err
+			}
+
+			checked[hop.TpID] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// validatePath checks that 'path' leads from the source to the destination of 'desc'.
+func validatePath(desc routing.RouteDescriptor, path routing.Path) error {
+	if len(path) == 0 {
+		return errors.New("empty path")
+	}
+
+	if path[0].From != desc.SrcPK() {
+		return fmt.Errorf("path starts at %s instead of %s", path[0].From, desc.SrcPK())
+	}
+
+	for i, hop := range path {
+		if hop.From == hop.To {
+			return fmt.Errorf("hop %d loops back to %s", i, hop.From)
+		}
+
+		if i > 0 && path[i-1].To != hop.From {
+			return fmt.Errorf("hop %d starts at %s instead of %s", i, hop.From, path[i-1].To)
+		}
+	}
+
+	if last := path[len(path)-1]; last.To != desc.DstPK() {
+		return fmt.Errorf("path ends at %s instead of %s", last.To, desc.DstPK())
+	}
+
+	return nil
+}
+
+// tpDiscRetryInterval is the minimum interval between attempts to create the transport discovery client.
+const tpDiscRetryInterval = 10 * time.Second
+
+// transportDiscovery returns the transport discovery client, creating it on first use.
+// It returns nil if transports of paths are not to be checked.
+func (sn *Node) transportDiscovery() (transport.DiscoveryClient, error) {
+	sn.tpDiscMx.Lock()
+	defer sn.tpDiscMx.Unlock()
+
+	if sn.tpDisc != nil || sn.newTpDisc == nil {
+		return sn.tpDisc, nil
+	}
+
+	if sn.tpDiscErr != nil && time.Now().Before(sn.tpDiscRetryAt) {
+		return nil, sn.tpDiscErr
+	}
+
+	tpDisc, err := sn.newTpDisc()
+	if err != nil {
+		sn.tpDiscErr, sn.tpDiscRetryAt = err, time.Now().Add(tpDiscRetryInterval)
+		return nil, err
+	}
+
+	sn.tpDisc, sn.tpDiscErr = tpDisc, nil
+
+	return tpDisc, nil
+}
+
+func validateHop(ctx context.Context, tpDisc transport.DiscoveryClient, hop routing.Hop) error {
+	entry, err := tpDisc.GetTransportByID(ctx, hop.TpID)
+	if err != nil {
+		var httpErr *httputil.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			return invalidPath(fmt.Errorf("transport %s is not registered", hop.TpID))
+		}
+
+		return routing.Failure{
+			Code: routing.FailureCheckPath,
+			Msg:  fmt.Sprintf("failed to obtain transport %s from transport discovery: %v", hop.TpID, err),
+		}
+	}
+
+	if !transportConnects(entry.Entry, hop) {
+		return invalidPath(fmt.Errorf("transport %s does not connect %s and %s", hop.TpID, hop.From, hop.To))
+	}
+
+	if !entry.IsUp {
+		return invalidPath(fmt.Errorf("transport %s is down", hop.TpID))
+	}
+
+	return nil
+}
+
+func transportConnects(entry *transport.Entry, hop routing.Hop) bool {
+	return entry != nil && entry.HasEdge(hop.From) && entry.HasEdge(hop.To)
+}
+
+func invalidPath(err error) error {
+	return routing.Failure{Code: routing.FailureInvalidPath, Msg: err.Error()}
+}
@@ -0,0 +1,156 @@
+package setup
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/httputil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/internal/testhelpers"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
+)
+
+type testTpDisc struct {
+	transport.DiscoveryClient
+	entries map[uuid.UUID]*transport.EntryWithStatus
+	err     error
+}
+
+func (td *testTpDisc) GetTransportByID(_ context.Context, id uuid.UUID) (*transport.EntryWithStatus, error) {
+	if td.err != nil {
+		return nil, td.err
+	}
+
+	entry, ok := td.entries[id]
+	if !ok {
+		return nil, &httputil.HTTPError{Status: http.StatusNotFound, Body: "transport not found"}
+	}
+
+	return entry, nil
+}
+
+func TestNode_validateRoute(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	pk3, _ := cipher.GenerateKeyPair()
+
+	tp12 := transport.NewEntry(pk1, pk2, "dmsg", true)
+	tp23 := transport.NewEntry(pk2, pk3, "dmsg", true)
+	tp13 := transport.NewEntry(pk1, pk3, "dmsg", true)
+
+	td := &testTpDisc{entries: map[uuid.UUID]*transport.EntryWithStatus{
+		tp12.ID: {Entry: tp12, IsUp: true},
+		tp23.ID: {Entry: tp23, IsUp: true},
+		tp13.ID: {Entry: tp13, IsUp: false},
+	}}
+
+	sn := &Node{tpDisc: td}
+
+	makeRoute := func(fwd, rev routing.Path) routing.BidirectionalRoute {
+		return routing.BidirectionalRoute{
+			Desc:      routing.NewRouteDescriptor(pk1, pk3, 1, 2),
+			KeepAlive: time.Hour,
+			Forward:   fwd,
+			Reverse:   rev,
+		}
+	}
+
+	validFwd := routing.Path{{From: pk1, To: pk2, TpID: tp12.ID}, {From: pk2, To: pk3, TpID: tp23.ID}}
+	validRev := routing.Path{{From: pk3, To: pk2, TpID: tp23.ID}, {From: pk2, To: pk1, TpID: tp12.ID}}
+
+	tests := []struct {
+		name  string
+		route routing.BidirectionalRoute
+		code  routing.FailureCode
+	}{
+		{
+			name:  "empty path",
+			route: makeRoute(validFwd, nil),
+			code:  routing.FailureInvalidPath,
+		},
+		{
+			name:  "disconnected hops",
+			route: makeRoute(routing.Path{{From: pk1, To: pk2, TpID: tp12.ID}, {From: pk1, To: pk3, TpID: tp13.ID}}, validRev),
+			code:  routing.FailureInvalidPath,
+		},
+		{
+			name:  "wrong destination",
+			route: makeRoute(routing.Path{{From: pk1, To: pk2, TpID: tp12.ID}}, validRev),
+			code:  routing.FailureInvalidPath,
+		},
+		{
+			name:  "unknown transport",
+			route: makeRoute(routing.Path{{From: pk1, To: pk2, TpID: uuid.New()}, {From: pk2, To: pk3, TpID: tp23.ID}}, validRev),
+			code:  routing.FailureInvalidPath,
+		},
+		{
+			name:  "transport between other visors",
+			route: makeRoute(routing.Path{{From: pk1, To: pk2, TpID: tp23.ID}, {From: pk2, To: pk3, TpID: tp23.ID}}, validRev),
+			code:  routing.FailureInvalidPath,
+		},
+		{
+			name:  "transport down",
+			route: makeRoute(routing.Path{{From: pk1, To: pk3, TpID: tp13.ID}}, validRev),
+			code:  routing.FailureInvalidPath,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sn.validateRoute(context.TODO(), tc.route)
+			require.Error(t, err)
+
+			failure, ok := err.(routing.Failure)
+			require.True(t, ok)
+			require.Equal(t, tc.code, failure.Code)
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev)))
+	})
+
+	t.Run("transport discovery unavailable", func(t *testing.T) {
+		sn := &Node{tpDisc: &testTpDisc{err: testhelpers.Err}}
+
+		err := sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev))
+		require.Equal(t, routing.FailureCheckPath, err.(routing.Failure).Code)
+	})
+
+	t.Run("transport discovery client is created on first use", func(t *testing.T) {
+		calls := 0
+		sn := &Node{newTpDisc: func() (transport.DiscoveryClient, error) {
+			calls++
+			if calls == 1 {
+				return nil, testhelpers.Err
+			}
+			return td, nil
+		}}
+
+		err := sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev))
+		require.Equal(t, routing.FailureCheckPath, err.(routing.Failure).Code)
+
+		// A failed attempt is not retried right away.
+		err = sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev))
+		require.Equal(t, routing.FailureCheckPath, err.(routing.Failure).Code)
+		require.Equal(t, 1, calls)
+
+		sn.tpDiscRetryAt = time.Time{}
+		require.NoError(t, sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev)))
+		require.NoError(t, sn.validateRoute(context.TODO(), makeRoute(validFwd, validRev)))
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("no transport discovery", func(t *testing.T) {
+		sn := &Node{}
+
+		fwd := routing.Path{{From: pk1, To: pk3, TpID: uuid.New()}}
+		require.NoError(t, sn.validateRoute(context.TODO(), makeRoute(fwd, validRev)))
+	})
+}
@@ -49,6 +49,9 @@ const (
 	SkysocksClientName = "skysocks-client"
 	SkysocksClientPort = uint16(13)
 	SkysocksClientAddr = ":1080"
+
+	SkyforwardName = "skyforward"
+	SkyforwardPort = uint16(5)
 )
 
 // MustPK unmarshals string PK to cipher.PubKey. It panics if unmarshaling fails.
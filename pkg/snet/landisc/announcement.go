@@ -0,0 +1,73 @@
+package landisc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+)
+
+// Announcement is broadcast by a visor to advertise it's stcp address to the local network.
+type Announcement struct {
+	PK        cipher.PubKey `json:"pk"`
+	Addr      string        `json:"addr"`
+	Timestamp int64         `json:"ts"` // unix nanoseconds
+	Sig       cipher.Sig    `json:"sig"`
+}
+
+// NewAnnouncement creates a signed Announcement.
+func NewAnnouncement(pk cipher.PubKey, sk cipher.SecKey, addr string, ts time.Time) (Announcement, error) {
+	a := Announcement{PK: pk, Addr: addr, Timestamp: ts.UnixNano()}
+	if err := a.Sign(sk); err != nil {
+		return Announcement{}, err
+	}
+	return a, nil
+}
+
+// Sign signs the Announcement.
+func (a *Announcement) Sign(sk cipher.SecKey) error {
+	a.Sig = cipher.Sig{}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	sig, err := cipher.SignPayload(b, sk)
+	if err != nil {
+		return err
+	}
+	a.Sig = sig
+	return nil
+}
+
+// Verify verifies the signature of the Announcement, and that it is not older than 'ttl'.
+func (a Announcement) Verify(now time.Time, ttl time.Duration) error {
+	if age := now.Sub(time.Unix(0, a.Timestamp)); age > ttl || age < -ttl {
+		return errors.New("announcement timestamp is out of range")
+	}
+
+	sig := a.Sig
+	a.Sig = cipher.Sig{}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return cipher.VerifyPubKeySignedPayload(a.PK, sig, b)
+}
+
+func encodeAnnouncement(a Announcement) ([]byte, error) {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(a); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func decodeAnnouncement(b []byte) (Announcement, error) {
+	var a Announcement
+	err := json.Unmarshal(b, &a)
+	return a, err
+}
@@ -0,0 +1,125 @@
+package landisc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+func TestAnnouncement_Verify(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	now := time.Now()
+
+	a, err := NewAnnouncement(pk, sk, "192.168.1.2:7777", now)
+	require.NoError(t, err)
+	require.NoError(t, a.Verify(now, time.Minute))
+
+	t.Run("stale", func(t *testing.T) {
+		assert.Error(t, a.Verify(now.Add(2*time.Minute), time.Minute))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		b := a
+		b.Addr = "192.168.1.3:7777"
+		assert.Error(t, b.Verify(now, time.Minute))
+	})
+
+	t.Run("wrong_key", func(t *testing.T) {
+		b := a
+		b.PK, _ = cipher.GenerateKeyPair()
+		assert.Error(t, b.Verify(now, time.Minute))
+	})
+}
+
+func TestTable(t *testing.T) {
+	staticPK, _ := cipher.GenerateKeyPair()
+	pk, sk := cipher.GenerateKeyPair()
+
+	tab := NewTable(stcp.NewTable(map[cipher.PubKey]string{staticPK: "10.0.0.1:7777"}))
+
+	a1, err := NewAnnouncement(pk, sk, "10.0.0.2:7777", time.Now())
+	require.NoError(t, err)
+	assert.True(t, tab.Put(a1, a1.Addr, time.Now().Add(time.Minute)))
+	assert.False(t, tab.Put(a1, a1.Addr, time.Now().Add(time.Minute)), "replayed announcement")
+
+	addr, ok := tab.Addr(pk)
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.2:7777", addr)
+
+	gotPK, ok := tab.PubKey("10.0.0.2:7777")
+	assert.True(t, ok)
+	assert.Equal(t, pk, gotPK)
+
+	assert.Equal(t, 2, tab.Count())
+
+	// Static entries take precedence.
+	sa, err := NewAnnouncement(staticPK, sk, "10.0.0.3:7777", time.Now())
+	require.NoError(t, err)
+	tab.Put(sa, sa.Addr, time.Now().Add(time.Minute))
+	addr, ok = tab.Addr(staticPK)
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1:7777", addr)
+	assert.Equal(t, 2, tab.Count())
+
+	// Expired entries are not returned.
+	a2, err := NewAnnouncement(pk, sk, "10.0.0.2:7777", time.Now().Add(time.Second))
+	require.NoError(t, err)
+	tab.Put(a2, a2.Addr, time.Now().Add(-time.Second))
+	_, ok = tab.Addr(pk)
+	assert.False(t, ok)
+
+	tab.RemoveExpired()
+	assert.Len(t, tab.Discovered(), 0)
+}
+
+func TestService(t *testing.T) {
+	type visor struct {
+		pk  cipher.PubKey
+		sk  cipher.SecKey
+		pc  net.PacketConn
+		srv *Service
+	}
+
+	conf := Config{Interval: 50 * time.Millisecond, TTL: time.Second}
+	visors := make([]visor, 2)
+	for i := range visors {
+		pk, sk := cipher.GenerateKeyPair()
+		pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		require.NoError(t, err)
+		visors[i] = visor{pk: pk, sk: sk, pc: pc, srv: NewService(pk, sk, NewTable(nil), conf)}
+	}
+
+	// Each visor announces to the other.
+	require.NoError(t, visors[0].srv.ServeConn(visors[0].pc, visors[1].pc.LocalAddr(), ":7777"))
+	require.NoError(t, visors[1].srv.ServeConn(visors[1].pc, visors[0].pc.LocalAddr(), "10.0.0.2:7777"))
+	defer func() {
+		for _, v := range visors {
+			assert.NoError(t, v.srv.Close())
+		}
+	}()
+
+	for i, v := range visors {
+		other := visors[1-i]
+		select {
+		case pk := <-v.srv.Discovered():
+			assert.Equal(t, other.pk, pk)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("visor %d did not discover visor %d", i, 1-i)
+		}
+	}
+
+	// Unspecified hosts are replaced with the source host.
+	addr, ok := visors[1].srv.Table().Addr(visors[0].pk)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:7777", addr)
+
+	addr, ok = visors[0].srv.Table().Addr(visors[1].pk)
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.2:7777", addr)
+}
@@ -0,0 +1,255 @@
+// Package landisc implements discovery of stcp addresses of visors within the local network.
+package landisc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+)
+
+// Defaults.
+const (
+	DefaultAddress  = "239.255.77.77:7778" // multicast group
+	DefaultInterval = 5 * time.Second
+	DefaultTTL      = 30 * time.Second
+
+	maxAnnouncementSize = 1024
+	discoveredBuffer    = 64
+)
+
+// Config configures a Service.
+type Config struct {
+	Address  string        // UDP address which announcements are sent to and received on (multicast or broadcast).
+	Interval time.Duration // Interval between announcements.
+	TTL      time.Duration // Time after which a discovered entry expires.
+}
+
+// DefaultConfig returns the default Config.
+func DefaultConfig() Config {
+	return Config{
+		Address:  DefaultAddress,
+		Interval: DefaultInterval,
+		TTL:      DefaultTTL,
+	}
+}
+
+// Service periodically announces the local stcp address, and records the addresses announced by other visors.
+type Service struct {
+	log  *logging.Logger
+	conf Config
+	pk   cipher.PubKey
+	sk   cipher.SecKey
+	t    *Table
+
+	discovered chan cipher.PubKey
+
+	done chan struct{}
+	once sync.Once
+	mx   sync.Mutex
+	pc   net.PacketConn
+}
+
+// NewService creates a new Service which records discovered entries into 't'.
+func NewService(pk cipher.PubKey, sk cipher.SecKey, t *Table, conf Config) *Service {
+	def := DefaultConfig()
+	if conf.Address == "" {
+		conf.Address = def.Address
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = def.Interval
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = def.TTL
+	}
+
+	return &Service{
+		log:        logging.MustGetLogger("landisc"),
+		conf:       conf,
+		pk:         pk,
+		sk:         sk,
+		t:          t,
+		discovered: make(chan cipher.PubKey, discoveredBuffer),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetLogger sets a logger for Service.
+func (s *Service) SetLogger(log *logging.Logger) {
+	s.log = log
+}
+
+// Table returns the table which discovered entries are recorded into.
+func (s *Service) Table() *Table {
+	return s.t
+}
+
+// Discovered returns a channel which receives public keys of newly discovered visors.
+// A nil channel is returned for a nil Service.
+func (s *Service) Discovered() <-chan cipher.PubKey {
+	if s == nil {
+		return nil
+	}
+	return s.discovered
+}
+
+// Serve starts announcing 'tcpAddr' and listening for announcements of other visors.
+func (s *Service) Serve(tcpAddr string) error {
+	gAddr, err := net.ResolveUDPAddr("udp4", s.conf.Address)
+	if err != nil {
+		return err
+	}
+
+	var pc net.PacketConn
+	if gAddr.IP.IsMulticast() {
+		pc, err = net.ListenMulticastUDP("udp4", nil, gAddr)
+	} else {
+		pc, err = net.ListenUDP("udp4", &net.UDPAddr{Port: gAddr.Port})
+	}
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("announcing stcp addr %s to %s", tcpAddr, gAddr)
+	return s.ServeConn(pc, gAddr, tcpAddr)
+}
+
+// ServeConn is similar to Serve, but uses the provided packet connection.
+// Announcements are sent to 'dst'.
+func (s *Service) ServeConn(pc net.PacketConn, dst net.Addr, tcpAddr string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	select {
+	case <-s.done:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	if s.pc != nil {
+		return errors.New("already serving")
+	}
+	s.pc = pc
+
+	go s.announceLoop(pc, dst, tcpAddr)
+	go s.receiveLoop(pc)
+	return nil
+}
+
+func (s *Service) announceLoop(pc net.PacketConn, dst net.Addr, tcpAddr string) {
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.announce(pc, dst, tcpAddr); err != nil {
+			s.log.WithError(err).Warn("Failed to send announcement.")
+		}
+		s.t.RemoveExpired()
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) announce(pc net.PacketConn, dst net.Addr, tcpAddr string) error {
+	a, err := NewAnnouncement(s.pk, s.sk, tcpAddr, time.Now())
+	if err != nil {
+		return err
+	}
+	b, err := encodeAnnouncement(a)
+	if err != nil {
+		return err
+	}
+	_, err = pc.WriteTo(b, dst)
+	return err
+}
+
+func (s *Service) receiveLoop(pc net.PacketConn) {
+	buf := make([]byte, maxAnnouncementSize)
+	for {
+		n, src, err := pc.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				s.log.WithError(err).Warn("Stopped receiving announcements.")
+			}
+			return
+		}
+		if err := s.handleAnnouncement(buf[:n], src); err != nil {
+			s.log.WithError(err).WithField("src", src).Debug("Dropped announcement.")
+		}
+	}
+}
+
+func (s *Service) handleAnnouncement(b []byte, src net.Addr) error {
+	a, err := decodeAnnouncement(b)
+	if err != nil {
+		return err
+	}
+	if a.PK == s.pk {
+		return nil
+	}
+	now := time.Now()
+	if err := a.Verify(now, s.conf.TTL); err != nil {
+		return err
+	}
+	addr, err := resolveAddr(a.Addr, src)
+	if err != nil {
+		return err
+	}
+
+	if isNew := s.t.Put(a, addr, now.Add(s.conf.TTL)); !isNew {
+		return nil
+	}
+	s.log.Infof("discovered visor %s at %s", a.PK, addr)
+
+	select {
+	case s.discovered <- a.PK:
+	default:
+		s.log.Warnf("discovered channel is full, dropping %s", a.PK)
+	}
+	return nil
+}
+
+// resolveAddr replaces an unspecified host of an announced address with the host the announcement was sent from.
+func resolveAddr(addr string, src net.Addr) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+		return addr, nil
+	}
+	srcHost, _, err := net.SplitHostPort(src.String())
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(srcHost, port), nil
+}
+
+// Close stops the Service.
+func (s *Service) Close() error {
+	if s == nil {
+		return nil
+	}
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+
+		s.mx.Lock()
+		defer s.mx.Unlock()
+
+		if s.pc != nil {
+			err = s.pc.Close()
+		}
+	})
+	return err
+}
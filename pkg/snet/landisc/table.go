@@ -0,0 +1,119 @@
+package landisc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+type entry struct {
+	addr   string
+	ts     int64 // timestamp of the latest accepted announcement
+	expiry time.Time
+}
+
+// Table is a stcp.PKTable which merges entries discovered on the local network into a static table.
+// Static entries always take precedence over discovered ones.
+type Table struct {
+	static  stcp.PKTable
+	entries map[cipher.PubKey]entry
+	mx      sync.RWMutex
+}
+
+// NewTable creates a Table on top of a static table.
+func NewTable(static stcp.PKTable) *Table {
+	if static == nil {
+		static = stcp.NewTable(nil)
+	}
+	return &Table{
+		static:  static,
+		entries: make(map[cipher.PubKey]entry),
+	}
+}
+
+// Put records a discovered entry which is valid until 'expiry'.
+// Announcements older than the latest one recorded for the same public key are ignored.
+// It returns true if the public key was not previously known (or had expired).
+func (t *Table) Put(a Announcement, addr string, expiry time.Time) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	e, ok := t.entries[a.PK]
+	if ok && a.Timestamp <= e.ts {
+		return false
+	}
+	isNew := !ok || time.Now().After(e.expiry) || e.addr != addr
+	t.entries[a.PK] = entry{addr: addr, ts: a.Timestamp, expiry: expiry}
+	return isNew
+}
+
+// Addr implements stcp.PKTable
+func (t *Table) Addr(pk cipher.PubKey) (string, bool) {
+	if addr, ok := t.static.Addr(pk); ok {
+		return addr, true
+	}
+
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	e, ok := t.entries[pk]
+	if !ok || time.Now().After(e.expiry) {
+		return "", false
+	}
+	return e.addr, true
+}
+
+// PubKey implements stcp.PKTable
+func (t *Table) PubKey(addr string) (cipher.PubKey, bool) {
+	if pk, ok := t.static.PubKey(addr); ok {
+		return pk, true
+	}
+
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	now := time.Now()
+	for pk, e := range t.entries {
+		if e.addr == addr && !now.After(e.expiry) {
+			return pk, true
+		}
+	}
+	return cipher.PubKey{}, false
+}
+
+// Count implements stcp.PKTable
+func (t *Table) Count() int {
+	return t.static.Count() + len(t.Discovered())
+}
+
+// Discovered returns the discovered entries which have not expired.
+func (t *Table) Discovered() map[cipher.PubKey]string {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	now := time.Now()
+	out := make(map[cipher.PubKey]string, len(t.entries))
+	for pk, e := range t.entries {
+		if _, ok := t.static.Addr(pk); ok || now.After(e.expiry) {
+			continue
+		}
+		out[pk] = e.addr
+	}
+	return out
+}
+
+// RemoveExpired removes expired entries.
+func (t *Table) RemoveExpired() {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	now := time.Now()
+	for pk, e := range t.entries {
+		if now.After(e.expiry) {
+			delete(t.entries, pk)
+		}
+	}
+}
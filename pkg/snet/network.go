@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/landisc"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/sudp"
 
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
@@ -30,8 +32,12 @@ const (
 const (
 	DmsgType = dmsg.Type
 	STCPType = stcp.Type
+	SUDPType = sudp.Type
 )
 
+// tableReloadInterval is the interval between checks of the stcp pk table file for modifications.
+const tableReloadInterval = 5 * time.Second
+
 var (
 	// ErrUnknownNetwork occurs on attempt to dial an unknown network type.
 	ErrUnknownNetwork = errors.New("unknown network type")
@@ -55,8 +61,15 @@ func (c *DmsgConfig) Type() string {
 
 // STCPConfig defines config for STCP network.
 type STCPConfig struct {
-	PubKeyTable map[cipher.PubKey]string `json:"pk_table"`
-	LocalAddr   string                   `json:"local_address"`
+	PubKeyTable     map[cipher.PubKey]string `json:"pk_table"`
+	PubKeyTableFile string                   `json:"pk_table_file,omitempty"` // reloaded on modification
+	LocalAddr       string                   `json:"local_address"`
+	LANDiscovery    *LANDiscoveryConfig      `json:"lan_discovery,omitempty"`
+}
+
+// LANDiscoveryConfig enables discovery of STCP addresses within the local network.
+type LANDiscoveryConfig struct {
+	Address string `json:"address,omitempty"` // multicast or broadcast UDP address, defaults to landisc.DefaultAddress
 }
 
 // Type returns STCPType.
@@ -64,12 +77,24 @@ func (c *STCPConfig) Type() string {
 	return STCPType
 }
 
+// SUDPConfig defines config for SUDP network.
+type SUDPConfig struct {
+	PubKeyTable map[cipher.PubKey]string `json:"pk_table"`
+	LocalAddr   string                   `json:"local_address"`
+}
+
+// Type returns SUDPType.
+func (c *SUDPConfig) Type() string {
+	return SUDPType
+}
+
 // Config represents a network configuration.
 type Config struct {
 	PubKey cipher.PubKey
 	SecKey cipher.SecKey
 	Dmsg   *DmsgConfig
 	STCP   *STCPConfig
+	SUDP   *SUDPConfig
 }
 
 // Network represents a network between nodes in Skywire.
@@ -78,12 +103,20 @@ type Network struct {
 	networks []string // networks to be used with transports
 	dmsgC    *dmsg.Client
 	stcpC    *stcp.Client
+	sudpC    *sudp.Client
+	stcpT    *stcp.DynamicTable
+	lanD     *landisc.Service
+	done     chan struct{}
+	once     sync.Once
 }
 
 // New creates a network from a config.
 func New(conf Config) *Network {
 	var dmsgC *dmsg.Client
 	var stcpC *stcp.Client
+	var sudpC *sudp.Client
+	var stcpT *stcp.DynamicTable
+	var lanD *landisc.Service
 
 	if conf.Dmsg != nil {
 		c := &dmsg.Config{
@@ -95,15 +128,34 @@ func New(conf Config) *Network {
 	}
 
 	if conf.STCP != nil {
-		stcpC = stcp.NewClient(conf.PubKey, conf.SecKey, stcp.NewTable(conf.STCP.PubKeyTable))
+		stcpT = stcp.NewDynamicTable(conf.STCP.PubKeyTable)
+		var table stcp.PKTable = stcpT
+
+		if lc := conf.STCP.LANDiscovery; lc != nil {
+			lanTable := landisc.NewTable(table)
+			lanD = landisc.NewService(conf.PubKey, conf.SecKey, lanTable, landisc.Config{Address: lc.Address})
+			lanD.SetLogger(logging.MustGetLogger("snet.landisc"))
+			table = lanTable
+		}
+
+		stcpC = stcp.NewClient(conf.PubKey, conf.SecKey, table)
 		stcpC.SetLogger(logging.MustGetLogger("snet.stcpC"))
 	}
 
-	return NewRaw(conf, dmsgC, stcpC)
+	if conf.SUDP != nil {
+		sudpC = sudp.NewClient(conf.PubKey, conf.SecKey, stcp.NewTable(conf.SUDP.PubKeyTable))
+		sudpC.SetLogger(logging.MustGetLogger("snet.sudpC"))
+	}
+
+	n := NewRaw(conf, dmsgC, stcpC, sudpC)
+	n.stcpT = stcpT
+	n.lanD = lanD
+
+	return n
 }
 
-// NewRaw creates a network from a config and a dmsg client.
-func NewRaw(conf Config, dmsgC *dmsg.Client, stcpC *stcp.Client) *Network {
+// NewRaw creates a network from a config and the underlying network clients.
+func NewRaw(conf Config, dmsgC *dmsg.Client, stcpC *stcp.Client, sudpC *sudp.Client) *Network {
 	networks := make([]string, 0)
 
 	if dmsgC != nil {
@@ -114,11 +166,17 @@ func NewRaw(conf Config, dmsgC *dmsg.Client, stcpC *stcp.Client) *Network {
 		networks = append(networks, STCPType)
 	}
 
+	if sudpC != nil {
+		networks = append(networks, SUDPType)
+	}
+
 	return &Network{
 		conf:     conf,
 		networks: networks,
 		dmsgC:    dmsgC,
 		stcpC:    stcpC,
+		sudpC:    sudpC,
+		done:     make(chan struct{}),
 	}
 }
 
@@ -138,6 +196,29 @@ func (n *Network) Init(_ context.Context) error {
 		} else {
 			fmt.Println("No config found for stcp")
 		}
+
+		if n.stcpT != nil && n.conf.STCP.PubKeyTableFile != "" {
+			log := logging.MustGetLogger("snet.stcpT")
+			if err := n.stcpT.WatchFile(n.conf.STCP.PubKeyTableFile, tableReloadInterval, log, n.done); err != nil {
+				return fmt.Errorf("failed to load stcp pk table file: %v", err)
+			}
+		}
+
+		if n.lanD != nil && n.conf.STCP.LocalAddr != "" {
+			if err := n.lanD.Serve(n.conf.STCP.LocalAddr); err != nil {
+				return fmt.Errorf("failed to initiate LAN discovery: %v", err)
+			}
+		}
+	}
+
+	if n.conf.SUDP != nil {
+		if n.sudpC != nil && n.conf.SUDP.LocalAddr != "" {
+			if err := n.sudpC.Serve(n.conf.SUDP.LocalAddr); err != nil {
+				return fmt.Errorf("failed to initiate 'sudp': %v", err)
+			}
+		} else {
+			fmt.Println("No config found for sudp")
+		}
 	}
 
 	return nil
@@ -145,8 +226,12 @@ func (n *Network) Init(_ context.Context) error {
 
 // Close closes underlying connections.
 func (n *Network) Close() error {
+	n.once.Do(func() { close(n.done) })
+
+	lanErr := n.lanD.Close()
+
 	wg := new(sync.WaitGroup)
-	wg.Add(2)
+	wg.Add(3)
 
 	var dmsgErr error
 	go func() {
@@ -160,6 +245,12 @@ func (n *Network) Close() error {
 		wg.Done()
 	}()
 
+	var sudpErr error
+	go func() {
+		sudpErr = n.sudpC.Close()
+		wg.Done()
+	}()
+
 	wg.Wait()
 
 	if dmsgErr != nil {
@@ -168,6 +259,12 @@ func (n *Network) Close() error {
 	if stcpErr != nil {
 		return stcpErr
 	}
+	if sudpErr != nil {
+		return sudpErr
+	}
+	if lanErr != nil {
+		return lanErr
+	}
 	return nil
 }
 
@@ -186,6 +283,17 @@ func (n *Network) Dmsg() *dmsg.Client { return n.dmsgC }
 // STcp returns the underlying stcp.Client.
 func (n *Network) STcp() *stcp.Client { return n.stcpC }
 
+// SUdp returns the underlying sudp.Client.
+func (n *Network) SUdp() *sudp.Client { return n.sudpC }
+
+// STCPTable returns the mutable pk table of stcp.
+// It returns nil if the network was not created from a stcp config.
+func (n *Network) STCPTable() *stcp.DynamicTable { return n.stcpT }
+
+// LANDiscovery returns the LAN discovery service of stcp.
+// It returns nil if LAN discovery is disabled.
+func (n *Network) LANDiscovery() *landisc.Service { return n.lanD }
+
 // Dialer is an entity that can be dialed and asked for its type.
 type Dialer interface {
 	Dial(ctx context.Context, remote cipher.PubKey, port uint16) (net.Conn, error)
@@ -213,6 +321,13 @@ func (n *Network) Dial(ctx context.Context, network string, pk cipher.PubKey, po
 			return nil, err
 		}
 
+		return makeConn(conn, network), nil
+	case SUDPType:
+		conn, err := n.sudpC.Dial(ctx, pk, port)
+		if err != nil {
+			return nil, err
+		}
+
 		return makeConn(conn, network), nil
 	default:
 		return nil, ErrUnknownNetwork
@@ -235,6 +350,13 @@ func (n *Network) Listen(network string, port uint16) (*Listener, error) {
 			return nil, err
 		}
 
+		return makeListener(lis, network), nil
+	case SUDPType:
+		lis, err := n.sudpC.Listen(port)
+		if err != nil {
+			return nil, err
+		}
+
 		return makeListener(lis, network), nil
 	default:
 		return nil, ErrUnknownNetwork
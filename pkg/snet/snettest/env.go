@@ -14,6 +14,7 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/sudp"
 )
 
 // KeyPair holds a public/private key pair.
@@ -54,16 +55,22 @@ func NewEnv(t *testing.T, keys []KeyPair, networks []string) *Env {
 	dmsgD := disc.NewMock()
 	dmsgS, dmsgSErr := createDmsgSrv(t, dmsgD)
 
-	const baseSTCPPort = 7033
+	const (
+		baseSTCPPort = 7033
+		baseSUDPPort = 7133
+	)
 
 	tableEntries := make(map[cipher.PubKey]string)
+	sudpEntries := make(map[cipher.PubKey]string)
 	for i, pair := range keys {
 		tableEntries[pair.PK] = "127.0.0.1:" + strconv.Itoa(baseSTCPPort+i)
+		sudpEntries[pair.PK] = "127.0.0.1:" + strconv.Itoa(baseSUDPPort+i)
 	}
 
 	table := stcp.NewTable(tableEntries)
+	sudpTable := stcp.NewTable(sudpEntries)
 
-	var hasDmsg, hasStcp bool
+	var hasDmsg, hasStcp, hasSudp bool
 
 	for _, network := range networks {
 		switch network {
@@ -71,6 +78,8 @@ func NewEnv(t *testing.T, keys []KeyPair, networks []string) *Env {
 			hasDmsg = true
 		case stcp.Type:
 			hasStcp = true
+		case sudp.Type:
+			hasSudp = true
 		}
 	}
 
@@ -80,6 +89,8 @@ func NewEnv(t *testing.T, keys []KeyPair, networks []string) *Env {
 	for i, pairs := range keys {
 		var dmsgClient *dmsg.Client
 		var stcpClient *stcp.Client
+		var sudpClient *sudp.Client
+		var sudpConf *snet.SUDPConfig
 
 		if hasDmsg {
 			dmsgClient = dmsg.NewClient(pairs.PK, pairs.SK, dmsgD, nil)
@@ -90,6 +101,13 @@ func NewEnv(t *testing.T, keys []KeyPair, networks []string) *Env {
 			stcpClient = stcp.NewClient(pairs.PK, pairs.SK, table)
 		}
 
+		if hasSudp {
+			sudpClient = sudp.NewClient(pairs.PK, pairs.SK, sudpTable)
+			sudpConf = &snet.SUDPConfig{
+				LocalAddr: sudpEntries[pairs.PK],
+			}
+		}
+
 		port := 7033
 		n := snet.NewRaw(
 			snet.Config{
@@ -101,9 +119,11 @@ func NewEnv(t *testing.T, keys []KeyPair, networks []string) *Env {
 				STCP: &snet.STCPConfig{
 					LocalAddr: "127.0.0.1:" + strconv.Itoa(port+i),
 				},
+				SUDP: sudpConf,
 			},
 			dmsgClient,
 			stcpClient,
+			sudpClient,
 		)
 		require.NoError(t, n.Init(context.TODO()))
 		ns[i] = n
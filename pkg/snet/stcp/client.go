@@ -157,6 +157,11 @@ func (c *Client) SetLogger(log *logging.Logger) {
 	c.log = log
 }
 
+// Table returns the pk table used to resolve remote addresses.
+func (c *Client) Table() PKTable {
+	return c.t
+}
+
 // Serve serves the listening portion of the client.
 func (c *Client) Serve(tcpAddr string) error {
 	if c.lTCP != nil {
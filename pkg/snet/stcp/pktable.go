@@ -4,11 +4,15 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
 )
 
 // PKTable associates public keys to tcp addresses.
@@ -38,6 +42,15 @@ func NewTable(entries map[cipher.PubKey]string) PKTable {
 // NewTableFromFile is similar to NewTable, but grabs predefined values
 // from a file specified in 'path'.
 func NewTableFromFile(path string) (PKTable, error) {
+	entries, err := readTableFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(entries), nil
+}
+
+// readTableFile reads a table file where each line contains a public key and an address.
+func readTableFile(path string) (map[cipher.PubKey]string, error) {
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
@@ -68,7 +81,10 @@ func NewTableFromFile(path string) (PKTable, error) {
 		}
 		entries[pk] = fields[1]
 	}
-	return NewTable(entries), nil
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
 
 // Addr obtains the address associated with the given public key.
@@ -87,3 +103,175 @@ func (mt *memoryTable) PubKey(addr string) (cipher.PubKey, bool) {
 func (mt *memoryTable) Count() int {
 	return len(mt.entries)
 }
+
+// DynamicTable is a concurrency-safe PKTable which can be modified at runtime.
+type DynamicTable struct {
+	entries map[cipher.PubKey]string
+	reverse map[string]cipher.PubKey
+	mx      sync.RWMutex
+}
+
+// NewDynamicTable instantiates a DynamicTable with the given initial entries.
+func NewDynamicTable(entries map[cipher.PubKey]string) *DynamicTable {
+	t := &DynamicTable{
+		entries: make(map[cipher.PubKey]string, len(entries)),
+		reverse: make(map[string]cipher.PubKey, len(entries)),
+	}
+	for pk, addr := range entries {
+		t.entries[pk] = addr
+		t.reverse[addr] = pk
+	}
+	return t
+}
+
+// Addr obtains the address associated with the given public key.
+func (t *DynamicTable) Addr(pk cipher.PubKey) (string, bool) {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	addr, ok := t.entries[pk]
+	return addr, ok
+}
+
+// PubKey obtains the public key associated with the given address.
+func (t *DynamicTable) PubKey(addr string) (cipher.PubKey, bool) {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	pk, ok := t.reverse[addr]
+	return pk, ok
+}
+
+// Count returns the number of entries within the DynamicTable.
+func (t *DynamicTable) Count() int {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	return len(t.entries)
+}
+
+// Entries returns a copy of all entries within the DynamicTable.
+func (t *DynamicTable) Entries() map[cipher.PubKey]string {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	out := make(map[cipher.PubKey]string, len(t.entries))
+	for pk, addr := range t.entries {
+		out[pk] = addr
+	}
+	return out
+}
+
+// Add adds an entry, replacing the previous address of 'pk' if any.
+func (t *DynamicTable) Add(pk cipher.PubKey, addr string) error {
+	if pk.Null() {
+		return errors.New("public key cannot be null")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("invalid address: %v", err)
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if oldAddr, ok := t.entries[pk]; ok {
+		delete(t.reverse, oldAddr)
+	}
+	t.entries[pk] = addr
+	t.reverse[addr] = pk
+	return nil
+}
+
+// Remove removes the entry of 'pk'.
+// It returns false if the entry did not exist.
+func (t *DynamicTable) Remove(pk cipher.PubKey) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	addr, ok := t.entries[pk]
+	if !ok {
+		return false
+	}
+	delete(t.entries, pk)
+	if t.reverse[addr] == pk {
+		delete(t.reverse, addr)
+	}
+	return true
+}
+
+// WatchFile loads the entries of the table file at 'path' and reloads them whenever the file is modified.
+// Entries which disappear from the file are removed from the table.
+// The file is polled every 'interval' until 'done' is closed.
+func (t *DynamicTable) WatchFile(path string, interval time.Duration, log *logging.Logger, done <-chan struct{}) error {
+	modTime, err := fileModTime(path)
+	if err != nil {
+		return err
+	}
+	loaded, err := t.reloadFile(path, nil)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			mt, err := fileModTime(path)
+			if err != nil {
+				log.WithError(err).Warnf("Failed to stat pk table file %s", path)
+				continue
+			}
+			if mt.Equal(modTime) {
+				continue
+			}
+			modTime = mt
+
+			if loaded, err = t.reloadFile(path, loaded); err != nil {
+				log.WithError(err).Warnf("Failed to reload pk table file %s", path)
+				continue
+			}
+			log.Infof("Reloaded %d entries from pk table file %s", len(loaded), path)
+		}
+	}()
+
+	return nil
+}
+
+// reloadFile adds the entries of the table file, and removes 'prev' entries which are no longer in the file.
+func (t *DynamicTable) reloadFile(path string, prev map[cipher.PubKey]string) (map[cipher.PubKey]string, error) {
+	entries, err := readTableFile(path)
+	if err != nil {
+		return prev, err
+	}
+
+	for _, addr := range entries {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return prev, fmt.Errorf("pk file is invalid: %v", err)
+		}
+	}
+
+	for pk := range prev {
+		if _, ok := entries[pk]; !ok {
+			t.Remove(pk)
+		}
+	}
+	for pk, addr := range entries {
+		_ = t.Add(pk, addr) //nolint:errcheck
+	}
+	return entries, nil
+}
+
+func fileModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
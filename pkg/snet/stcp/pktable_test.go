@@ -0,0 +1,79 @@
+package stcp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicTable(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	tab := NewDynamicTable(map[cipher.PubKey]string{pk1: "127.0.0.1:7001"})
+	assert.Equal(t, 1, tab.Count())
+
+	require.NoError(t, tab.Add(pk2, "127.0.0.1:7002"))
+	assert.Error(t, tab.Add(pk2, "no-port"))
+	assert.Error(t, tab.Add(cipher.PubKey{}, "127.0.0.1:7003"))
+
+	addr, ok := tab.Addr(pk2)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:7002", addr)
+
+	// Replacing an address also updates the reverse lookup.
+	require.NoError(t, tab.Add(pk2, "127.0.0.1:7003"))
+	_, ok = tab.PubKey("127.0.0.1:7002")
+	assert.False(t, ok)
+	pk, ok := tab.PubKey("127.0.0.1:7003")
+	assert.True(t, ok)
+	assert.Equal(t, pk2, pk)
+
+	assert.True(t, tab.Remove(pk1))
+	assert.False(t, tab.Remove(pk1))
+	assert.Equal(t, map[cipher.PubKey]string{pk2: "127.0.0.1:7003"}, tab.Entries())
+}
+
+func TestDynamicTable_WatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pktable")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+	pk3, _ := cipher.GenerateKeyPair()
+
+	path := filepath.Join(dir, "pk_table")
+	writeFile := func(content string, modTime time.Time) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeFile(fmt.Sprintf("%s 127.0.0.1:7001\n%s 127.0.0.1:7002\n", pk1, pk2), time.Now().Add(-time.Hour))
+
+	tab := NewDynamicTable(map[cipher.PubKey]string{pk3: "127.0.0.1:7003"})
+
+	done := make(chan struct{})
+	defer close(done)
+	require.NoError(t, tab.WatchFile(path, 10*time.Millisecond, logging.MustGetLogger("pktable"), done))
+	assert.Equal(t, 3, tab.Count())
+
+	// Entries removed from the file are removed from the table, other entries are retained.
+	writeFile(fmt.Sprintf("%s 127.0.0.1:7011\n", pk1), time.Now())
+	require.Eventually(t, func() bool {
+		addr, _ := tab.Addr(pk1)
+		return addr == "127.0.0.1:7011"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, map[cipher.PubKey]string{
+		pk1: "127.0.0.1:7011",
+		pk3: "127.0.0.1:7003",
+	}, tab.Entries())
+}
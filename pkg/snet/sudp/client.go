@@ -0,0 +1,473 @@
+package sudp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/dmsg"
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/dmsg/netutil"
+	"github.com/SkycoinProject/dmsg/noise"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// Type is sudp type.
+const Type = "sudp"
+
+// Conn wraps an underlying net.Conn and modifies various methods to integrate better with the 'network' package.
+type Conn struct {
+	net.Conn
+	lAddr    dmsg.Addr
+	rAddr    dmsg.Addr
+	freePort func()
+}
+
+// newConn performs the stcp handshake over the given session, and then encrypts the session with noise.
+func newConn(conn net.Conn, deadline time.Time, hs stcp.Handshake, lSK cipher.SecKey, initiator bool, freePort func()) (*Conn, error) {
+	fail := func(err error) (*Conn, error) {
+		_ = conn.Close() //nolint:errcheck
+
+		if freePort != nil {
+			freePort()
+		}
+
+		return nil, err
+	}
+
+	lAddr, rAddr, err := hs(conn, deadline)
+	if err != nil {
+		return fail(err)
+	}
+	ns, err := noise.KKAndSecp256k1(noise.Config{
+		LocalPK:   lAddr.PK,
+		LocalSK:   lSK,
+		RemotePK:  rAddr.PK,
+		Initiator: initiator,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	nc, err := noise.WrapConn(conn, ns, time.Until(deadline))
+	if err != nil {
+		return fail(err)
+	}
+	return &Conn{Conn: nc, lAddr: lAddr, rAddr: rAddr, freePort: freePort}, nil
+}
+
+// LocalAddr implements net.Conn
+func (c *Conn) LocalAddr() net.Addr {
+	return c.lAddr
+}
+
+// RemoteAddr implements net.Conn
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.rAddr
+}
+
+// Close implements net.Conn
+func (c *Conn) Close() error {
+	if c.freePort != nil {
+		c.freePort()
+	}
+	return c.Conn.Close()
+}
+
+// Listener implements net.Listener
+type Listener struct {
+	lAddr    dmsg.Addr
+	freePort func()
+	accept   chan *Conn
+	done     chan struct{}
+	once     sync.Once
+	mx       sync.Mutex
+}
+
+func newListener(lAddr dmsg.Addr, freePort func()) *Listener {
+	return &Listener{
+		lAddr:    lAddr,
+		freePort: freePort,
+		accept:   make(chan *Conn),
+		done:     make(chan struct{}),
+	}
+}
+
+// Introduce is used by sudp.Client to introduce sudp.Conn to Listener.
+func (l *Listener) Introduce(conn *Conn) error {
+	select {
+	case <-l.done:
+		return io.ErrClosedPipe
+	default:
+		l.mx.Lock()
+		defer l.mx.Unlock()
+
+		select {
+		case l.accept <- conn:
+			return nil
+		case <-l.done:
+			return io.ErrClosedPipe
+		}
+	}
+}
+
+// Accept implements net.Listener
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, ok := <-l.accept
+	if !ok {
+		return nil, io.ErrClosedPipe
+	}
+	return conn, nil
+}
+
+// Close implements net.Listener
+func (l *Listener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+
+		l.mx.Lock()
+		close(l.accept)
+		l.mx.Unlock()
+
+		l.freePort()
+	})
+	return nil
+}
+
+// Addr implements net.Listener
+func (l *Listener) Addr() net.Addr {
+	return l.lAddr
+}
+
+type sessionKey struct {
+	addr string
+	conv uint32
+}
+
+// Limits of half-open sessions: sessions opened by remotes whose handshake is not complete.
+// SYNs beyond the limits are dropped, so that unauthenticated datagrams can't exhaust resources.
+const (
+	maxHalfOpen        = 256
+	maxHalfOpenPerHost = 16
+)
+
+// Client is the central control for incoming and outgoing 'sudp.Conn's.
+// All sessions of a Client are multiplexed over a single UDP socket.
+type Client struct {
+	log *logging.Logger
+
+	lPK cipher.PubKey
+	lSK cipher.SecKey
+	t   stcp.PKTable
+	p   *netutil.Porter
+
+	pc       net.PacketConn
+	sessions map[sessionKey]*session
+	lMap     map[uint16]*Listener // key: lPort
+	halfOpen map[string]int       // key: remote host
+	nHalf    int                  // total number of half-open sessions
+	mx       sync.Mutex
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewClient creates a net Client.
+func NewClient(pk cipher.PubKey, sk cipher.SecKey, t stcp.PKTable) *Client {
+	return &Client{
+		log:      logging.MustGetLogger(Type),
+		lPK:      pk,
+		lSK:      sk,
+		t:        t,
+		p:        netutil.NewPorter(netutil.PorterMinEphemeral),
+		sessions: make(map[sessionKey]*session),
+		lMap:     make(map[uint16]*Listener),
+		halfOpen: make(map[string]int),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetLogger sets a logger for Client.
+func (c *Client) SetLogger(log *logging.Logger) {
+	c.log = log
+}
+
+// Serve serves the listening portion of the client.
+func (c *Client) Serve(udpAddr string) error {
+	pc, err := net.ListenPacket("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	if err := c.ServeConn(pc); err != nil {
+		_ = pc.Close() //nolint:errcheck
+		return err
+	}
+	c.log.Infof("listening on udp addr: %v", pc.LocalAddr())
+	return nil
+}
+
+// ServeConn serves the client over the provided packet connection.
+// This allows the underlying datagram transport to be substituted (i.e. for testing).
+func (c *Client) ServeConn(pc net.PacketConn) error {
+	if c.isClosed() {
+		return io.ErrClosedPipe
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.pc != nil {
+		return errors.New("already listening")
+	}
+	c.pc = pc
+
+	go c.readLoop(pc)
+	return nil
+}
+
+// packetConn returns the packet connection of the client, binding to a random local port if not yet served.
+func (c *Client) packetConn() (net.PacketConn, error) {
+	c.mx.Lock()
+	pc := c.pc
+	c.mx.Unlock()
+
+	if pc != nil {
+		return pc, nil
+	}
+
+	pc, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ServeConn(pc); err != nil {
+		_ = pc.Close() //nolint:errcheck
+		return c.packetConn()
+	}
+	return pc, nil
+}
+
+func (c *Client) readLoop(pc net.PacketConn) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if !c.isClosed() {
+				c.log.Warnf("stopped serving sudp: %v", err)
+			}
+			return
+		}
+
+		h, data, err := decodeSegment(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		key := sessionKey{addr: addr.String(), conv: h.conv}
+
+		c.mx.Lock()
+		sess, ok := c.sessions[key]
+		if !ok && h.cmd == cmdSyn && h.sn == 0 && !c.isClosed() {
+			if host := addrHost(addr); c.reserveHalfOpen(host) {
+				sess = c.newSession(pc, key, addr)
+				go c.acceptSession(sess, host)
+			}
+		}
+		c.mx.Unlock()
+
+		if sess != nil {
+			sess.input(h, data)
+		}
+	}
+}
+
+// newSession creates and registers a session.
+// c.mx should be locked.
+func (c *Client) newSession(pc net.PacketConn, key sessionKey, rAddr net.Addr) *session {
+	sess := newSession(key.conv, pc.LocalAddr(), rAddr, pc.WriteTo, func() {
+		c.mx.Lock()
+		delete(c.sessions, key)
+		c.mx.Unlock()
+	})
+	c.sessions[key] = sess
+	return sess
+}
+
+// reserveHalfOpen counts a new half-open session from 'host', unless that exceeds the limits.
+// c.mx should be locked.
+func (c *Client) reserveHalfOpen(host string) bool {
+	if c.nHalf >= maxHalfOpen || c.halfOpen[host] >= maxHalfOpenPerHost {
+		return false
+	}
+	c.nHalf++
+	c.halfOpen[host]++
+	return true
+}
+
+// releaseHalfOpen is called once the handshake of a half-open session from 'host' is over.
+func (c *Client) releaseHalfOpen(host string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.nHalf--
+	if c.halfOpen[host]--; c.halfOpen[host] <= 0 {
+		delete(c.halfOpen, host)
+	}
+}
+
+func (c *Client) acceptSession(sess *session, host string) {
+	var lis *Listener
+	hs := stcp.ResponderHandshake(func(f2 stcp.Frame2) error {
+		if f2.DstAddr.PK != c.lPK {
+			return errors.New("unexpected destination public key")
+		}
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		var ok bool
+		if lis, ok = c.lMap[f2.DstAddr.Port]; !ok {
+			return errors.New("not listening on given port")
+		}
+		return nil
+	})
+	conn, err := newConn(sess, time.Now().Add(stcp.HandshakeTimeout), hs, c.lSK, false, nil)
+	c.releaseHalfOpen(host)
+	if err != nil {
+		c.log.Warnf("failed to accept incoming connection: %v", err)
+		return
+	}
+	if err := lis.Introduce(conn); err != nil {
+		c.log.Warnf("failed to introduce incoming connection: %v", err)
+		_ = conn.Close() //nolint:errcheck
+	}
+}
+
+// addrHost returns the host of a remote address, so that sessions from all ports of a host are counted together.
+func addrHost(addr net.Addr) string {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.IP.String()
+	}
+	return addr.String()
+}
+
+// Dial dials a new sudp.Conn to specified remote public key and port.
+func (c *Client) Dial(ctx context.Context, rPK cipher.PubKey, rPort uint16) (*Conn, error) {
+	if c.isClosed() {
+		return nil, io.ErrClosedPipe
+	}
+
+	udpAddr, ok := c.t.Addr(rPK)
+	if !ok {
+		return nil, fmt.Errorf("pk table: entry of %s does not exist", rPK)
+	}
+	rAddr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := c.packetConn()
+	if err != nil {
+		return nil, err
+	}
+
+	lPort, freePort, err := c.p.ReserveEphemeral(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mx.Lock()
+	var key sessionKey
+	for {
+		key = sessionKey{addr: rAddr.String(), conv: binary.BigEndian.Uint32(cipher.RandByte(4))}
+		if _, ok := c.sessions[key]; !ok {
+			break
+		}
+	}
+	sess := c.newSession(pc, key, rAddr)
+	c.mx.Unlock()
+
+	sess.open()
+
+	deadline := time.Now().Add(stcp.HandshakeTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	hs := stcp.InitiatorHandshake(c.lSK, dmsg.Addr{PK: c.lPK, Port: lPort}, dmsg.Addr{PK: rPK, Port: rPort})
+	return newConn(sess, deadline, hs, c.lSK, true, freePort)
+}
+
+// Listen creates a new listener for sudp.
+// The created Listener cannot actually accept remote connections unless Serve is called beforehand.
+func (c *Client) Listen(lPort uint16) (*Listener, error) {
+	if c.isClosed() {
+		return nil, io.ErrClosedPipe
+	}
+
+	ok, freePort := c.p.Reserve(lPort, nil)
+	if !ok {
+		return nil, errors.New("port is already occupied")
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	lAddr := dmsg.Addr{PK: c.lPK, Port: lPort}
+	lis := newListener(lAddr, func() {
+		c.mx.Lock()
+		delete(c.lMap, lPort)
+		c.mx.Unlock()
+		freePort()
+	})
+	c.lMap[lPort] = lis
+	return lis, nil
+}
+
+// Close closes the Client.
+func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.once.Do(func() {
+		close(c.done)
+
+		c.mx.Lock()
+		pc := c.pc
+		sessions := make([]*session, 0, len(c.sessions))
+		for _, sess := range c.sessions {
+			sessions = append(sessions, sess)
+		}
+		listeners := make([]*Listener, 0, len(c.lMap))
+		for _, lis := range c.lMap {
+			listeners = append(listeners, lis)
+		}
+		c.mx.Unlock()
+
+		if pc != nil {
+			_ = pc.Close() //nolint:errcheck
+		}
+		for _, sess := range sessions {
+			sess.teardown(io.ErrClosedPipe)
+		}
+		for _, lis := range listeners {
+			_ = lis.Close() // nolint:errcheck
+		}
+	})
+	return nil
+}
+
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Type returns the stream type.
+func (c *Client) Type() string {
+	return Type
+}
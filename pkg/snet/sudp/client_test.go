@@ -0,0 +1,203 @@
+package sudp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/nettest"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/stcp"
+)
+
+// lossyConn drops a fraction of outgoing datagrams.
+type lossyConn struct {
+	net.PacketConn
+	loss float64
+	rand *rand.Rand
+	mx   sync.Mutex
+}
+
+func newLossyConn(t *testing.T, loss float64) *lossyConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return &lossyConn{PacketConn: pc, loss: loss, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (c *lossyConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.mx.Lock()
+	drop := c.rand.Float64() < c.loss
+	c.mx.Unlock()
+
+	if drop {
+		return len(b), nil
+	}
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func TestSession(t *testing.T) {
+	mp := func() (c1, c2 net.Conn, stop func(), err error) {
+		c1, c2, stop = prepareConns(t, 0)
+		return
+	}
+	nettest.TestConn(t, mp)
+}
+
+func TestConn_Lossy(t *testing.T) {
+	a, b, stop := prepareConns(t, 0.2)
+	defer stop()
+
+	want := make([]byte, 512*1024)
+	_, err := rand.Read(want)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := a.Write(want)
+		errCh <- err
+	}()
+
+	got := make([]byte, len(want))
+	_, err = io.ReadFull(b, got)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	assert.True(t, bytes.Equal(want, got))
+}
+
+func TestClient_Dial(t *testing.T) {
+	aPK, aSK := cipher.GenerateKeyPair()
+	bPK, bSK := cipher.GenerateKeyPair()
+
+	bPC := newLossyConn(t, 0)
+	table := stcp.NewTable(map[cipher.PubKey]string{bPK: bPC.LocalAddr().String()})
+
+	aC := NewClient(aPK, aSK, table)
+	bC := NewClient(bPK, bSK, table)
+	require.NoError(t, aC.ServeConn(newLossyConn(t, 0)))
+	require.NoError(t, bC.ServeConn(bPC))
+	defer func() {
+		require.NoError(t, aC.Close())
+		require.NoError(t, bC.Close())
+	}()
+
+	// Not listening on port.
+	_, err := aC.Dial(context.TODO(), bPK, 5)
+	require.Error(t, err)
+
+	// Unknown public key.
+	cPK, _ := cipher.GenerateKeyPair()
+	_, err = aC.Dial(context.TODO(), cPK, 5)
+	require.Error(t, err)
+
+	lis, err := bC.Listen(5)
+	require.NoError(t, err)
+
+	_, err = bC.Listen(5)
+	require.Error(t, err)
+
+	require.NoError(t, lis.Close())
+	lis, err = bC.Listen(5)
+	require.NoError(t, err)
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := lis.Accept()
+		assert.NoError(t, err)
+		accepted <- conn
+	}()
+
+	aConn, err := aC.Dial(context.TODO(), bPK, 5)
+	require.NoError(t, err)
+	bConn := <-accepted
+
+	assert.Equal(t, aConn.LocalAddr(), bConn.RemoteAddr())
+	assert.Equal(t, aConn.RemoteAddr(), bConn.LocalAddr())
+
+	require.NoError(t, aConn.Close())
+	require.NoError(t, bConn.Close())
+	require.NoError(t, lis.Close())
+}
+
+func TestClient_halfOpenLimit(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+
+	pc := newLossyConn(t, 0)
+	c := NewClient(pk, sk, stcp.NewTable(nil))
+	require.NoError(t, c.ServeConn(pc))
+	defer func() { require.NoError(t, c.Close()) }()
+
+	raw, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, raw.Close()) }()
+
+	// SYNs of unauthenticated sessions which never complete the handshake
+	for conv := uint32(1); conv <= 2*maxHalfOpenPerHost; conv++ {
+		_, err := raw.WriteTo(header{conv: conv, cmd: cmdSyn}.encode(nil), pc.LocalAddr())
+		require.NoError(t, err)
+	}
+
+	numSessions := func() int {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		return len(c.sessions)
+	}
+
+	require.Eventually(t, func() bool { return numSessions() == maxHalfOpenPerHost }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, maxHalfOpenPerHost, numSessions())
+
+	c.mx.Lock()
+	assert.Equal(t, maxHalfOpenPerHost, c.nHalf)
+	c.mx.Unlock()
+}
+
+func prepareConns(t *testing.T, loss float64) (net.Conn, net.Conn, func()) {
+	aPK, aSK := cipher.GenerateKeyPair()
+	bPK, bSK := cipher.GenerateKeyPair()
+
+	aPC, bPC := newLossyConn(t, loss), newLossyConn(t, loss)
+	table := stcp.NewTable(map[cipher.PubKey]string{
+		aPK: aPC.LocalAddr().String(),
+		bPK: bPC.LocalAddr().String(),
+	})
+
+	aC := NewClient(aPK, aSK, table)
+	bC := NewClient(bPK, bSK, table)
+	require.NoError(t, aC.ServeConn(aPC))
+	require.NoError(t, bC.ServeConn(bPC))
+
+	lis, err := bC.Listen(1)
+	require.NoError(t, err)
+
+	var b net.Conn
+	var respErr error
+	done := make(chan struct{})
+
+	go func() {
+		b, respErr = lis.Accept()
+		close(done)
+	}()
+
+	a, err := aC.Dial(context.TODO(), bPK, 1)
+	require.NoError(t, err)
+
+	<-done
+	require.NoError(t, respErr)
+
+	closeFunc := func() {
+		require.NoError(t, a.Close())
+		require.NoError(t, b.Close())
+		require.NoError(t, aC.Close())
+		require.NoError(t, bC.Close())
+	}
+
+	return a, b, closeFunc
+}
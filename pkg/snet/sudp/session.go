@@ -0,0 +1,595 @@
+package sudp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/deadline"
+)
+
+// Segment commands.
+// Sequenced commands (syn, push, fin) are retransmitted until acknowledged.
+const (
+	cmdSyn   = byte(iota + 1) // opens a session, carries no payload
+	cmdPush                   // carries stream data
+	cmdFin                    // closes the sender's write direction
+	cmdAck                    // acknowledges a sequenced segment
+	cmdWnd                    // advertises the receive window, also used as keep-alive
+	cmdProbe                  // asks the remote to advertise its receive window
+)
+
+// Segment header: | conv (4) | cmd (1) | wnd (2) | sn (4) | una (4) | len (2) |
+const headerSize = 17
+
+// ARQ tuning.
+// The values are close to what KCP recommends for its "fast" mode.
+const (
+	mtu           = 1400
+	mss           = mtu - headerSize
+	sndWnd        = 256 // max number of segments in flight
+	rcvWnd        = 256 // max number of segments buffered by the receiver
+	flushInterval = 10 * time.Millisecond
+	initRTO       = 200 * time.Millisecond
+	minRTO        = 30 * time.Millisecond
+	maxRTO        = 5 * time.Second
+	fastResend    = 3  // number of skipping acks which trigger a retransmission
+	deadLink      = 24 // number of transmissions after which a segment is considered lost for good
+	probeInterval = time.Second
+	keepAlive     = 5 * time.Second
+	idleTimeout   = 30 * time.Second
+	lingerTimeout = 5 * time.Second
+)
+
+var (
+	// ErrDeadLink occurs when the remote stops acknowledging segments.
+	ErrDeadLink = errors.New("sudp: remote stopped acknowledging segments")
+
+	// ErrIdleTimeout occurs when nothing is received from the remote for too long.
+	ErrIdleTimeout = errors.New("sudp: remote has been idle for too long")
+
+	errMalformedSegment = errors.New("sudp: malformed segment")
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "deadline exceeded" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+type header struct {
+	conv uint32
+	cmd  byte
+	wnd  uint16
+	sn   uint32
+	una  uint32
+}
+
+func (h header) encode(data []byte) []byte {
+	b := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(b[0:], h.conv)
+	b[4] = h.cmd
+	binary.BigEndian.PutUint16(b[5:], h.wnd)
+	binary.BigEndian.PutUint32(b[7:], h.sn)
+	binary.BigEndian.PutUint32(b[11:], h.una)
+	binary.BigEndian.PutUint16(b[15:], uint16(len(data)))
+	copy(b[headerSize:], data)
+	return b
+}
+
+func decodeSegment(b []byte) (header, []byte, error) {
+	if len(b) < headerSize {
+		return header{}, nil, errMalformedSegment
+	}
+	h := header{
+		conv: binary.BigEndian.Uint32(b[0:]),
+		cmd:  b[4],
+		wnd:  binary.BigEndian.Uint16(b[5:]),
+		sn:   binary.BigEndian.Uint32(b[7:]),
+		una:  binary.BigEndian.Uint32(b[11:]),
+	}
+	if int(binary.BigEndian.Uint16(b[15:])) != len(b)-headerSize {
+		return header{}, nil, errMalformedSegment
+	}
+	if h.cmd < cmdSyn || h.cmd > cmdProbe {
+		return header{}, nil, errMalformedSegment
+	}
+	return h, b[headerSize:], nil
+}
+
+// seqDiff compares sequence numbers while taking wrap-around into account.
+func seqDiff(a, b uint32) int32 {
+	return int32(a - b)
+}
+
+type segment struct {
+	cmd  byte
+	sn   uint32
+	data []byte
+
+	xmit    int           // number of times transmitted
+	sentAt  time.Time     // time of last transmission
+	rto     time.Duration // current retransmission timeout
+	fastack int           // number of later segments acknowledged before this one
+}
+
+// session is a reliable, ordered stream on top of an unreliable datagram transport.
+// It implements net.Conn.
+type session struct {
+	conv   uint32
+	lAddr  net.Addr
+	rAddr  net.Addr
+	write  func(b []byte, addr net.Addr) (int, error)
+	onDone func()
+
+	mx sync.Mutex
+
+	sndUna uint32 // first unacknowledged sequence number
+	sndNxt uint32 // next sequence number to send
+	sndBuf []*segment
+	rmtWnd uint16
+	probe  time.Time
+
+	rcvNxt   uint32 // next sequence number expected
+	rcvBuf   map[uint32]*segment
+	rcvQueue bytes.Buffer
+	rcvFin   bool
+	advWnd   uint16 // last receive window advertised to the remote
+
+	srtt   time.Duration
+	rttvar time.Duration
+	rto    time.Duration
+
+	lastRecv time.Time
+	lastSend time.Time
+	closedAt time.Time
+
+	readCh    chan struct{}
+	writeCh   chan struct{}
+	rDeadline deadline.PipeDeadline
+	wDeadline deadline.PipeDeadline
+
+	closed    chan struct{} // closed on local Close
+	closeOnce sync.Once
+	done      chan struct{} // closed when the session is torn down
+	doneOnce  sync.Once
+	err       error
+}
+
+func newSession(conv uint32, lAddr, rAddr net.Addr, write func([]byte, net.Addr) (int, error), onDone func()) *session {
+	now := time.Now()
+	s := &session{
+		conv:      conv,
+		lAddr:     lAddr,
+		rAddr:     rAddr,
+		write:     write,
+		onDone:    onDone,
+		rmtWnd:    rcvWnd,
+		rcvBuf:    make(map[uint32]*segment),
+		advWnd:    rcvWnd,
+		rto:       initRTO,
+		lastRecv:  now,
+		lastSend:  now,
+		readCh:    make(chan struct{}, 1),
+		writeCh:   make(chan struct{}, 1),
+		rDeadline: deadline.MakePipeDeadline(),
+		wDeadline: deadline.MakePipeDeadline(),
+		closed:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.serve()
+	return s
+}
+
+// open sends the segment which introduces the session to the remote.
+func (s *session) open() {
+	s.mx.Lock()
+	s.push(cmdSyn, nil)
+	s.flush()
+	s.mx.Unlock()
+}
+
+func (s *session) serve() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mx.Lock()
+			stop, err := s.update(now)
+			s.mx.Unlock()
+
+			if stop {
+				s.teardown(err)
+				return
+			}
+		}
+	}
+}
+
+// update performs periodic maintenance of the session.
+// It reports whether the session should be torn down.
+func (s *session) update(now time.Time) (bool, error) {
+	if isDone(s.closed) && (len(s.sndBuf) == 0 || now.Sub(s.closedAt) > lingerTimeout) {
+		return true, io.ErrClosedPipe
+	}
+	if now.Sub(s.lastRecv) > idleTimeout {
+		return true, ErrIdleTimeout
+	}
+	if dead := s.flush(); dead {
+		return true, ErrDeadLink
+	}
+	if s.rmtWnd == 0 && now.After(s.probe) {
+		s.output(cmdProbe, 0, nil)
+		s.probe = now.Add(probeInterval)
+	}
+	if now.Sub(s.lastSend) > keepAlive || (s.advWnd == 0 && s.wndUnused() > 0) {
+		s.output(cmdWnd, 0, nil)
+	}
+	return false, nil
+}
+
+func (s *session) teardown(err error) {
+	s.doneOnce.Do(func() {
+		if err == nil {
+			err = io.ErrClosedPipe
+		}
+		s.mx.Lock()
+		s.err = err
+		s.mx.Unlock()
+		close(s.done)
+
+		if s.onDone != nil {
+			s.onDone()
+		}
+	})
+}
+
+/*
+	<<< SENDING >>>
+*/
+
+// push appends a sequenced segment to the send buffer.
+func (s *session) push(cmd byte, data []byte) {
+	seg := &segment{cmd: cmd, sn: s.sndNxt}
+	if len(data) > 0 {
+		seg.data = make([]byte, len(data))
+		copy(seg.data, data)
+	}
+	s.sndBuf = append(s.sndBuf, seg)
+	s.sndNxt++
+}
+
+func (s *session) canPush() bool {
+	wnd := uint16(sndWnd)
+	if s.rmtWnd < wnd {
+		wnd = s.rmtWnd
+	}
+	return seqDiff(s.sndNxt, s.sndUna) < int32(wnd)
+}
+
+// flush (re)transmits segments of the send buffer which are due.
+// It reports whether a segment exceeded the transmission limit.
+func (s *session) flush() (dead bool) {
+	now := time.Now()
+	for _, seg := range s.sndBuf {
+		switch {
+		case seg.xmit == 0:
+			seg.rto = s.rto
+		case now.Sub(seg.sentAt) >= seg.rto:
+			if seg.rto *= 2; seg.rto > maxRTO {
+				seg.rto = maxRTO
+			}
+		case seg.fastack >= fastResend:
+			seg.fastack = 0
+		default:
+			continue
+		}
+		seg.xmit++
+		seg.sentAt = now
+		s.output(seg.cmd, seg.sn, seg.data)
+
+		if seg.xmit > deadLink {
+			dead = true
+		}
+	}
+	return dead
+}
+
+func (s *session) output(cmd byte, sn uint32, data []byte) {
+	s.advWnd = s.wndUnused()
+	h := header{conv: s.conv, cmd: cmd, wnd: s.advWnd, sn: sn, una: s.rcvNxt}
+
+	// Errors are treated the same as a lost datagram.
+	_, _ = s.write(h.encode(data), s.rAddr) //nolint:errcheck
+	s.lastSend = time.Now()
+}
+
+/*
+	<<< RECEIVING >>>
+*/
+
+// input processes a segment received from the remote.
+func (s *session) input(h header, data []byte) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.lastRecv = time.Now()
+	s.rmtWnd = h.wnd
+	s.ackUna(h.una)
+
+	switch h.cmd {
+	case cmdAck:
+		s.ackSegment(h.sn)
+		signal(s.writeCh)
+	case cmdWnd:
+		signal(s.writeCh)
+	case cmdProbe:
+		s.output(cmdWnd, 0, nil)
+	default:
+		if s.receive(h, data) {
+			s.output(cmdAck, h.sn, nil)
+		}
+		signal(s.readCh)
+	}
+}
+
+// ackUna drops all segments preceding 'una' from the send buffer.
+func (s *session) ackUna(una uint32) {
+	i := 0
+	for ; i < len(s.sndBuf); i++ {
+		if seqDiff(s.sndBuf[i].sn, una) >= 0 {
+			break
+		}
+	}
+	s.sndBuf = s.sndBuf[i:]
+	s.shrinkBuf()
+}
+
+// ackSegment drops a single acknowledged segment from the send buffer.
+func (s *session) ackSegment(sn uint32) {
+	for i, seg := range s.sndBuf {
+		if seg.sn == sn {
+			// Karn's algorithm: only sample segments which were sent once.
+			if seg.xmit == 1 {
+				s.updateRTO(time.Since(seg.sentAt))
+			}
+			s.sndBuf = append(s.sndBuf[:i], s.sndBuf[i+1:]...)
+			break
+		}
+		if seqDiff(seg.sn, sn) < 0 {
+			seg.fastack++
+		}
+	}
+	s.shrinkBuf()
+}
+
+func (s *session) shrinkBuf() {
+	if len(s.sndBuf) > 0 {
+		s.sndUna = s.sndBuf[0].sn
+	} else {
+		s.sndUna = s.sndNxt
+	}
+}
+
+// updateRTO updates the retransmission timeout as described in RFC 6298.
+func (s *session) updateRTO(rtt time.Duration) {
+	if s.srtt == 0 {
+		s.srtt = rtt
+		s.rttvar = rtt / 2
+	} else {
+		delta := rtt - s.srtt
+		if delta < 0 {
+			delta = -delta
+		}
+		s.rttvar = (3*s.rttvar + delta) / 4
+		s.srtt = (7*s.srtt + rtt) / 8
+	}
+
+	variance := 4 * s.rttvar
+	if variance < flushInterval {
+		variance = flushInterval
+	}
+
+	s.rto = s.srtt + variance
+	if s.rto < minRTO {
+		s.rto = minRTO
+	} else if s.rto > maxRTO {
+		s.rto = maxRTO
+	}
+}
+
+// receive stores a sequenced segment and moves in-order segments to the read queue.
+// It reports whether the segment should be acknowledged.
+func (s *session) receive(h header, data []byte) bool {
+	diff := seqDiff(h.sn, s.rcvNxt)
+	if diff < 0 {
+		return true // duplicate, re-acknowledge
+	}
+	if diff >= rcvWnd || s.rcvQueue.Len() >= rcvWnd*mss {
+		return false
+	}
+
+	if _, ok := s.rcvBuf[h.sn]; !ok {
+		seg := &segment{cmd: h.cmd, sn: h.sn}
+		if len(data) > 0 {
+			seg.data = make([]byte, len(data))
+			copy(seg.data, data)
+		}
+		s.rcvBuf[h.sn] = seg
+	}
+
+	for {
+		seg, ok := s.rcvBuf[s.rcvNxt]
+		if !ok {
+			break
+		}
+		delete(s.rcvBuf, s.rcvNxt)
+		s.rcvNxt++
+
+		switch seg.cmd {
+		case cmdPush:
+			s.rcvQueue.Write(seg.data)
+		case cmdFin:
+			s.rcvFin = true
+		}
+	}
+	return true
+}
+
+// wndUnused returns the number of segments the receiver is still able to buffer.
+func (s *session) wndUnused() uint16 {
+	used := len(s.rcvBuf) + (s.rcvQueue.Len()+mss-1)/mss
+	if used >= rcvWnd {
+		return 0
+	}
+	return uint16(rcvWnd - used)
+}
+
+/*
+	<<< NET.CONN >>>
+*/
+
+// Read implements net.Conn
+func (s *session) Read(p []byte) (int, error) {
+	for {
+		s.mx.Lock()
+		switch {
+		case isDone(s.closed):
+			s.mx.Unlock()
+			return 0, io.ErrClosedPipe
+		case s.rDeadline.Closed():
+			s.mx.Unlock()
+			return 0, timeoutError{}
+		case s.rcvQueue.Len() > 0:
+			n, _ := s.rcvQueue.Read(p) //nolint:errcheck
+			if s.advWnd == 0 && s.wndUnused() > 0 {
+				s.output(cmdWnd, 0, nil)
+			}
+			s.mx.Unlock()
+			return n, nil
+		case s.rcvFin:
+			s.mx.Unlock()
+			return 0, io.EOF
+		case isDone(s.done):
+			err := s.err
+			s.mx.Unlock()
+			return 0, err
+		}
+		s.mx.Unlock()
+
+		select {
+		case <-s.readCh:
+		case <-s.closed:
+		case <-s.done:
+		case <-s.rDeadline.Wait():
+		}
+	}
+}
+
+// Write implements net.Conn
+func (s *session) Write(p []byte) (n int, err error) {
+	for {
+		s.mx.Lock()
+		switch {
+		case isDone(s.closed):
+			s.mx.Unlock()
+			return n, io.ErrClosedPipe
+		case isDone(s.done):
+			err := s.err
+			s.mx.Unlock()
+			return n, err
+		case s.wDeadline.Closed():
+			s.mx.Unlock()
+			return n, timeoutError{}
+		}
+
+		for len(p) > 0 && s.canPush() {
+			size := len(p)
+			if size > mss {
+				size = mss
+			}
+			s.push(cmdPush, p[:size])
+			n += size
+			p = p[size:]
+		}
+		s.flush()
+		s.mx.Unlock()
+
+		if len(p) == 0 {
+			return n, nil
+		}
+
+		select {
+		case <-s.writeCh:
+		case <-s.closed:
+		case <-s.done:
+		case <-s.wDeadline.Wait():
+		}
+	}
+}
+
+// Close implements net.Conn
+// Buffered segments are still delivered for up to 'lingerTimeout' after Close returns.
+func (s *session) Close() error {
+	s.closeOnce.Do(func() {
+		s.mx.Lock()
+		s.closedAt = time.Now()
+		if !isDone(s.done) {
+			s.push(cmdFin, nil)
+			s.flush()
+		}
+		s.mx.Unlock()
+
+		close(s.closed)
+	})
+	return nil
+}
+
+// LocalAddr implements net.Conn
+func (s *session) LocalAddr() net.Addr { return s.lAddr }
+
+// RemoteAddr implements net.Conn
+func (s *session) RemoteAddr() net.Addr { return s.rAddr }
+
+// SetDeadline implements net.Conn
+func (s *session) SetDeadline(t time.Time) error {
+	s.rDeadline.Set(t)
+	s.wDeadline.Set(t)
+	return nil
+}
+
+// SetReadDeadline implements net.Conn
+func (s *session) SetReadDeadline(t time.Time) error {
+	s.rDeadline.Set(t)
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn
+func (s *session) SetWriteDeadline(t time.Time) error {
+	s.wDeadline.Set(t)
+	return nil
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func isDone(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
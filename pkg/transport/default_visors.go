@@ -0,0 +1,179 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/google/uuid"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
+)
+
+// Constants associated with maintaining transports to default visors.
+const (
+	dvCheckInterval = time.Second * 10 // interval of checking an established transport
+	dvDialTimeout   = time.Second * 30
+	dvInitBO        = time.Second
+	dvMaxBO         = time.Minute * 2
+	dvFactor        = 2
+)
+
+var (
+	errNoDefaultVisorNetwork = errors.New("no network available to reach visor")
+	errDefaultVisorNotUp     = errors.New("transport is not up")
+)
+
+// States of a transport to a default visor.
+const (
+	DefaultVisorConnecting = "connecting"
+	DefaultVisorUp         = "up"
+	DefaultVisorBackoff    = "backoff"
+	DefaultVisorNoNetwork  = "no_network"
+)
+
+// DefaultVisorState describes the state of the transport which is maintained to a default visor.
+type DefaultVisorState struct {
+	PK        cipher.PubKey `json:"pk"`
+	State     string        `json:"state"`
+	Type      string        `json:"type,omitempty"` // network type of the last dial attempt
+	TpID      uuid.UUID     `json:"tp_id"`          // ID of the transport when up
+	Failures  int           `json:"failures"`       // consecutive failed dial attempts
+	LastError string        `json:"last_error,omitempty"`
+	NextDial  time.Time     `json:"next_dial"`
+}
+
+// DefaultVisorStates returns the states of transports to default visors, sorted by public key.
+func (tm *Manager) DefaultVisorStates() []DefaultVisorState {
+	tm.dvMx.Lock()
+	states := make([]DefaultVisorState, 0, len(tm.dvStates))
+	for _, s := range tm.dvStates {
+		states = append(states, *s)
+	}
+	tm.dvMx.Unlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].PK.Hex() < states[j].PK.Hex()
+	})
+	return states
+}
+
+func (tm *Manager) updateDefaultVisorState(pk cipher.PubKey, fn func(s *DefaultVisorState)) {
+	tm.dvMx.Lock()
+	s, ok := tm.dvStates[pk]
+	if !ok {
+		s = &DefaultVisorState{PK: pk}
+		tm.dvStates[pk] = s
+	}
+	fn(s)
+	tm.dvMx.Unlock()
+}
+
+// maintainDefaultVisors keeps a transport up to every default visor.
+func (tm *Manager) maintainDefaultVisors(ctx context.Context) {
+	for _, pk := range tm.Conf.DefaultVisors {
+		if pk == tm.Conf.PubKey {
+			continue
+		}
+
+		tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) { s.State = DefaultVisorConnecting })
+
+		// Not tracked by the wait group, as SaveTransport blocks on a closing manager.
+		go tm.maintainDefaultVisor(ctx, pk)
+	}
+}
+
+// maintainDefaultVisor dials a transport to 'pk' whenever there is none up.
+// Failed attempts are retried with exponential back-off.
+func (tm *Manager) maintainDefaultVisor(ctx context.Context, pk cipher.PubKey) {
+	log := tm.Logger.WithField("default_visor", pk)
+	bo := dvInitBO
+
+	for {
+		wait := dvCheckInterval
+
+		if mTp := tm.upTransport(pk); mTp != nil {
+			tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) {
+				s.State, s.Type, s.TpID = DefaultVisorUp, mTp.Type(), mTp.Entry.ID
+				s.Failures, s.LastError, s.NextDial = 0, "", time.Time{}
+			})
+			bo = dvInitBO
+		} else if err := tm.dialDefaultVisor(ctx, pk); err != nil {
+			log.WithError(err).Warnf("Failed to establish transport to default visor, retrying in %s.", bo)
+
+			wait = bo
+			tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) { s.NextDial = time.Now().Add(wait) })
+			if bo *= dvFactor; bo > dvMaxBO {
+				bo = dvMaxBO
+			}
+		} else {
+			log.Info("Established transport to default visor.")
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (tm *Manager) dialDefaultVisor(ctx context.Context, pk cipher.PubKey) error {
+	netType := tm.defaultVisorNetwork(pk)
+
+	if netType == "" {
+		tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) {
+			s.State, s.Type, s.TpID = DefaultVisorNoNetwork, "", uuid.UUID{}
+			s.LastError = errNoDefaultVisorNetwork.Error()
+		})
+		return errNoDefaultVisorNetwork
+	}
+
+	tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) {
+		s.State, s.Type, s.TpID = DefaultVisorConnecting, netType, uuid.UUID{}
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, dvDialTimeout)
+	defer cancel()
+
+	mTp, err := tm.SaveTransport(ctx, pk, netType)
+	if err == nil && !mTp.IsUp() {
+		err = errDefaultVisorNotUp
+	}
+	if err != nil {
+		tm.updateDefaultVisorState(pk, func(s *DefaultVisorState) {
+			s.Failures++
+			s.State, s.LastError = DefaultVisorBackoff, err.Error()
+		})
+	}
+	return err
+}
+
+// defaultVisorNetwork chooses the best network type to reach 'pk':
+// stcp if 'pk' is in the stcp pk table, dmsg otherwise.
+func (tm *Manager) defaultVisorNetwork(pk cipher.PubKey) string {
+	if _, ok := tm.nets[snet.STCPType]; ok && tm.n.STcp() != nil {
+		if _, ok := tm.n.STcp().Table().Addr(pk); ok {
+			return snet.STCPType
+		}
+	}
+	if _, ok := tm.nets[snet.DmsgType]; ok {
+		return snet.DmsgType
+	}
+	return ""
+}
+
+// upTransport returns a transport to 'pk' of any network type which is up, or nil.
+func (tm *Manager) upTransport(pk cipher.PubKey) *ManagedTransport {
+	for netType := range tm.nets {
+		if mTp := tm.Transport(tm.tpIDFromPK(pk, netType)); mTp != nil && mTp.IsUp() {
+			return mTp
+		}
+	}
+	return nil
+}
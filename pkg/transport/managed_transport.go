@@ -15,6 +15,7 @@ import (
 	"github.com/SkycoinProject/dmsg/httputil"
 	"github.com/SkycoinProject/dmsg/netutil"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/google/uuid"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
@@ -62,6 +63,7 @@ type ManagedTransport struct {
 	isUp    bool  // records last successful status update to discovery
 	isUpErr error // records whether the last status update was successful or not
 	isUpMux sync.Mutex
+	onDown  func(id uuid.UUID) // called when the status changes to DOWN
 
 	redialCancel context.CancelFunc // for canceling redialling logic
 	redialMx     sync.Mutex
@@ -202,6 +204,14 @@ func (mt *ManagedTransport) isServing() bool {
 	}
 }
 
+// IsUp returns true if the transport is served and has an underlying connection.
+func (mt *ManagedTransport) IsUp() bool {
+	mt.connMx.Lock()
+	defer mt.connMx.Unlock()
+
+	return mt.isServing() && mt.conn != nil
+}
+
 // Close implements io.Closer
 // It also waits for transport to stop serving before it returns.
 // It only returns an error if transport status update fails.
@@ -470,6 +480,11 @@ func (mt *ManagedTransport) updateStatus(isUp bool, tries int) (err error) {
 	mt.isUp = isUp
 	mt.isUpErr = err
 	mt.isUpMux.Unlock()
+
+	if !isUp && mt.onDown != nil {
+		mt.onDown(mt.Entry.ID)
+	}
+
 	return err
 }
 
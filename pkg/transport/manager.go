@@ -26,6 +26,7 @@ type ManagerConfig struct {
 	DefaultVisors   []cipher.PubKey // Visors to automatically connect to
 	DiscoveryClient DiscoveryClient
 	LogStore        LogStore
+	OnTransportDown func(id uuid.UUID) // Called when a transport which was up goes down
 }
 
 // Manager manages Transports.
@@ -36,6 +37,9 @@ type Manager struct {
 	tps    map[uuid.UUID]*ManagedTransport
 	n      *snet.Network
 
+	dvStates map[cipher.PubKey]*DefaultVisorState
+	dvMx     sync.Mutex
+
 	readCh    chan routing.Packet
 	mx        sync.RWMutex
 	wgMu      sync.Mutex
@@ -53,13 +57,14 @@ func NewManager(n *snet.Network, config *ManagerConfig) (*Manager, error) {
 		nets[netType] = struct{}{}
 	}
 	tm := &Manager{
-		Logger: logging.MustGetLogger("tp_manager"),
-		Conf:   config,
-		nets:   nets,
-		tps:    make(map[uuid.UUID]*ManagedTransport),
-		n:      n,
-		readCh: make(chan routing.Packet, 20),
-		done:   make(chan struct{}),
+		Logger:   logging.MustGetLogger("tp_manager"),
+		Conf:     config,
+		nets:     nets,
+		tps:      make(map[uuid.UUID]*ManagedTransport),
+		n:        n,
+		dvStates: make(map[cipher.PubKey]*DefaultVisorState),
+		readCh:   make(chan routing.Packet, 20),
+		done:     make(chan struct{}),
 	}
 	return tm, nil
 }
@@ -117,6 +122,18 @@ func (tm *Manager) serve(ctx context.Context) {
 	}
 
 	tm.initTransports(ctx)
+
+	tm.wgMu.Lock()
+	tm.wg.Add(1)
+	tm.wgMu.Unlock()
+
+	go func() {
+		defer tm.wg.Done()
+		tm.connectDiscoveredVisors(ctx)
+	}()
+
+	tm.maintainDefaultVisors(ctx)
+
 	tm.Logger.Info("transport manager is serving.")
 
 	// closing logic
@@ -156,6 +173,43 @@ func (tm *Manager) initTransports(ctx context.Context) {
 	}
 }
 
+// connectDiscoveredVisors establishes stcp transports to trusted visors discovered within the local network.
+func (tm *Manager) connectDiscoveredVisors(ctx context.Context) {
+	discovered := tm.n.LANDiscovery().Discovered()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.done:
+			return
+		case pk := <-discovered:
+			if !tm.isDefaultVisor(pk) {
+				tm.Logger.Debugf("Skipping discovered visor %s: not trusted", pk)
+				continue
+			}
+
+			// Not tracked by the wait group, as SaveTransport blocks on a closing manager.
+			go func(pk cipher.PubKey) {
+				if _, err := tm.SaveTransport(ctx, pk, snet.STCPType); err != nil {
+					tm.Logger.WithError(err).Warnf("Failed to establish stcp transport to discovered visor %s", pk)
+					return
+				}
+				tm.Logger.Infof("Established stcp transport to discovered visor %s", pk)
+			}(pk)
+		}
+	}
+}
+
+func (tm *Manager) isDefaultVisor(pk cipher.PubKey) bool {
+	for _, v := range tm.Conf.DefaultVisors {
+		if v == pk {
+			return true
+		}
+	}
+	return false
+}
+
 func (tm *Manager) acceptTransport(ctx context.Context, lis *snet.Listener) error {
 	conn, err := lis.AcceptConn() // TODO: tcp panic.
 	if err != nil {
@@ -180,6 +234,7 @@ func (tm *Manager) acceptTransport(ctx context.Context, lis *snet.Listener) erro
 		tm.Logger.Debugln("No TP found, creating new one")
 
 		mTp = NewManagedTransport(tm.n, tm.Conf.DiscoveryClient, tm.Conf.LogStore, conn.RemotePK(), lis.Network())
+		mTp.onDown = tm.Conf.OnTransportDown
 
 		go func() {
 			mTp.Serve(tm.readCh)
@@ -270,6 +325,7 @@ func (tm *Manager) saveTransport(remote cipher.PubKey, netName string) (*Managed
 	}
 
 	mTp := NewManagedTransport(tm.n, tm.Conf.DiscoveryClient, tm.Conf.LogStore, remote, netName)
+	mTp.onDown = tm.Conf.OnTransportDown
 	go func() {
 		mTp.Serve(tm.readCh)
 		tm.mx.Lock()
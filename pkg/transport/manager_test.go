@@ -12,6 +12,7 @@ import (
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet/snettest"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 
@@ -182,3 +183,50 @@ func TestMakeTransportID(t *testing.T) {
 		require.NotEqual(t, transport.MakeTransportID(keyA, keyA, "a"), transport.MakeTransportID(keyA, keyA, "b"))
 	})
 }
+
+func TestManager_DefaultVisors(t *testing.T) {
+	tpDisc := transport.NewDiscoveryMock()
+
+	keys := snettest.GenKeyPairs(2)
+	nEnv := snettest.NewEnv(t, keys, []string{dmsg.Type, snet.STCPType})
+	defer nEnv.Teardown()
+
+	pk0, sk0 := keys[0].PK, keys[0].SK
+	m0, err := transport.NewManager(nEnv.Nets[0], &transport.ManagerConfig{
+		PubKey:          pk0,
+		SecKey:          sk0,
+		DefaultVisors:   []cipher.PubKey{keys[1].PK},
+		DiscoveryClient: tpDisc,
+		LogStore:        transport.InMemoryTransportLogStore(),
+	})
+	require.NoError(t, err)
+	go m0.Serve(context.TODO())
+	defer func() { require.NoError(t, m0.Close()) }()
+
+	pk1, sk1 := keys[1].PK, keys[1].SK
+	m1, err := transport.NewManager(nEnv.Nets[1], &transport.ManagerConfig{
+		PubKey:          pk1,
+		SecKey:          sk1,
+		DiscoveryClient: tpDisc,
+		LogStore:        transport.InMemoryTransportLogStore(),
+	})
+	require.NoError(t, err)
+	go m1.Serve(context.TODO())
+	defer func() { require.NoError(t, m1.Close()) }()
+
+	// The default visor is in the stcp pk table, so stcp should be preferred over dmsg.
+	tpID := transport.MakeTransportID(pk0, pk1, snet.STCPType)
+	require.Eventually(t, func() bool {
+		states := m0.DefaultVisorStates()
+		return len(states) == 1 && states[0].State == transport.DefaultVisorUp
+	}, 10*time.Second, 50*time.Millisecond)
+
+	state := m0.DefaultVisorStates()[0]
+	assert.Equal(t, pk1, state.PK)
+	assert.Equal(t, snet.STCPType, state.Type)
+	assert.Equal(t, tpID, state.TpID)
+	assert.NotNil(t, m1.Transport(tpID))
+
+	// Managers without default visors report no states.
+	assert.Empty(t, m1.DefaultVisorStates())
+}
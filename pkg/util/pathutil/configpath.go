@@ -165,7 +165,7 @@ func WriteJSONConfig(conf interface{}, output string, replace bool) {
 		log.WithError(err).Fatalln("failed to create output directory")
 	}
 
-	if err := ioutil.WriteFile(output, raw, 0744); err != nil {
+	if err := ioutil.WriteFile(output, raw, 0600); err != nil {
 		log.WithError(err).Fatalln("failed to write file")
 	}
 
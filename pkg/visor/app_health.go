@@ -0,0 +1,58 @@
+package visor
+
+import (
+	"context"
+	"time"
+)
+
+// appHealthCheckInterval is how often health of running apps is checked.
+const appHealthCheckInterval = 5 * time.Second
+
+// monitorAppsHealth restarts apps which stopped sending heartbeats, until ctx is done.
+func (visor *Visor) monitorAppsHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			visor.restartStaleApps()
+		}
+	}
+}
+
+// restartStaleApps restarts running apps which stopped sending heartbeats.
+func (visor *Visor) restartStaleApps() {
+	for _, name := range visor.staleApps() {
+		visor.logger.Warnf("App %s stopped sending heartbeats", name)
+
+		if err := visor.RestartApp(name); err != nil {
+			visor.logger.WithError(err).Errorf("Failed to restart app %s", name)
+		}
+	}
+}
+
+// staleApps returns names of running apps which stopped sending heartbeats.
+func (visor *Visor) staleApps() []string {
+	visor.appsMu.RLock()
+	defer visor.appsMu.RUnlock()
+
+	var names []string
+
+	for name := range visor.appsConf {
+		if !visor.procManager.Exists(name) {
+			continue
+		}
+
+		health, err := visor.procManager.Health(name)
+		if err != nil || !health.Stale {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
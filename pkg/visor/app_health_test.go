@@ -0,0 +1,26 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
+)
+
+func TestVisor_StaleApps(t *testing.T) {
+	pm := &appserver.MockProcManager{}
+	pm.On("Exists", "foo").Return(true)
+	pm.On("Exists", "bar").Return(true)
+	pm.On("Exists", "baz").Return(false)
+	pm.On("Health", "foo").Return(appserver.Health{Status: appserver.HealthUnhealthy, Stale: true}, nil)
+	pm.On("Health", "bar").Return(appserver.Health{Status: appserver.HealthUnhealthy}, nil)
+
+	visor := &Visor{
+		appsConf:    map[string]AppConfig{"foo": {App: "foo"}, "bar": {App: "bar"}, "baz": {App: "baz"}},
+		procManager: pm,
+	}
+
+	// apps which report not being ready are not restarted
+	assert.Equal(t, []string{"foo"}, visor.staleApps())
+}
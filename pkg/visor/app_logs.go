@@ -0,0 +1,148 @@
+package visor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+)
+
+// appLogsSuffix is the suffix of the file with logs of an app, which is placed in the local directory.
+const appLogsSuffix = ".logs.db"
+
+// oldAppLogsSuffix is appended to the name of the file with logs kept by older versions, once it is imported.
+const oldAppLogsSuffix = ".imported"
+
+// MaxFollowTimeout is the longest time followAppLogs waits for new logs.
+const MaxFollowTimeout = time.Minute
+
+// appLogStore returns the log store of app 'appName', opening it if needed.
+func (visor *Visor) appLogStore(appName string) (app.LogStore, error) {
+	if err := validateAppName(appName); err != nil {
+		return nil, err
+	}
+
+	visor.appLogsMu.Lock()
+	defer visor.appLogsMu.Unlock()
+
+	if ls, ok := visor.appLogs[appName]; ok {
+		return ls, nil
+	}
+
+	dir, err := ensureDir(visor.localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ls, err := app.NewBoltLogStore(filepath.Join(dir, appName+appLogsSuffix), appName, visor.conf.AppLogStoreConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logs of %s: %v", appName, err)
+	}
+
+	visor.importOldAppLogs(ls, appName)
+
+	if visor.appLogs == nil {
+		visor.appLogs = make(map[string]app.LogStore)
+	}
+
+	visor.appLogs[appName] = ls
+
+	return ls, nil
+}
+
+// importOldAppLogs imports logs of app 'appName' kept by older versions of visor in the visor directory.
+// The old file is renamed once imported, so that logs are imported once.
+func (visor *Visor) importOldAppLogs(ls app.LogStore, appName string) {
+	path := filepath.Join(visor.dir(), appName)
+
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	n, err := app.ImportBoltLogs(ls, path, appName)
+	if err != nil {
+		visor.logger.WithError(err).Warnf("Failed to import old logs of %s from %s", appName, path)
+		return
+	}
+
+	if err := os.Rename(path, path+oldAppLogsSuffix); err != nil {
+		visor.logger.WithError(err).Warnf("Failed to rename imported logs of %s", appName)
+	}
+
+	visor.logger.Infof("Imported %d old logs of %s from %s", n, appName, path)
+}
+
+// closeAppLogStore closes the log store of app 'appName' if it is open.
+func (visor *Visor) closeAppLogStore(appName string) error {
+	visor.appLogsMu.Lock()
+	defer visor.appLogsMu.Unlock()
+
+	ls, ok := visor.appLogs[appName]
+	if !ok {
+		return nil
+	}
+
+	delete(visor.appLogs, appName)
+
+	return ls.Close()
+}
+
+func (visor *Visor) closeAppLogStores() {
+	visor.appLogsMu.Lock()
+	defer visor.appLogsMu.Unlock()
+
+	for name, ls := range visor.appLogs {
+		if err := ls.Close(); err != nil {
+			visor.logger.WithError(err).Warnf("Failed to close logs of %s", name)
+		}
+
+		delete(visor.appLogs, name)
+	}
+}
+
+// followAppLogs returns logs of app 'appName' matching 'q', waiting up to 'timeout' for new logs if there are none yet.
+func (visor *Visor) followAppLogs(appName string, q app.LogQuery, timeout time.Duration) (*app.LogsPage, error) {
+	ls, err := visor.appLogStore(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 || timeout > MaxFollowTimeout {
+		timeout = MaxFollowTimeout
+	}
+
+	// Subscribe before querying, so that logs stored in between are not missed.
+	logs, cancel := ls.Subscribe()
+	defer cancel()
+
+	page, err := ls.Logs(q)
+	if err != nil || len(page.Logs) > 0 {
+		return page, err
+	}
+
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), timeout)
+	defer cancelTimeout()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return page, nil
+		case e, ok := <-logs:
+			if !ok {
+				// The subscription fell behind or the store is closed, logs are queried again.
+				return ls.Logs(q)
+			}
+
+			if !e.Time.After(q.Since) {
+				continue
+			}
+
+			if page, err = ls.Logs(q); err != nil || len(page.Logs) > 0 {
+				return page, err
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package visor
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
+)
+
+func TestVisor_FollowAppLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app-logs")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	visor := &Visor{
+		conf:      &Config{AppLogs: &AppLogsConfig{MaxSize: 1 << 10}},
+		localPath: dir,
+		logger:    logging.MustGetLogger("visor"),
+	}
+	defer visor.closeAppLogStores()
+
+	_, err = visor.appLogStore("../foo")
+	assert.True(t, errors.Is(err, ErrInvalidAppName))
+
+	ls, err := visor.appLogStore("foo")
+	require.NoError(t, err)
+
+	same, err := visor.appLogStore("foo")
+	require.NoError(t, err)
+	assert.Equal(t, ls, same)
+
+	_, err = ls.Write([]byte("[2000-01-01T00:00:00Z] INFO foo\n"))
+	require.NoError(t, err)
+
+	// Existing logs are returned at once.
+	page, err := visor.followAppLogs("foo", app.LogQuery{}, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, page.Logs, 1)
+
+	since := page.Logs[0].Time
+
+	// No new logs within timeout.
+	page, err = visor.followAppLogs("foo", app.LogQuery{Since: since}, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, page.Logs)
+
+	// Logs below the level are skipped while waiting.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := ls.Write([]byte("[2000-01-01T00:00:00Z] DEBUG bar\n"))
+		assert.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = ls.Write([]byte("[2000-01-01T00:00:00Z] ERROR baz\n"))
+		assert.NoError(t, err)
+	}()
+
+	page, err = visor.followAppLogs("foo", app.LogQuery{Since: since, Level: "info"}, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, page.Logs, 1)
+	assert.Contains(t, page.Logs[0].Line, "baz")
+}
+
+func TestVisor_AppLogStore_importOld(t *testing.T) {
+	home, err := ioutil.TempDir("", "app-logs-home")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(home)) }()
+
+	defer func(home string) { require.NoError(t, os.Setenv("HOME", home)) }(os.Getenv("HOME"))
+	require.NoError(t, os.Setenv("HOME", home))
+
+	visor := &Visor{
+		conf:      &Config{KeyPair: NewKeyPair()},
+		localPath: filepath.Join(home, "local"),
+		logger:    logging.MustGetLogger("visor"),
+	}
+	defer visor.closeAppLogStores()
+
+	// Older versions kept logs in the visor directory, keyed by timestamps formatted as RFC3339Nano.
+	require.NoError(t, os.MkdirAll(visor.dir(), 0700))
+	oldPath := filepath.Join(visor.dir(), "foo")
+
+	start := time.Now().Add(-time.Hour).UTC()
+
+	db, err := bbolt.Open(oldPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("foo"))
+		if err != nil {
+			return err
+		}
+
+		for _, l := range []struct {
+			t    time.Time
+			line string
+		}{
+			{start.Add(900 * time.Millisecond), "second"},
+			{start, "first"},
+			{start.Add(time.Minute), "third"},
+		} {
+			if err := b.Put([]byte(l.t.Format(time.RFC3339Nano)), []byte(l.line)); err != nil {
+				return err
+			}
+		}
+
+		return b.Put([]byte("not a timestamp"), []byte("skipped"))
+	}))
+	require.NoError(t, db.Close())
+
+	ls, err := visor.appLogStore("foo")
+	require.NoError(t, err)
+
+	page, err := ls.Logs(app.LogQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Logs, 3)
+
+	for i, want := range []string{"first", "second", "third"} {
+		assert.Equal(t, want, page.Logs[i].Line)
+	}
+
+	assert.True(t, page.Logs[0].Time.Equal(start))
+
+	// The old file is imported once.
+	_, err = os.Stat(oldPath)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat(oldPath + oldAppLogsSuffix)
+	assert.NoError(t, err)
+}
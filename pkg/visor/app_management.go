@@ -0,0 +1,206 @@
+package visor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/util/rename"
+)
+
+// MaxAppBinarySize is the maximum size of an uploaded app binary.
+const MaxAppBinarySize = 64 << 20
+
+var (
+	// ErrAppExists is returned when an app of the same name is already configured.
+	ErrAppExists = errors.New("app already exists")
+
+	// ErrInvalidAppName is returned when an app name can not be used as a binary name.
+	ErrInvalidAppName = errors.New("invalid app name")
+
+	// ErrAppPortInUse is returned when a port is reserved or used by another app.
+	ErrAppPortInUse = errors.New("app port is already in use")
+
+	// ErrChecksumMismatch is returned when an uploaded app binary does not match its checksum.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrAppBinaryTooLarge is returned when an uploaded app binary exceeds MaxAppBinarySize.
+	ErrAppBinaryTooLarge = errors.New("app binary is too large")
+
+	// ErrUnknownAppProtocol is returned when an app is configured with an unknown protocol.
+	ErrUnknownAppProtocol = errors.New("unknown app protocol")
+
+	// ErrJSONAppServerDisabled is returned when an app speaks JSON-RPC, but app_server_json_addr is not set.
+	ErrJSONAppServerDisabled = errors.New("JSON-RPC app server is disabled, set app_server_json_addr")
+
+	// ErrUnixSocketProtocol is returned when an app speaking JSON-RPC is configured with a unix socket.
+	ErrUnixSocketProtocol = errors.New("unix socket app server only speaks gob")
+)
+
+// addApp registers a new app and saves it to config.
+func (visor *Visor) addApp(conf AppConfig) error {
+	if err := validateAppName(conf.App); err != nil {
+		return err
+	}
+
+	if err := validateAppProtocol(conf, visor.conf.AppServerJSONAddr); err != nil {
+		return err
+	}
+
+	visor.appsMu.Lock()
+	defer visor.appsMu.Unlock()
+
+	if _, ok := visor.appsConf[conf.App]; ok {
+		return ErrAppExists
+	}
+
+	if err := visor.checkAppPort(conf.App, conf.Port); err != nil {
+		return err
+	}
+
+	visor.logger.Infof("Adding app %v on port %d to config", conf.App, conf.Port)
+
+	prevApps := visor.conf.Apps
+	apps := append(make([]AppConfig, 0, len(prevApps)+1), prevApps...)
+	visor.conf.Apps = append(apps, conf)
+
+	if err := visor.conf.flush(); err != nil {
+		visor.conf.Apps = prevApps
+		return err
+	}
+
+	visor.appsConf[conf.App] = conf
+
+	return nil
+}
+
+// removeApp stops app 'appName' if it is running, and removes it from config.
+// The binary of the app is kept in the apps directory.
+func (visor *Visor) removeApp(appName string) error {
+	visor.appsMu.Lock()
+	defer visor.appsMu.Unlock()
+
+	if _, ok := visor.appsConf[appName]; !ok {
+		return ErrUnknownApp
+	}
+
+	if visor.procManager.Exists(appName) {
+		if err := visor.StopApp(appName); err != nil {
+			return fmt.Errorf("stop app %v: %w", appName, err)
+		}
+	}
+
+	visor.logger.Infof("Removing app %v from config", appName)
+
+	prevApps := visor.conf.Apps
+	apps := make([]AppConfig, 0, len(prevApps))
+
+	for _, app := range prevApps {
+		if app.App != appName {
+			apps = append(apps, app)
+		}
+	}
+
+	visor.conf.Apps = apps
+
+	if err := visor.conf.flush(); err != nil {
+		visor.conf.Apps = prevApps
+		return err
+	}
+
+	delete(visor.appsConf, appName)
+
+	if err := visor.closeAppLogStore(appName); err != nil {
+		visor.logger.WithError(err).Warnf("Failed to close logs of %s", appName)
+	}
+
+	return nil
+}
+
+// uploadApp saves binary of app 'appName' into the apps directory, after verifying its SHA256 'checksum'.
+// An existing binary is replaced atomically, running instances of the app are not affected.
+func (visor *Visor) uploadApp(appName string, binary []byte, checksum string) error {
+	if err := validateAppName(appName); err != nil {
+		return err
+	}
+
+	if len(binary) > MaxAppBinarySize {
+		return ErrAppBinaryTooLarge
+	}
+
+	sum := sha256.Sum256(binary)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return ErrChecksumMismatch
+	}
+
+	path := filepath.Join(visor.appsPath, appName)
+	tmpPath := path + ".tmp"
+
+	visor.logger.Infof("Saving binary of app %v to %s", appName, path)
+
+	if err := ioutil.WriteFile(tmpPath, binary, ownerRWX); err != nil {
+		return fmt.Errorf("failed to write binary of %s: %v", appName, err)
+	}
+
+	if err := rename.Rename(tmpPath, path); err != nil {
+		if rmErr := os.Remove(tmpPath); rmErr != nil {
+			visor.logger.WithError(rmErr).Warnf("Failed to remove %s", tmpPath)
+		}
+
+		return fmt.Errorf("failed to save binary of %s: %v", appName, err)
+	}
+
+	return nil
+}
+
+// checkAppPort checks that 'port' is neither reserved for nor used by apps other than 'appName'.
+// It should be called with appsMu held.
+func (visor *Visor) checkAppPort(appName string, port routing.Port) error {
+	if app, ok := reservedPorts[port]; ok && app != appName {
+		return fmt.Errorf("%w: port %d is reserved for %s", ErrAppPortInUse, port, app)
+	}
+
+	for _, app := range visor.appsConf {
+		if app.App != appName && app.Port == port {
+			return fmt.Errorf("%w: port %d is used by %s", ErrAppPortInUse, port, app.App)
+		}
+	}
+
+	return nil
+}
+
+// validateAppProtocol checks that the app protocol is known and that the app server speaks it.
+func validateAppProtocol(conf AppConfig, jsonServerAddr string) error {
+	switch conf.Protocol {
+	case "", AppProtocolGob:
+		return nil
+	case AppProtocolJSONRPC:
+		if conf.UnixSocket {
+			return ErrUnixSocketProtocol
+		}
+
+		if jsonServerAddr == "" {
+			return ErrJSONAppServerDisabled
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAppProtocol, conf.Protocol)
+	}
+}
+
+// validateAppName checks that app name is a plain file name, so that the app binary stays within the apps directory.
+func validateAppName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) ||
+		strings.HasSuffix(name, appManifestSuffix) {
+		return fmt.Errorf("%w: %q", ErrInvalidAppName, name)
+	}
+
+	return nil
+}
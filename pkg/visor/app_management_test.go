@@ -0,0 +1,113 @@
+package visor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
+)
+
+func TestVisor_AddRemoveApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app-management")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	confPath := filepath.Join(dir, "config.json")
+	apps := []AppConfig{{App: "foo", Port: 10}}
+
+	pm := &appserver.MockProcManager{}
+	pm.On("Exists", "foo").Return(true)
+	pm.On("Exists", "bar").Return(false)
+	pm.On("Stop", "foo").Return(nil)
+
+	visor := &Visor{
+		conf:        &Config{Path: &confPath, log: logging.MustGetLogger("config"), KeyPair: NewKeyPair(), Apps: apps},
+		appsConf:    map[string]AppConfig{"foo": apps[0]},
+		procManager: pm,
+		logger:      logging.MustGetLogger("visor"),
+	}
+
+	tests := []struct {
+		name string
+		conf AppConfig
+		err  error
+	}{
+		{"invalid name", AppConfig{App: "../bar", Port: 11}, ErrInvalidAppName},
+		{"app exists", AppConfig{App: "foo", Port: 11}, ErrAppExists},
+		{"reserved port", AppConfig{App: "bar", Port: 1}, ErrAppPortInUse},
+		{"port of another app", AppConfig{App: "bar", Port: 10}, ErrAppPortInUse},
+		{"unknown protocol", AppConfig{App: "bar", Port: 11, Protocol: "grpc"}, ErrUnknownAppProtocol},
+		{"json server disabled", AppConfig{App: "bar", Port: 11, Protocol: AppProtocolJSONRPC}, ErrJSONAppServerDisabled},
+		{"json over unix socket", AppConfig{App: "bar", Port: 11, Protocol: AppProtocolJSONRPC, UnixSocket: true},
+			ErrUnixSocketProtocol},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := visor.addApp(tc.conf)
+			assert.True(t, errors.Is(err, tc.err), err)
+		})
+	}
+
+	bar := AppConfig{App: "bar", Port: 11, Args: []string{"-v"}}
+	require.NoError(t, visor.addApp(bar))
+	assert.Equal(t, bar, visor.appsConf["bar"])
+	assert.Equal(t, []AppConfig{apps[0], bar}, visor.conf.Apps)
+
+	data, err := ioutil.ReadFile(confPath)
+	require.NoError(t, err)
+
+	var conf Config
+	require.NoError(t, json.Unmarshal(data, &conf))
+	assert.Equal(t, []AppConfig{apps[0], bar}, conf.Apps)
+
+	// Running apps are stopped.
+	require.NoError(t, visor.removeApp("foo"))
+	pm.AssertCalled(t, "Stop", "foo")
+	assert.Equal(t, []AppConfig{bar}, visor.conf.Apps)
+
+	_, ok := visor.appsConf["foo"]
+	assert.False(t, ok)
+
+	require.NoError(t, visor.removeApp("bar"))
+	assert.Empty(t, visor.conf.Apps)
+	assert.Equal(t, ErrUnknownApp, visor.removeApp("bar"))
+}
+
+func TestVisor_UploadApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app-upload")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	visor := &Visor{appsPath: dir, logger: logging.MustGetLogger("visor")}
+
+	binary := []byte("#!/bin/sh\necho foo\n")
+	sum := sha256.Sum256(binary)
+	checksum := hex.EncodeToString(sum[:])
+
+	assert.Equal(t, ErrChecksumMismatch, visor.uploadApp("foo", binary, checksum[1:]))
+	assert.True(t, errors.Is(visor.uploadApp("..", binary, checksum), ErrInvalidAppName))
+
+	_, err = os.Stat(filepath.Join(dir, "foo"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, visor.uploadApp("foo", binary, checksum))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	assert.Equal(t, binary, data)
+
+	info, err := os.Stat(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "binary should be executable")
+}
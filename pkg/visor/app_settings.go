@@ -0,0 +1,327 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+)
+
+// Types of app settings.
+const (
+	AppSettingString = "string"
+	AppSettingInt    = "int"
+	AppSettingBool   = "bool"
+	AppSettingPubKey = "pubkey"
+)
+
+// appManifestSuffix is the suffix of the manifest file of an app, which is placed next to its binary.
+const appManifestSuffix = ".manifest.json"
+
+var (
+	// ErrNoAppSettings is returned when an app does not declare settings.
+	ErrNoAppSettings = errors.New("app has no settings")
+
+	// ErrUnknownAppSetting is returned when a setting is not declared by the app.
+	ErrUnknownAppSetting = errors.New("unknown app setting")
+
+	// ErrInvalidAppSetting is returned when a value does not match the type of the setting.
+	ErrInvalidAppSetting = errors.New("invalid app setting")
+)
+
+// AppSetting declares a setting of an app, which is passed to the app as a command-line flag.
+type AppSetting struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Flag        string `json:"flag"` // name of the flag, without leading dash
+	Default     string `json:"default,omitempty"`
+	Secret      bool   `json:"secret,omitempty"` // values of secret settings are not returned
+	Description string `json:"description,omitempty"`
+}
+
+// AppManifest describes an app.
+type AppManifest struct {
+	Settings []AppSetting `json:"settings"`
+}
+
+// AppSettingValue is the value of an app setting.
+// Value is empty for secret settings, in which case IsSet tells whether the setting is set.
+type AppSettingValue struct {
+	AppSetting
+	Value string `json:"value,omitempty"`
+	IsSet bool   `json:"is_set"`
+}
+
+// builtinAppManifests are used for apps shipped with visor which have no manifest file.
+var builtinAppManifests = map[string]AppManifest{
+	skyenv.SkychatName: {
+		Settings: []AppSetting{
+			{Name: "addr", Type: AppSettingString, Flag: "addr", Default: skyenv.SkychatAddr, Description: "address to bind"},
+		},
+	},
+	skyenv.SkysocksName: {
+		Settings: []AppSetting{
+			{Name: "passcode", Type: AppSettingString, Flag: "passcode", Secret: true, Description: "passcode to authorize users"},
+		},
+	},
+	skyenv.SkysocksClientName: {
+		Settings: []AppSetting{
+			{Name: "pk", Type: AppSettingPubKey, Flag: "srv", Description: "public key of the server to connect to"},
+			{Name: "addr", Type: AppSettingString, Flag: "addr", Default: skyenv.SkysocksClientAddr, Description: "address to listen on"},
+		},
+	},
+}
+
+// Validate checks that settings are declared properly.
+func (m AppManifest) Validate() error {
+	names := make(map[string]struct{}, len(m.Settings))
+
+	for _, s := range m.Settings {
+		if s.Name == "" || s.Flag == "" {
+			return fmt.Errorf("setting %q: name and flag are required", s.Name)
+		}
+
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("setting %q is declared twice", s.Name)
+		}
+
+		names[s.Name] = struct{}{}
+
+		switch s.Type {
+		case AppSettingString, AppSettingInt, AppSettingBool, AppSettingPubKey:
+		default:
+			return fmt.Errorf("setting %q: unknown type %q", s.Name, s.Type)
+		}
+
+		if s.Default != "" {
+			if err := s.validateValue(s.Default); err != nil {
+				return fmt.Errorf("setting %q: invalid default: %v", s.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateSettings checks that 'settings' are declared by the manifest and their values match their types.
+func (m AppManifest) ValidateSettings(settings map[string]string) error {
+	for name, v := range settings {
+		s, ok := m.setting(name)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownAppSetting, name)
+		}
+
+		if err := s.validateValue(v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidAppSetting, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m AppManifest) setting(name string) (AppSetting, bool) {
+	for _, s := range m.Settings {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return AppSetting{}, false
+}
+
+func (s AppSetting) validateValue(v string) error {
+	var err error
+
+	switch s.Type {
+	case AppSettingString:
+	case AppSettingInt:
+		_, err = strconv.Atoi(v)
+	case AppSettingBool:
+		_, err = strconv.ParseBool(v)
+	case AppSettingPubKey:
+		var pk cipher.PubKey
+		err = pk.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q", s.Type, v)
+	}
+
+	return nil
+}
+
+// appManifest returns the manifest of app 'appName' from the apps directory,
+// or the built-in manifest of the app if it has no manifest file.
+func (visor *Visor) appManifest(appName string) (AppManifest, error) {
+	visor.appsMu.RLock()
+	_, ok := visor.appsConf[appName]
+	visor.appsMu.RUnlock()
+
+	if !ok {
+		return AppManifest{}, ErrUnknownApp
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(visor.appsPath, appName+appManifestSuffix))
+	if os.IsNotExist(err) {
+		manifest, ok := builtinAppManifests[appName]
+		if !ok {
+			return AppManifest{}, ErrNoAppSettings
+		}
+
+		return manifest, nil
+	}
+
+	if err != nil {
+		return AppManifest{}, fmt.Errorf("failed to read manifest of %s: %v", appName, err)
+	}
+
+	var manifest AppManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return AppManifest{}, fmt.Errorf("failed to parse manifest of %s: %v", appName, err)
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return AppManifest{}, fmt.Errorf("invalid manifest of %s: %v", appName, err)
+	}
+
+	return manifest, nil
+}
+
+// appSettings returns the settings of app 'appName', as set in its arguments.
+func (visor *Visor) appSettings(appName string) ([]AppSettingValue, error) {
+	manifest, err := visor.appManifest(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	visor.appsMu.RLock()
+	args := visor.appsConf[appName].Args
+	visor.appsMu.RUnlock()
+	out := make([]AppSettingValue, len(manifest.Settings))
+
+	for i, s := range manifest.Settings {
+		v, ok := getFlagArg(args, s.Flag, s.Type == AppSettingBool)
+		if !ok {
+			v = s.Default
+		}
+
+		out[i] = AppSettingValue{AppSetting: s, IsSet: ok}
+		if !s.Secret {
+			out[i].Value = v
+		}
+	}
+
+	return out, nil
+}
+
+// setAppSettings validates 'settings' of app 'appName' against its manifest, and saves them into its arguments.
+// The app is restarted if it is running and 'restart' is set.
+func (visor *Visor) setAppSettings(appName string, settings map[string]string, restart bool) error {
+	manifest, err := visor.appManifest(appName)
+	if err != nil {
+		return err
+	}
+
+	if err := manifest.ValidateSettings(settings); err != nil {
+		return err
+	}
+
+	setArgs := func(args []string) []string {
+		for name, v := range settings {
+			s, _ := manifest.setting(name)
+			args = setFlagArg(args, s.Flag, v, s.Type == AppSettingBool)
+		}
+
+		return args
+	}
+
+	visor.logger.Infof("Saving %d settings of app %v to config", len(settings), appName)
+
+	if err := visor.updateAppArgs(appName, setArgs); err != nil {
+		return err
+	}
+
+	if restart && visor.procManager.Exists(appName) {
+		visor.logger.Infof("Updated %v settings, restarting it", appName)
+		return visor.RestartApp(appName)
+	}
+
+	return nil
+}
+
+// getFlagArg returns the value of flag 'name' in 'args', given either as "-name value" or "-name=value".
+// A boolean flag given as "-name" is true, other flags given so take the next argument unless it is a flag.
+func getFlagArg(args []string, name string, isBool bool) (string, bool) {
+	for i, arg := range args {
+		flag, v, hasValue := parseFlagArg(arg)
+		if flag != name {
+			continue
+		}
+
+		if hasValue {
+			return v, true
+		}
+
+		if isBool {
+			return "true", true
+		}
+
+		if hasNextValue(args, i) {
+			return args[i+1], true
+		}
+	}
+
+	return "", false
+}
+
+// setFlagArg sets the value of flag 'name' in 'args'. The flag keeps the form it is given in,
+// except for boolean flags, which are always set as "-name=value", as the flag package expects.
+// A flag which is not given yet is appended as "-name=value".
+func setFlagArg(args []string, name, value string, isBool bool) []string {
+	for i, arg := range args {
+		flag, _, hasValue := parseFlagArg(arg)
+		if flag != name {
+			continue
+		}
+
+		if hasValue || isBool || !hasNextValue(args, i) {
+			args[i] = "-" + name + "=" + value
+			return args
+		}
+
+		args[i+1] = value
+
+		return args
+	}
+
+	return append(args, "-"+name+"="+value)
+}
+
+// hasNextValue tells whether the argument after args[i] may be the value of the flag args[i].
+func hasNextValue(args []string, i int) bool {
+	return i+1 < len(args) && !strings.HasPrefix(args[i+1], "-")
+}
+
+func parseFlagArg(arg string) (name, value string, hasValue bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", "", false
+	}
+
+	name = strings.TrimLeft(arg, "-")
+
+	if i := strings.Index(name, "="); i >= 0 {
+		return name[:i], name[i+1:], true
+	}
+
+	return name, "", false
+}
@@ -0,0 +1,147 @@
+package visor
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+)
+
+func TestFlagArgs(t *testing.T) {
+	args := []string{"-srv", "foo", "--addr=:1080", "-v"}
+
+	v, ok := getFlagArg(args, "srv", false)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+
+	v, ok = getFlagArg(args, "addr", false)
+	assert.True(t, ok)
+	assert.Equal(t, ":1080", v)
+
+	_, ok = getFlagArg(args, "passcode", false)
+	assert.False(t, ok)
+
+	args = setFlagArg(args, "srv", "bar", false)
+	args = setFlagArg(args, "addr", ":1081", false)
+	args = setFlagArg(args, "passcode", "baz", false)
+	assert.Equal(t, []string{"-srv", "bar", "-addr=:1081", "-v", "-passcode=baz"}, args)
+
+	// The argument after a bare flag is not its value if it is a flag, or if the flag is boolean.
+	args = []string{"-verbose", "-addr", ":1", "-debug", "arg"}
+
+	_, ok = getFlagArg(args, "verbose", false)
+	assert.False(t, ok)
+
+	v, ok = getFlagArg(args, "debug", true)
+	assert.True(t, ok)
+	assert.Equal(t, "true", v)
+
+	args = setFlagArg(args, "verbose", "false", true)
+	args = setFlagArg(args, "debug", "false", true)
+	assert.Equal(t, []string{"-verbose=false", "-addr", ":1", "-debug=false", "arg"}, args)
+}
+
+func TestAppManifest_Validate(t *testing.T) {
+	for name, manifest := range builtinAppManifests {
+		assert.NoError(t, manifest.Validate(), name)
+	}
+
+	tests := []struct {
+		name     string
+		settings []AppSetting
+	}{
+		{"no flag", []AppSetting{{Name: "a", Type: AppSettingString}}},
+		{"declared twice", []AppSetting{
+			{Name: "a", Type: AppSettingString, Flag: "a"},
+			{Name: "a", Type: AppSettingInt, Flag: "b"},
+		}},
+		{"unknown type", []AppSetting{{Name: "a", Type: "float", Flag: "a"}}},
+		{"invalid default", []AppSetting{{Name: "a", Type: AppSettingInt, Flag: "a", Default: "one"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, AppManifest{Settings: tc.settings}.Validate())
+		})
+	}
+}
+
+func TestAppManifest_ValidateSettings(t *testing.T) {
+	manifest := builtinAppManifests[skyenv.SkysocksClientName]
+
+	assert.NoError(t, manifest.ValidateSettings(map[string]string{"addr": ":1080"}))
+	assert.True(t, errors.Is(manifest.ValidateSettings(map[string]string{"port": "1"}), ErrUnknownAppSetting))
+	assert.True(t, errors.Is(manifest.ValidateSettings(map[string]string{"pk": "02ab"}), ErrInvalidAppSetting))
+}
+
+func TestVisor_AppSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app-settings")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	manifest := `{"settings": [
+		{"name": "port", "type": "int", "flag": "port", "default": "8000"},
+		{"name": "token", "type": "string", "flag": "token", "secret": true}
+	]}`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo"+appManifestSuffix), []byte(manifest), 0600))
+
+	confPath := filepath.Join(dir, "config.json")
+	apps := []AppConfig{
+		{App: "foo", Args: []string{"-token", "secret"}},
+		{App: skyenv.SkysocksClientName},
+		{App: "bar"},
+	}
+
+	pm := &appserver.MockProcManager{}
+	pm.On("Exists", "foo").Return(false)
+
+	visor := &Visor{
+		conf:        &Config{Path: &confPath, log: logging.MustGetLogger("config"), KeyPair: NewKeyPair(), Apps: apps},
+		appsConf:    make(map[string]AppConfig),
+		appsPath:    dir,
+		procManager: pm,
+		logger:      logging.MustGetLogger("visor"),
+	}
+
+	for _, app := range apps {
+		visor.appsConf[app.App] = app
+	}
+
+	settings, err := visor.appSettings("foo")
+	require.NoError(t, err)
+	require.Len(t, settings, 2)
+	assert.Equal(t, "8000", settings[0].Value)
+	assert.False(t, settings[0].IsSet)
+	assert.Empty(t, settings[1].Value)
+	assert.True(t, settings[1].IsSet)
+
+	err = visor.setAppSettings("foo", map[string]string{"port": "eighty"}, false)
+	assert.True(t, errors.Is(err, ErrInvalidAppSetting))
+
+	err = visor.setAppSettings("foo", map[string]string{"host": "localhost"}, false)
+	assert.True(t, errors.Is(err, ErrUnknownAppSetting))
+
+	require.NoError(t, visor.setAppSettings("foo", map[string]string{"port": "80", "token": "new"}, true))
+	assert.Equal(t, []string{"-token", "new", "-port=80"}, visor.appsConf["foo"].Args)
+	assert.Equal(t, []string{"-token", "new", "-port=80"}, visor.conf.Apps[0].Args)
+
+	// Apps without a manifest file fall back to built-in manifests.
+	pk, _ := cipher.GenerateKeyPair()
+	require.NoError(t, visor.setAppSettings(skyenv.SkysocksClientName, map[string]string{"pk": pk.String()}, false))
+	assert.Equal(t, []string{"-srv=" + pk.String()}, visor.appsConf[skyenv.SkysocksClientName].Args)
+
+	_, err = visor.appSettings("bar")
+	assert.Equal(t, ErrNoAppSettings, err)
+
+	_, err = visor.appSettings("baz")
+	assert.Equal(t, ErrUnknownApp, err)
+}
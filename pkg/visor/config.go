@@ -16,7 +16,10 @@ import (
 	"github.com/SkycoinProject/dmsg/dmsgpty"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/keystore"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routefinder/rfclient"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
@@ -41,6 +44,9 @@ const (
 var (
 	// ErrNoConfigPath is returned on attempt to read/write config when visor contains no config path.
 	ErrNoConfigPath = errors.New("no config path")
+
+	// ErrKeyStoreLocked is returned on attempt to use a secret key which is stored in a keystore that is not unlocked.
+	ErrKeyStoreLocked = errors.New("secret key is stored in a keystore that is not unlocked")
 )
 
 // Config defines configuration parameters for Visor.
@@ -54,11 +60,13 @@ type Config struct {
 	Dmsg          *snet.DmsgConfig     `json:"dmsg"`
 	DmsgPty       *DmsgPtyConfig       `json:"dmsg_pty,omitempty"`
 	STCP          *snet.STCPConfig     `json:"stcp,omitempty"`
+	SUDP          *snet.SUDPConfig     `json:"sudp,omitempty"`
 	Transport     *TransportConfig     `json:"transport"`
 	Routing       *RoutingConfig       `json:"routing"`
 	UptimeTracker *UptimeTrackerConfig `json:"uptime_tracker,omitempty"`
 
-	Apps []AppConfig `json:"apps"`
+	Apps    []AppConfig    `json:"apps"`
+	AppLogs *AppLogsConfig `json:"app_logs,omitempty"`
 
 	TrustedVisors []cipher.PubKey    `json:"trusted_visors"`
 	Hypervisors   []HypervisorConfig `json:"hypervisors"`
@@ -71,7 +79,8 @@ type Config struct {
 
 	Interfaces *InterfaceConfig `json:"interfaces"`
 
-	AppServerAddr string `json:"app_server_addr"`
+	AppServerAddr     string `json:"app_server_addr"`
+	AppServerJSONAddr string `json:"app_server_json_addr,omitempty"` // for apps speaking jsonrpc, disabled if empty
 
 	RestartCheckDelay string `json:"restart_check_delay,omitempty"`
 }
@@ -85,20 +94,26 @@ func (c *Config) flush() error {
 		return ErrNoConfigPath
 	}
 
-	c.log.Infof("Updating visor config to %#v", c)
+	c.log.Infof("Updating visor config at %s", *c.Path)
 
 	bytes, err := json.MarshalIndent(c, "", "\t")
 	if err != nil {
 		return err
 	}
 
-	const filePerm = 0644
+	// The config may contain the secret key, so it should be readable by the owner only.
+	const filePerm = 0600
 	return ioutil.WriteFile(*c.Path, bytes, filePerm)
 }
 
 // Keys returns visor public and secret keys extracted from config.
-// If they are not found, new keys are generated.
+// If they are not found, new keys are generated in memory. Configs loaded through MigrateConfig always contain keys.
+// If the secret key is stored in a keystore, the key pair is returned as is, see UnlockKeyStore.
 func (c *Config) Keys() *KeyPair {
+	if c.KeyPair != nil && c.KeyPair.KeyStore != "" {
+		return c.KeyPair
+	}
+
 	// If both keys are set, no additional action is needed.
 	if c.KeyPair != nil && !c.KeyPair.SecKey.Null() && !c.KeyPair.PubKey.Null() {
 		return c.KeyPair
@@ -120,11 +135,27 @@ func (c *Config) Keys() *KeyPair {
 		}
 	}
 
-	if err := c.flush(); err != nil && c.log != nil {
-		c.log.WithError(err).Errorf("Failed to flush config to disk")
+	return c.KeyPair
+}
+
+// UnlockKeyStore decrypts the secret key from the keystore referenced by the config.
+// The keystore must belong to the public key of the config.
+func (c *Config) UnlockKeyStore(passphrase []byte) error {
+	if c.KeyPair == nil || c.KeyPair.KeyStore == "" {
+		return errors.New("no keystore configured")
+	}
+
+	pk, sk, err := keystore.Unlock(c.KeyPair.KeyStore, passphrase)
+	if err != nil {
+		return err
+	}
+	if !c.KeyPair.PubKey.Null() && pk != c.KeyPair.PubKey {
+		return fmt.Errorf("keystore public key %s does not match config public key %s", pk, c.KeyPair.PubKey)
 	}
 
-	return c.KeyPair
+	c.KeyPair.PubKey = pk
+	c.KeyPair.SecKey = sk
+	return nil
 }
 
 // DmsgConfig extracts and returns DmsgConfig from Visor Config.
@@ -132,9 +163,6 @@ func (c *Config) Keys() *KeyPair {
 func (c *Config) DmsgConfig() *snet.DmsgConfig {
 	if c.Dmsg == nil {
 		c.Dmsg = DefaultDmsgConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return c.Dmsg
@@ -145,9 +173,6 @@ func (c *Config) DmsgConfig() *snet.DmsgConfig {
 func (c *Config) DmsgPtyHost(dmsgC *dmsg.Client) (*dmsgpty.Host, error) {
 	if c.DmsgPty == nil {
 		c.DmsgPty = DefaultDmsgPtyConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	var wl dmsgpty.Whitelist
@@ -177,9 +202,6 @@ func (c *Config) DmsgPtyHost(dmsgC *dmsg.Client) (*dmsgpty.Host, error) {
 func (c *Config) TransportDiscovery() (transport.DiscoveryClient, error) {
 	if c.Transport == nil {
 		c.Transport = DefaultTransportConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return trClient.NewHTTP(c.Transport.Discovery, c.Keys().PubKey, c.Keys().SecKey)
@@ -190,14 +212,8 @@ func (c *Config) TransportDiscovery() (transport.DiscoveryClient, error) {
 func (c *Config) TransportLogStore() (transport.LogStore, error) {
 	if c.Transport == nil {
 		c.Transport = DefaultTransportConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	} else if c.Transport.LogStore == nil {
 		c.Transport.LogStore = DefaultLogStoreConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	if c.Transport.LogStore.Type == LogStoreFile {
@@ -212,9 +228,6 @@ func (c *Config) TransportLogStore() (transport.LogStore, error) {
 func (c *Config) RoutingConfig() *RoutingConfig {
 	if c.Routing == nil {
 		c.Routing = DefaultRoutingConfig()
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return c.Routing
@@ -230,15 +243,29 @@ func (c *Config) AppsConfig() (map[string]AppConfig, error) {
 	return apps, nil
 }
 
+// AppLogStoreConfig returns retention of app logs, using defaults for unset values.
+func (c *Config) AppLogStoreConfig() app.LogStoreConfig {
+	conf := app.LogStoreConfig{MaxSize: app.DefaultLogMaxSize, MaxAge: app.DefaultLogMaxAge}
+
+	if c.AppLogs != nil {
+		if c.AppLogs.MaxSize > 0 {
+			conf.MaxSize = c.AppLogs.MaxSize
+		}
+
+		if c.AppLogs.MaxAge > 0 {
+			conf.MaxAge = time.Duration(c.AppLogs.MaxAge)
+		}
+	}
+
+	return conf
+}
+
 // AppsDir returns absolute path for directory with application binaries.
 // Directory will be created if necessary.
 // If it is not set in config, DefaultAppsPath is used.
 func (c *Config) AppsDir() (string, error) {
 	if c.AppsPath == "" {
 		c.AppsPath = DefaultAppsPath
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return ensureDir(c.AppsPath)
@@ -250,9 +277,6 @@ func (c *Config) AppsDir() (string, error) {
 func (c *Config) LocalDir() (string, error) {
 	if c.LocalPath == "" {
 		c.LocalPath = DefaultLocalPath
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return ensureDir(c.LocalPath)
@@ -263,14 +287,26 @@ func (c *Config) LocalDir() (string, error) {
 func (c *Config) AppServerAddress() string {
 	if c.AppServerAddr == "" {
 		c.AppServerAddr = appcommon.DefaultServerAddr
-		if err := c.flush(); err != nil && c.log != nil {
-			c.log.WithError(err).Errorf("Failed to flush config to disk")
-		}
 	}
 
 	return c.AppServerAddr
 }
 
+// updateSTCPTable applies 'fn' to the stcp pk table of the config and flushes the config.
+func (c *Config) updateSTCPTable(fn func(table map[cipher.PubKey]string)) error {
+	c.flushMu.Lock()
+	if c.STCP == nil {
+		c.STCP = &snet.STCPConfig{}
+	}
+	if c.STCP.PubKeyTable == nil {
+		c.STCP.PubKeyTable = make(map[cipher.PubKey]string)
+	}
+	fn(c.STCP.PubKeyTable)
+	c.flushMu.Unlock()
+
+	return c.flush()
+}
+
 func ensureDir(path string) (string, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -289,9 +325,28 @@ func ensureDir(path string) (string, error) {
 }
 
 // KeyPair defines Visor public and secret key pair.
+// If KeyStore is set, the secret key is stored encrypted in the keystore file instead of the config.
 type KeyPair struct {
-	PubKey cipher.PubKey `json:"public_key"`
-	SecKey cipher.SecKey `json:"secret_key"`
+	PubKey   cipher.PubKey `json:"public_key"`
+	SecKey   cipher.SecKey `json:"secret_key"`
+	KeyStore string        `json:"key_store,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// The secret key is omitted if it is stored in a keystore.
+func (kp KeyPair) MarshalJSON() ([]byte, error) {
+	if kp.KeyStore == "" {
+		type keyPair KeyPair
+		return json.Marshal(keyPair(kp))
+	}
+
+	return json.Marshal(struct {
+		PubKey   cipher.PubKey `json:"public_key"`
+		KeyStore string        `json:"key_store"`
+	}{
+		PubKey:   kp.PubKey,
+		KeyStore: kp.KeyStore,
+	})
 }
 
 // NewKeyPair returns a new public and secret key pair.
@@ -393,17 +448,37 @@ func DefaultLogStoreConfig() *LogStoreConfig {
 
 // RoutingConfig configures routing.
 type RoutingConfig struct {
-	SetupNodes         []cipher.PubKey `json:"setup_nodes,omitempty"`
-	RouteFinder        string          `json:"route_finder"`
-	RouteFinderTimeout Duration        `json:"route_finder_timeout,omitempty"`
+	SetupNodes          []cipher.PubKey `json:"setup_nodes,omitempty"`
+	RouteFinder         string          `json:"route_finder"`
+	RouteFinders        []string        `json:"route_finders,omitempty"` // failed over to, in order, if RouteFinder fails
+	RouteFinderTimeout  Duration        `json:"route_finder_timeout,omitempty"`
+	RouteFinderCacheTTL Duration        `json:"route_finder_cache_ttl,omitempty"`
+}
+
+// RouteFinderAddrs returns the addresses of all configured route finders, in order of preference.
+func (c *RoutingConfig) RouteFinderAddrs() []string {
+	addrs := make([]string, 0, len(c.RouteFinders)+1)
+	seen := make(map[string]struct{}, len(c.RouteFinders)+1)
+
+	for _, addr := range append([]string{c.RouteFinder}, c.RouteFinders...) {
+		if _, ok := seen[addr]; ok || addr == "" {
+			continue
+		}
+
+		seen[addr] = struct{}{}
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
 }
 
 // DefaultRoutingConfig returns default routing config.
 func DefaultRoutingConfig() *RoutingConfig {
 	return &RoutingConfig{
-		SetupNodes:         []cipher.PubKey{skyenv.MustPK(skyenv.DefaultSetupPK)},
-		RouteFinder:        skyenv.DefaultRouteFinderAddr,
-		RouteFinderTimeout: DefaultTimeout,
+		SetupNodes:          []cipher.PubKey{skyenv.MustPK(skyenv.DefaultSetupPK)},
+		RouteFinder:         skyenv.DefaultRouteFinderAddr,
+		RouteFinderTimeout:  DefaultTimeout,
+		RouteFinderCacheTTL: Duration(rfclient.DefaultCacheTTL),
 	}
 }
 
@@ -431,6 +506,24 @@ type AppConfig struct {
 	AutoStart bool         `json:"auto_start"`
 	Port      routing.Port `json:"port"`
 	Args      []string     `json:"args,omitempty"`
+
+	Limits *appcommon.Limits     `json:"limits,omitempty"` // resource limits, linux only
+	RunAs  *appcommon.Credential `json:"run_as,omitempty"` // uid and gid to run the app with, the visor user if not set
+
+	Protocol   string `json:"protocol,omitempty"`    // protocol spoken with the app server, AppProtocolGob if empty
+	UnixSocket bool   `json:"unix_socket,omitempty"` // serve the app on a unix socket in its local dir, linux only
+}
+
+// Protocols spoken by apps with the app server.
+const (
+	AppProtocolGob     = "gob"     // Go net/rpc, used by apps linking pkg/app
+	AppProtocolJSONRPC = "jsonrpc" // JSON-RPC 2.0, see pkg/app/appserver/JSONRPC.md
+)
+
+// AppLogsConfig defines retention of logs kept for each app.
+type AppLogsConfig struct {
+	MaxSize int64    `json:"max_size,omitempty"` // in bytes
+	MaxAge  Duration `json:"max_age,omitempty"`  // time value, examples: 24h, 168h, etc
 }
 
 // InterfaceConfig defines listening interfaces for skywire visor.
@@ -0,0 +1,191 @@
+package visor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
+)
+
+const (
+	// ConfigVersion is the current version of the visor config schema.
+	ConfigVersion = ConfigVersionV1
+
+	// ConfigVersionV0 is the version of configs generated before the schema was versioned.
+	// These either have an empty or a "1.0" version field.
+	ConfigVersionV0 = "v0"
+
+	// ConfigVersionV1 is the first versioned config schema.
+	// It has all defaults of optional sections filled in, so getters never need to patch the config.
+	ConfigVersionV1 = "v1"
+)
+
+// configMigration migrates a raw JSON config from one schema version to the next.
+type configMigration struct {
+	from    string
+	to      string
+	migrate func(raw []byte) ([]byte, error)
+}
+
+// configMigrations is the migration chain, ordered from the oldest version.
+var configMigrations = []configMigration{
+	{from: ConfigVersionV0, to: ConfigVersionV1, migrate: migrateConfigV0ToV1},
+}
+
+// ConfigMigration describes a single applied migration step.
+type ConfigMigration struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// String implements fmt.Stringer.
+func (m ConfigMigration) String() string {
+	return m.From + " -> " + m.To
+}
+
+// MigrateConfig migrates the raw JSON config to ConfigVersion.
+// It returns the migrated config alongside the applied migrations.
+// If the config is already of ConfigVersion, it is returned unchanged with no migrations.
+func MigrateConfig(raw []byte) ([]byte, []ConfigMigration, error) {
+	version, err := rawConfigVersion(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var applied []ConfigMigration
+
+	for version != ConfigVersion {
+		m, ok := findConfigMigration(version)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported config version %q", version)
+		}
+
+		if raw, err = m.migrate(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate config from %s to %s: %v", m.from, m.to, err)
+		}
+
+		applied = append(applied, ConfigMigration{From: m.from, To: m.to})
+		version = m.to
+	}
+
+	return raw, applied, nil
+}
+
+func rawConfigVersion(raw []byte) (string, error) {
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", fmt.Errorf("failed to decode config: %v", err)
+	}
+
+	switch v.Version {
+	case "", "1.0":
+		return ConfigVersionV0, nil
+	default:
+		return v.Version, nil
+	}
+}
+
+func findConfigMigration(from string) (configMigration, bool) {
+	for _, m := range configMigrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return configMigration{}, false
+}
+
+// migrateConfigV0ToV1 fills in the defaults which were previously patched in by the config getters.
+// The config is migrated as a generic JSON object rather than Config, so that fields unknown to or
+// reshaped by later schema versions are kept as they are.
+func migrateConfigV0ToV1(raw []byte) ([]byte, error) {
+	var c map[string]interface{}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+
+	if err := migrateKeyPairV0ToV1(c); err != nil {
+		return nil, err
+	}
+
+	setConfigDefault(c, "dmsg", DefaultDmsgConfig())
+	setConfigDefault(c, "transport", DefaultTransportConfig())
+	if transport, ok := c["transport"].(map[string]interface{}); ok {
+		setConfigDefault(transport, "log_store", DefaultLogStoreConfig())
+	}
+	setConfigDefault(c, "routing", DefaultRoutingConfig())
+	setConfigDefault(c, "apps_path", DefaultAppsPath)
+	setConfigDefault(c, "local_path", DefaultLocalPath)
+	setConfigDefault(c, "log_level", DefaultLogLevel)
+	setConfigDefault(c, "shutdown_timeout", DefaultTimeout)
+	setConfigDefault(c, "app_server_addr", appcommon.DefaultServerAddr)
+
+	c["version"] = ConfigVersionV1
+
+	return json.MarshalIndent(c, "", "\t")
+}
+
+// migrateKeyPairV0ToV1 generates a key pair if the config has none and fills in a missing public key.
+func migrateKeyPairV0ToV1(c map[string]interface{}) error {
+	kp, ok := c["key_pair"].(map[string]interface{})
+	if !ok {
+		c["key_pair"] = NewKeyPair()
+		return nil
+	}
+
+	if !isUnsetConfigValue(kp["key_store"]) {
+		return nil
+	}
+
+	var sk cipher.SecKey
+	if s, ok := kp["secret_key"].(string); ok && s != "" {
+		if err := sk.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid secret key: %v", err)
+		}
+	}
+
+	if sk.Null() {
+		c["key_pair"] = NewKeyPair()
+		return nil
+	}
+
+	var pk cipher.PubKey
+	if s, ok := kp["public_key"].(string); ok && s != "" {
+		if err := pk.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid public key: %v", err)
+		}
+	}
+
+	if pk.Null() {
+		var err error
+		if pk, err = sk.PubKey(); err != nil {
+			return fmt.Errorf("invalid secret key: %v", err)
+		}
+		kp["public_key"] = pk
+	}
+
+	return nil
+}
+
+// setConfigDefault sets 'key' of the JSON object 'obj' to 'value', unless it is already set.
+func setConfigDefault(obj map[string]interface{}, key string, value interface{}) {
+	if isUnsetConfigValue(obj[key]) {
+		obj[key] = value
+	}
+}
+
+// isUnsetConfigValue tells whether a decoded JSON value is missing, null or an empty string,
+// which v0 configs treated the same.
+func isUnsetConfigValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	default:
+		return false
+	}
+}
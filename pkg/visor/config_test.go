@@ -2,6 +2,7 @@ package visor
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -13,7 +14,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/SkycoinProject/skywire-mainnet/internal/httpauth"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/keystore"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 )
 
 func TestTransportDiscovery(t *testing.T) {
@@ -112,3 +116,165 @@ func TestLocalDir(t *testing.T) {
 	_, err = os.Stat(dir)
 	assert.NoError(t, err)
 }
+
+func TestRoutingConfig_RouteFinderAddrs(t *testing.T) {
+	conf := RoutingConfig{
+		RouteFinder:  "http://rf1",
+		RouteFinders: []string{"http://rf2", "http://rf1", "", "http://rf3"},
+	}
+	assert.Equal(t, []string{"http://rf1", "http://rf2", "http://rf3"}, conf.RouteFinderAddrs())
+
+	conf = RoutingConfig{RouteFinders: []string{"http://rf2"}}
+	assert.Equal(t, []string{"http://rf2"}, conf.RouteFinderAddrs())
+}
+
+func TestConfig_KeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	pk, sk := cipher.GenerateKeyPair()
+	passphrase := []byte("passphrase")
+	path := filepath.Join(dir, "keystore.json")
+
+	ks, err := keystore.Encrypt(sk, passphrase, keystore.Params{N: 16, R: 8, P: 1})
+	require.NoError(t, err)
+	require.NoError(t, ks.Save(path, false))
+
+	conf := Config{KeyPair: &KeyPair{PubKey: pk, KeyStore: path}}
+	assert.True(t, conf.Keys().SecKey.Null())
+
+	assert.Equal(t, keystore.ErrWrongPassphrase, conf.UnlockKeyStore([]byte("wrong")))
+	require.NoError(t, conf.UnlockKeyStore(passphrase))
+	assert.Equal(t, sk, conf.Keys().SecKey)
+
+	// The secret key should never be written to the config once it is stored in a keystore.
+	raw, err := json.Marshal(&conf)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret_key")
+	assert.NotContains(t, string(raw), sk.Hex())
+
+	t.Run("public key mismatch", func(t *testing.T) {
+		otherPK, _ := cipher.GenerateKeyPair()
+		conf := Config{KeyPair: &KeyPair{PubKey: otherPK, KeyStore: path}}
+		assert.Error(t, conf.UnlockKeyStore(passphrase))
+	})
+}
+
+func TestMigrateConfig(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	v0 := []byte(`{
+	"version": "1.0",
+	"key_pair": {"secret_key": "` + sk.Hex() + `"},
+	"apps": [{"app": "skychat", "auto_start": true, "port": 1}],
+	"unknown_field": "kept"
+}`)
+
+	raw, migrations, err := MigrateConfig(v0)
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigMigration{{From: ConfigVersionV0, To: ConfigVersionV1}}, migrations)
+
+	var conf Config
+	require.NoError(t, json.Unmarshal(raw, &conf))
+	assert.Equal(t, ConfigVersionV1, conf.Version)
+	assert.Equal(t, pk, conf.KeyPair.PubKey)
+	assert.Equal(t, DefaultDmsgConfig(), conf.Dmsg)
+	assert.Equal(t, DefaultTransportConfig(), conf.Transport)
+	assert.Equal(t, DefaultRoutingConfig(), conf.Routing)
+	assert.Equal(t, DefaultAppsPath, conf.AppsPath)
+	assert.Len(t, conf.Apps, 1)
+	assert.NoError(t, conf.Validate())
+	assert.Contains(t, string(raw), `"unknown_field": "kept"`)
+
+	// A config of the current version is left as is.
+	again, migrations, err := MigrateConfig(raw)
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+	assert.Equal(t, raw, again)
+
+	_, _, err = MigrateConfig([]byte(`{"version": "v100"}`))
+	assert.Error(t, err)
+}
+
+// TestMigrateConfig_golden migrates a config generated by skywire-cli before the schema was versioned.
+func TestMigrateConfig_golden(t *testing.T) {
+	v0, err := ioutil.ReadFile(filepath.Join("testdata", "config_v0.json"))
+	require.NoError(t, err)
+
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "config_v1.golden.json"))
+	require.NoError(t, err)
+
+	raw, migrations, err := MigrateConfig(v0)
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigMigration{{From: ConfigVersionV0, To: ConfigVersionV1}}, migrations)
+	assert.JSONEq(t, string(want), string(raw))
+
+	var conf Config
+	require.NoError(t, json.Unmarshal(raw, &conf))
+	assert.NoError(t, conf.Validate())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	conf := Config{
+		Version: ConfigVersion,
+		KeyPair: &KeyPair{PubKey: pk},
+		Dmsg:    &snet.DmsgConfig{Discovery: "dmsg.discovery"},
+		Transport: &TransportConfig{
+			Discovery: skyenv.DefaultTpDiscAddr,
+			LogStore:  &LogStoreConfig{Type: "disk"},
+		},
+		Apps: []AppConfig{
+			{App: "skychat", Port: 1},
+			{App: "skysocks", Port: 1},
+			{App: "vpn", Port: 2, Protocol: AppProtocolJSONRPC},
+		},
+		LogLevel:      "verbose",
+		AppServerAddr: "localhost",
+	}
+
+	err := conf.Validate()
+	require.Error(t, err)
+
+	verr, ok := err.(ValidationError)
+	require.True(t, ok)
+
+	fields := make([]string, len(verr))
+	for i, fe := range verr {
+		fields[i] = fe.Field
+	}
+	assert.Equal(t, []string{
+		"key_pair.secret_key",
+		"dmsg.discovery",
+		"dmsg.sessions_count",
+		"transport.log_store.type",
+		"routing",
+		"apps[1].port",
+		"apps[2].protocol",
+		"apps_path",
+		"local_path",
+		"log_level",
+		"app_server_addr",
+	}, fields)
+}
+
+func TestConfig_Validate_routeFinders(t *testing.T) {
+	raw, err := ioutil.ReadFile(filepath.Join("testdata", "config_v1.golden.json"))
+	require.NoError(t, err)
+
+	var conf Config
+	require.NoError(t, json.Unmarshal(raw, &conf))
+
+	conf.Routing.RouteFinder = ""
+	conf.Routing.RouteFinders = []string{"http://rf1", "http://rf2"}
+	assert.NoError(t, conf.Validate())
+
+	conf.Routing.RouteFinders = nil
+	err = conf.Validate()
+	require.Error(t, err)
+	assert.Equal(t, ValidationError{{
+		Field:  "routing.route_finder",
+		Reason: "is required unless routing.route_finders is set",
+	}}, err)
+}
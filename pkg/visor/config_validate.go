@@ -0,0 +1,247 @@
+package visor
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SkycoinProject/dmsg/cipher"
+	"github.com/SkycoinProject/skycoin/src/util/logging"
+
+	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+)
+
+// FieldError describes an invalid config field.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Error implements error.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// ValidationError lists every invalid field of a config.
+type ValidationError []FieldError
+
+// Error implements error.
+func (e ValidationError) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("invalid config (%d errors):", len(e)))
+	for _, fe := range e {
+		lines = append(lines, "\t- "+fe.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// configValidator collects field errors.
+type configValidator struct {
+	errs ValidationError
+}
+
+func (v *configValidator) addf(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *configValidator) required(field string, ok bool) bool {
+	if !ok {
+		v.addf(field, "is required")
+	}
+	return ok
+}
+
+func (v *configValidator) pk(field string, pk cipher.PubKey) {
+	if pk.Null() {
+		v.addf(field, "public key is null")
+	}
+}
+
+func (v *configValidator) url(field, s string) {
+	if !v.required(field, s != "") {
+		return
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		v.addf(field, "invalid url: %v", err)
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(field, "url %q should be of the form http(s)://host[:port]", s)
+	}
+}
+
+func (v *configValidator) hostPort(field, s string) {
+	if !v.required(field, s != "") {
+		return
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		v.addf(field, "invalid address: %v", err)
+	}
+}
+
+func (v *configValidator) pkTable(field string, table map[cipher.PubKey]string) {
+	for pk, addr := range table {
+		v.pk(fmt.Sprintf("%s[%s]", field, pk), pk)
+		v.hostPort(fmt.Sprintf("%s[%s]", field, pk), addr)
+	}
+}
+
+// Validate checks the config and returns a ValidationError listing every invalid field, or nil.
+// The config is expected to be of ConfigVersion, see MigrateConfig.
+func (c *Config) Validate() error {
+	var v configValidator
+
+	if c.Version != ConfigVersion {
+		v.addf("version", "expected %q, got %q (the config should be migrated)", ConfigVersion, c.Version)
+	}
+
+	if v.required("key_pair", c.KeyPair != nil) {
+		v.pk("key_pair.public_key", c.KeyPair.PubKey)
+		if c.KeyPair.KeyStore == "" {
+			if c.KeyPair.SecKey.Null() {
+				v.addf("key_pair.secret_key", "is required unless key_pair.key_store is set")
+			} else if pk, err := c.KeyPair.SecKey.PubKey(); err != nil {
+				v.addf("key_pair.secret_key", "invalid secret key: %v", err)
+			} else if pk != c.KeyPair.PubKey {
+				v.addf("key_pair.secret_key", "does not match key_pair.public_key")
+			}
+		}
+	}
+
+	if v.required("dmsg", c.Dmsg != nil) {
+		v.url("dmsg.discovery", c.Dmsg.Discovery)
+		if c.Dmsg.SessionsCount < 1 {
+			v.addf("dmsg.sessions_count", "should be at least 1")
+		}
+	}
+
+	if c.DmsgPty != nil {
+		v.required("dmsg_pty.cli_network", c.DmsgPty.CLINet != "")
+		v.required("dmsg_pty.cli_address", c.DmsgPty.CLIAddr != "")
+	}
+
+	if c.STCP != nil {
+		if c.STCP.LocalAddr != "" {
+			v.hostPort("stcp.local_address", c.STCP.LocalAddr)
+		}
+		v.pkTable("stcp.pk_table", c.STCP.PubKeyTable)
+	}
+
+	if c.SUDP != nil {
+		if c.SUDP.LocalAddr != "" {
+			v.hostPort("sudp.local_address", c.SUDP.LocalAddr)
+		}
+		v.pkTable("sudp.pk_table", c.SUDP.PubKeyTable)
+	}
+
+	if v.required("transport", c.Transport != nil) {
+		v.url("transport.discovery", c.Transport.Discovery)
+		if v.required("transport.log_store", c.Transport.LogStore != nil) {
+			switch c.Transport.LogStore.Type {
+			case LogStoreFile:
+				v.required("transport.log_store.location", c.Transport.LogStore.Location != "")
+			case LogStoreMemory:
+			default:
+				v.addf("transport.log_store.type", "should be %q or %q, got %q",
+					LogStoreFile, LogStoreMemory, c.Transport.LogStore.Type)
+			}
+		}
+	}
+
+	if v.required("routing", c.Routing != nil) {
+		if len(c.Routing.RouteFinderAddrs()) == 0 {
+			v.addf("routing.route_finder", "is required unless routing.route_finders is set")
+		} else if c.Routing.RouteFinder != "" {
+			v.url("routing.route_finder", c.Routing.RouteFinder)
+		}
+		for i, pk := range c.Routing.SetupNodes {
+			v.pk(fmt.Sprintf("routing.setup_nodes[%d]", i), pk)
+		}
+		if c.Routing.RouteFinderTimeout < 0 {
+			v.addf("routing.route_finder_timeout", "should not be negative")
+		}
+		for i, addr := range c.Routing.RouteFinders {
+			v.url(fmt.Sprintf("routing.route_finders[%d]", i), addr)
+		}
+		if c.Routing.RouteFinderCacheTTL < 0 {
+			v.addf("routing.route_finder_cache_ttl", "should not be negative")
+		}
+	}
+
+	if c.UptimeTracker != nil {
+		v.url("uptime_tracker.addr", c.UptimeTracker.Addr)
+	}
+
+	names := make(map[string]int, len(c.Apps))
+	ports := make(map[routing.Port]string, len(c.Apps))
+	for i, app := range c.Apps {
+		field := fmt.Sprintf("apps[%d]", i)
+		if v.required(field+".app", app.App != "") {
+			if j, ok := names[app.App]; ok {
+				v.addf(field+".app", "duplicates the name of apps[%d]", j)
+			}
+			names[app.App] = i
+		}
+		if v.required(field+".port", app.Port != 0) {
+			if other, ok := ports[app.Port]; ok {
+				v.addf(field+".port", "port %d is already used by %q", app.Port, other)
+			}
+			ports[app.Port] = app.App
+		}
+		if err := validateAppProtocol(app, c.AppServerJSONAddr); err != nil {
+			v.addf(field+".protocol", "%v", err)
+		}
+	}
+
+	if c.AppLogs != nil {
+		if c.AppLogs.MaxSize < 0 {
+			v.addf("app_logs.max_size", "should not be negative")
+		}
+		if c.AppLogs.MaxAge < 0 {
+			v.addf("app_logs.max_age", "should not be negative")
+		}
+	}
+
+	for i, pk := range c.TrustedVisors {
+		v.pk(fmt.Sprintf("trusted_visors[%d]", i), pk)
+	}
+
+	for i, hv := range c.Hypervisors {
+		v.pk(fmt.Sprintf("hypervisors[%d].public_key", i), hv.PubKey)
+	}
+
+	v.required("apps_path", c.AppsPath != "")
+	v.required("local_path", c.LocalPath != "")
+
+	if _, err := logging.LevelFromString(c.LogLevel); err != nil {
+		v.addf("log_level", "%v", err)
+	}
+
+	if c.ShutdownTimeout < 0 {
+		v.addf("shutdown_timeout", "should not be negative")
+	}
+
+	if c.Interfaces != nil && c.Interfaces.RPCAddress != "" {
+		v.hostPort("interfaces.rpc", c.Interfaces.RPCAddress)
+	}
+
+	v.hostPort("app_server_addr", c.AppServerAddr)
+
+	if c.AppServerJSONAddr != "" {
+		v.hostPort("app_server_json_addr", c.AppServerJSONAddr)
+	}
+
+	if c.RestartCheckDelay != "" {
+		if _, err := time.ParseDuration(c.RestartCheckDelay); err != nil {
+			v.addf("restart_check_delay", "%v", err)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
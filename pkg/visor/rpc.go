@@ -7,7 +7,7 @@ import (
 	"net/http"
 	"net/rpc"
 	"os"
-	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/SkycoinProject/dmsg/cipher"
@@ -16,6 +16,7 @@ import (
 
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/setup/setupclient"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/buildinfo"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/rpcutil"
@@ -64,9 +65,10 @@ func newRPCServer(v *Visor, remoteName string) (*rpc.Server, error) {
 
 // HealthInfo carries information about visor's external services health represented as http status codes
 type HealthInfo struct {
-	TransportDiscovery int `json:"transport_discovery"`
-	RouteFinder        int `json:"route_finder"`
-	SetupNode          int `json:"setup_node"`
+	TransportDiscovery int                      `json:"transport_discovery"`
+	RouteFinder        int                      `json:"route_finder"`
+	SetupNode          int                      `json:"setup_node"`
+	SetupNodes         []setupclient.NodeStatus `json:"setup_nodes,omitempty"` // status of every configured setup node
 }
 
 // Health returns health information about the visor
@@ -81,12 +83,20 @@ func (r *RPC) Health(_ *struct{}, out *HealthInfo) (err error) {
 		out.TransportDiscovery = http.StatusNotFound
 	}
 
-	if r.visor.conf.RoutingConfig().RouteFinder == "" {
+	if len(r.visor.conf.RoutingConfig().RouteFinderAddrs()) == 0 {
 		out.RouteFinder = http.StatusNotFound
 	}
 
-	if len(r.visor.conf.RoutingConfig().SetupNodes) == 0 {
+	setupNodes := r.visor.conf.RoutingConfig().SetupNodes
+
+	switch {
+	case len(setupNodes) == 0:
 		out.SetupNode = http.StatusNotFound
+	case r.visor.setupPool != nil:
+		out.SetupNodes = r.visor.setupPool.Status(setupNodes)
+		if !r.visor.setupPool.Available(setupNodes) {
+			out.SetupNode = http.StatusServiceUnavailable
+		}
 	}
 
 	return nil
@@ -120,7 +130,7 @@ type AppLogsRequest struct {
 func (r *RPC) LogsSince(in *AppLogsRequest, out *[]string) (err error) {
 	defer rpcutil.LogCall(r.log, "LogsSince", in)(out, &err)
 
-	ls, err := app.NewLogStore(filepath.Join(r.visor.dir(), in.AppName), in.AppName, "bbolt")
+	ls, err := r.visor.appLogStore(in.AppName)
 	if err != nil {
 		return err
 	}
@@ -134,6 +144,44 @@ func (r *RPC) LogsSince(in *AppLogsRequest, out *[]string) (err error) {
 	return nil
 }
 
+// AppLogsIn is input for AppLogs and FollowAppLogs.
+type AppLogsIn struct {
+	AppName string
+	Query   app.LogQuery
+	Timeout time.Duration // how long FollowAppLogs waits for new logs, up to MaxFollowTimeout
+}
+
+// AppLogs returns a page of logs of an app.
+func (r *RPC) AppLogs(in *AppLogsIn, out *app.LogsPage) (err error) {
+	defer rpcutil.LogCall(r.log, "AppLogs", in)(nil, &err)
+
+	ls, err := r.visor.appLogStore(in.AppName)
+	if err != nil {
+		return err
+	}
+
+	page, err := ls.Logs(in.Query)
+	if err != nil {
+		return err
+	}
+
+	*out = *page
+	return nil
+}
+
+// FollowAppLogs returns a page of logs of an app, waiting for new logs if there are none yet.
+func (r *RPC) FollowAppLogs(in *AppLogsIn, out *app.LogsPage) (err error) {
+	defer rpcutil.LogCall(r.log, "FollowAppLogs", in)(nil, &err)
+
+	page, err := r.visor.followAppLogs(in.AppName, in.Query, in.Timeout)
+	if err != nil {
+		return err
+	}
+
+	*out = *page
+	return nil
+}
+
 /*
 	<<< NODE SUMMARY >>>
 */
@@ -233,18 +281,54 @@ func (r *RPC) SetAutoStart(in *SetAutoStartIn, _ *struct{}) (err error) {
 	return r.visor.setAutoStart(in.AppName, in.AutoStart)
 }
 
-// SetSocksPassword sets password for skysocks.
-func (r *RPC) SetSocksPassword(in *string, _ *struct{}) (err error) {
-	defer rpcutil.LogCall(r.log, "SetSocksPassword", in)(nil, &err)
+// GetAppSettings returns the settings of an app, as declared by its manifest.
+func (r *RPC) GetAppSettings(name *string, out *[]AppSettingValue) (err error) {
+	defer rpcutil.LogCall(r.log, "GetAppSettings", name)(out, &err)
+
+	*out, err = r.visor.appSettings(*name)
+	return err
+}
+
+// SetAppSettingsIn is input for SetAppSettings.
+type SetAppSettingsIn struct {
+	AppName  string
+	Settings map[string]string
+	Restart  bool // restart the app if it is running
+}
+
+// SetAppSettings validates and saves settings of an app.
+func (r *RPC) SetAppSettings(in *SetAppSettingsIn, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "SetAppSettings", in.AppName)(nil, &err)
+
+	return r.visor.setAppSettings(in.AppName, in.Settings, in.Restart)
+}
+
+// AddApp registers a new app.
+func (r *RPC) AddApp(in *AppConfig, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "AddApp", in)(nil, &err)
 
-	return r.visor.setSocksPassword(*in)
+	return r.visor.addApp(*in)
 }
 
-// SetSocksClientPK sets PK for skysocks-client.
-func (r *RPC) SetSocksClientPK(in *cipher.PubKey, _ *struct{}) (err error) {
-	defer rpcutil.LogCall(r.log, "SetSocksClientPK", in)(nil, &err)
+// RemoveApp stops and unregisters an app.
+func (r *RPC) RemoveApp(name *string, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "RemoveApp", name)(nil, &err)
 
-	return r.visor.setSocksClientPK(*in)
+	return r.visor.removeApp(*name)
+}
+
+// UploadAppIn is input for UploadApp.
+type UploadAppIn struct {
+	AppName  string
+	Binary   []byte
+	Checksum string // hex-encoded SHA256 of Binary
+}
+
+// UploadApp saves an app binary into the apps directory.
+func (r *RPC) UploadApp(in *UploadAppIn, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "UploadApp", in.AppName)(nil, &err)
+
+	return r.visor.uploadApp(in.AppName, in.Binary, in.Checksum)
 }
 
 /*
@@ -390,6 +474,59 @@ func (r *RPC) DiscoverTransportByID(id *uuid.UUID, out *transport.EntryWithStatu
 	return nil
 }
 
+/*
+	<<< STCP PK TABLE >>>
+*/
+
+// STCPEntry is an entry of the stcp pk table.
+type STCPEntry struct {
+	PK   cipher.PubKey `json:"pk"`
+	Addr string        `json:"addr"`
+}
+
+// STCPTable obtains the entries of the stcp pk table.
+func (r *RPC) STCPTable(_ *struct{}, out *[]STCPEntry) (err error) {
+	defer rpcutil.LogCall(r.log, "STCPTable", nil)(out, &err)
+
+	table, err := r.visor.stcpTable()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]STCPEntry, 0, len(table))
+	for pk, addr := range table {
+		entries = append(entries, STCPEntry{PK: pk, Addr: addr})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PK.Hex() < entries[j].PK.Hex()
+	})
+
+	*out = entries
+	return nil
+}
+
+// AddSTCPEntry adds an entry to the stcp pk table and saves it to the config.
+func (r *RPC) AddSTCPEntry(in *STCPEntry, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "AddSTCPEntry", in)(nil, &err)
+
+	return r.visor.addSTCPEntry(in.PK, in.Addr)
+}
+
+// RemoveSTCPEntry removes an entry from the stcp pk table and the config.
+func (r *RPC) RemoveSTCPEntry(pk *cipher.PubKey, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "RemoveSTCPEntry", pk)(nil, &err)
+
+	return r.visor.removeSTCPEntry(*pk)
+}
+
+// TrustedVisors obtains the states of the transports which are maintained to trusted visors.
+func (r *RPC) TrustedVisors(_ *struct{}, out *[]transport.DefaultVisorState) (err error) {
+	defer rpcutil.LogCall(r.log, "TrustedVisors", nil)(out, &err)
+
+	*out = r.visor.tm.DefaultVisorStates()
+	return nil
+}
+
 /*
 	<<< ROUTES MANAGEMENT >>>
 */
@@ -1,12 +1,15 @@
 package visor
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"net/rpc"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,9 +43,14 @@ type RPCClient interface {
 	StartApp(appName string) error
 	StopApp(appName string) error
 	SetAutoStart(appName string, autostart bool) error
-	SetSocksPassword(password string) error
-	SetSocksClientPK(pk cipher.PubKey) error
+	GetAppSettings(appName string) ([]AppSettingValue, error)
+	SetAppSettings(appName string, settings map[string]string, restart bool) error
+	AddApp(conf AppConfig) error
+	RemoveApp(appName string) error
+	UploadApp(appName string, binary []byte, checksum string) error
 	LogsSince(timestamp time.Time, appName string) ([]string, error)
+	AppLogs(appName string, q app.LogQuery) (*app.LogsPage, error)
+	FollowAppLogs(appName string, q app.LogQuery, timeout time.Duration) (*app.LogsPage, error)
 
 	TransportTypes() ([]string, error)
 	Transports(types []string, pks []cipher.PubKey, logs bool) ([]*TransportSummary, error)
@@ -50,6 +58,12 @@ type RPCClient interface {
 	AddTransport(remote cipher.PubKey, tpType string, public bool, timeout time.Duration) (*TransportSummary, error)
 	RemoveTransport(tid uuid.UUID) error
 
+	STCPTable() ([]STCPEntry, error)
+	AddSTCPEntry(pk cipher.PubKey, addr string) error
+	RemoveSTCPEntry(pk cipher.PubKey) error
+
+	TrustedVisors() ([]transport.DefaultVisorState, error)
+
 	DiscoverTransportsByPK(pk cipher.PubKey) ([]*transport.EntryWithStatus, error)
 	DiscoverTransportByID(id uuid.UUID) (*transport.EntryWithStatus, error)
 
@@ -129,14 +143,39 @@ func (rc *rpcClient) SetAutoStart(appName string, autostart bool) error {
 	}, &struct{}{})
 }
 
-// SetSocksPassword calls SetSocksPassword.
-func (rc *rpcClient) SetSocksPassword(password string) error {
-	return rc.Call("SetSocksPassword", &password, &struct{}{})
+// GetAppSettings calls GetAppSettings.
+func (rc *rpcClient) GetAppSettings(appName string) ([]AppSettingValue, error) {
+	var settings []AppSettingValue
+	err := rc.Call("GetAppSettings", &appName, &settings)
+	return settings, err
+}
+
+// SetAppSettings calls SetAppSettings.
+func (rc *rpcClient) SetAppSettings(appName string, settings map[string]string, restart bool) error {
+	return rc.Call("SetAppSettings", &SetAppSettingsIn{
+		AppName:  appName,
+		Settings: settings,
+		Restart:  restart,
+	}, &struct{}{})
+}
+
+// AddApp calls AddApp.
+func (rc *rpcClient) AddApp(conf AppConfig) error {
+	return rc.Call("AddApp", &conf, &struct{}{})
 }
 
-// SetSocksClientPK calls SetSocksClientPK.
-func (rc *rpcClient) SetSocksClientPK(pk cipher.PubKey) error {
-	return rc.Call("SetSocksClientPK", &pk, &struct{}{})
+// RemoveApp calls RemoveApp.
+func (rc *rpcClient) RemoveApp(appName string) error {
+	return rc.Call("RemoveApp", &appName, &struct{}{})
+}
+
+// UploadApp calls UploadApp.
+func (rc *rpcClient) UploadApp(appName string, binary []byte, checksum string) error {
+	return rc.Call("UploadApp", &UploadAppIn{
+		AppName:  appName,
+		Binary:   binary,
+		Checksum: checksum,
+	}, &struct{}{})
 }
 
 // LogsSince calls LogsSince
@@ -154,6 +193,20 @@ func (rc *rpcClient) LogsSince(timestamp time.Time, appName string) ([]string, e
 	return res, nil
 }
 
+// AppLogs calls AppLogs.
+func (rc *rpcClient) AppLogs(appName string, q app.LogQuery) (*app.LogsPage, error) {
+	page := &app.LogsPage{}
+	err := rc.Call("AppLogs", &AppLogsIn{AppName: appName, Query: q}, page)
+	return page, err
+}
+
+// FollowAppLogs calls FollowAppLogs.
+func (rc *rpcClient) FollowAppLogs(appName string, q app.LogQuery, timeout time.Duration) (*app.LogsPage, error) {
+	page := &app.LogsPage{}
+	err := rc.Call("FollowAppLogs", &AppLogsIn{AppName: appName, Query: q, Timeout: timeout}, page)
+	return page, err
+}
+
 // TransportTypes calls TransportTypes.
 func (rc *rpcClient) TransportTypes() ([]string, error) {
 	var types []string
@@ -196,6 +249,30 @@ func (rc *rpcClient) RemoveTransport(tid uuid.UUID) error {
 	return rc.Call("RemoveTransport", &tid, &struct{}{})
 }
 
+// STCPTable calls STCPTable.
+func (rc *rpcClient) STCPTable() ([]STCPEntry, error) {
+	entries := make([]STCPEntry, 0)
+	err := rc.Call("STCPTable", &struct{}{}, &entries)
+	return entries, err
+}
+
+// AddSTCPEntry calls AddSTCPEntry.
+func (rc *rpcClient) AddSTCPEntry(pk cipher.PubKey, addr string) error {
+	return rc.Call("AddSTCPEntry", &STCPEntry{PK: pk, Addr: addr}, &struct{}{})
+}
+
+// RemoveSTCPEntry calls RemoveSTCPEntry.
+func (rc *rpcClient) RemoveSTCPEntry(pk cipher.PubKey) error {
+	return rc.Call("RemoveSTCPEntry", &pk, &struct{}{})
+}
+
+// TrustedVisors calls TrustedVisors.
+func (rc *rpcClient) TrustedVisors() ([]transport.DefaultVisorState, error) {
+	states := make([]transport.DefaultVisorState, 0)
+	err := rc.Call("TrustedVisors", &struct{}{}, &states)
+	return states, err
+}
+
 func (rc *rpcClient) DiscoverTransportsByPK(pk cipher.PubKey) ([]*transport.EntryWithStatus, error) {
 	entries := make([]*transport.EntryWithStatus, 0)
 	err := rc.Call("DiscoverTransportsByPK", &pk, &entries)
@@ -278,6 +355,7 @@ type mockRPCClient struct {
 	startedAt time.Time
 	s         *Summary
 	tpTypes   []string
+	stcpTable []STCPEntry
 	rt        routing.Table
 	appls     app.LogStore
 	sync.RWMutex
@@ -450,41 +528,129 @@ func (mc *mockRPCClient) SetAutoStart(appName string, autostart bool) error {
 	})
 }
 
-// SetSocksPassword implements RPCClient.
-func (mc *mockRPCClient) SetSocksPassword(string) error {
+// GetAppSettings implements RPCClient.
+func (mc *mockRPCClient) GetAppSettings(appName string) ([]AppSettingValue, error) {
+	var settings []AppSettingValue
+	err := mc.do(false, func() error {
+		manifest, err := mc.appManifest(appName)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range manifest.Settings {
+			settings = append(settings, AppSettingValue{AppSetting: s, Value: s.Default})
+		}
+
+		return nil
+	})
+
+	return settings, err
+}
+
+// SetAppSettings implements RPCClient.
+func (mc *mockRPCClient) SetAppSettings(appName string, settings map[string]string, _ bool) error {
 	return mc.do(true, func() error {
-		const socksName = "skysocks"
+		manifest, err := mc.appManifest(appName)
+		if err != nil {
+			return err
+		}
 
-		for i := range mc.s.Apps {
-			if mc.s.Apps[i].Name == socksName {
-				return nil
+		for name, v := range settings {
+			s, ok := manifest.setting(name)
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownAppSetting, name)
+			}
+
+			if err := s.validateValue(v); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidAppSetting, name, err)
 			}
 		}
 
-		return fmt.Errorf("app of name '%s' does not exist", socksName)
+		return nil
 	})
 }
 
-// SetSocksClientPK implements RPCClient.
-func (mc *mockRPCClient) SetSocksClientPK(cipher.PubKey) error {
+func (mc *mockRPCClient) appManifest(appName string) (AppManifest, error) {
+	for _, a := range mc.s.Apps {
+		if a.Name == appName {
+			manifest, ok := builtinAppManifests[appName]
+			if !ok {
+				return AppManifest{}, ErrNoAppSettings
+			}
+
+			return manifest, nil
+		}
+	}
+
+	return AppManifest{}, fmt.Errorf("app of name '%s' does not exist", appName)
+}
+
+// AddApp implements RPCClient.
+func (mc *mockRPCClient) AddApp(conf AppConfig) error {
+	if err := validateAppName(conf.App); err != nil {
+		return err
+	}
+
 	return mc.do(true, func() error {
-		const socksName = "skysocks-client"
+		for _, a := range mc.s.Apps {
+			if a.Name == conf.App {
+				return ErrAppExists
+			}
+
+			if a.Port == conf.Port {
+				return fmt.Errorf("%w: port %d is used by %s", ErrAppPortInUse, conf.Port, a.Name)
+			}
+		}
 
-		for i := range mc.s.Apps {
-			if mc.s.Apps[i].Name == socksName {
+		mc.s.Apps = append(mc.s.Apps, &AppState{Name: conf.App, AutoStart: conf.AutoStart, Port: conf.Port})
+
+		return nil
+	})
+}
+
+// RemoveApp implements RPCClient.
+func (mc *mockRPCClient) RemoveApp(appName string) error {
+	return mc.do(true, func() error {
+		for i, a := range mc.s.Apps {
+			if a.Name == appName {
+				mc.s.Apps = append(mc.s.Apps[:i], mc.s.Apps[i+1:]...)
 				return nil
 			}
 		}
 
-		return fmt.Errorf("app of name '%s' does not exist", socksName)
+		return fmt.Errorf("app of name '%s' does not exist", appName)
 	})
 }
 
+// UploadApp implements RPCClient.
+func (*mockRPCClient) UploadApp(appName string, binary []byte, checksum string) error {
+	if err := validateAppName(appName); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(binary)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
 // LogsSince implements RPCClient. Manually set (*mockRPPClient).appls before calling this function
 func (mc *mockRPCClient) LogsSince(timestamp time.Time, _ string) ([]string, error) {
 	return mc.appls.LogsSince(timestamp)
 }
 
+// AppLogs implements RPCClient. Manually set (*mockRPPClient).appls before calling this function
+func (mc *mockRPCClient) AppLogs(_ string, q app.LogQuery) (*app.LogsPage, error) {
+	return mc.appls.Logs(q)
+}
+
+// FollowAppLogs implements RPCClient. Manually set (*mockRPPClient).appls before calling this function
+func (mc *mockRPCClient) FollowAppLogs(_ string, q app.LogQuery, _ time.Duration) (*app.LogsPage, error) {
+	return mc.appls.Logs(q)
+}
+
 // TransportTypes implements RPCClient.
 func (mc *mockRPCClient) TransportTypes() ([]string, error) {
 	return mc.tpTypes, nil
@@ -569,6 +735,49 @@ func (mc *mockRPCClient) RemoveTransport(tid uuid.UUID) error {
 	})
 }
 
+// STCPTable implements RPCClient.
+func (mc *mockRPCClient) STCPTable() ([]STCPEntry, error) {
+	var entries []STCPEntry
+	err := mc.do(false, func() error {
+		entries = append(entries, mc.stcpTable...)
+		return nil
+	})
+	return entries, err
+}
+
+// AddSTCPEntry implements RPCClient.
+func (mc *mockRPCClient) AddSTCPEntry(pk cipher.PubKey, addr string) error {
+	return mc.do(true, func() error {
+		for i, e := range mc.stcpTable {
+			if e.PK == pk {
+				mc.stcpTable[i].Addr = addr
+				return nil
+			}
+		}
+		mc.stcpTable = append(mc.stcpTable, STCPEntry{PK: pk, Addr: addr})
+		return nil
+	})
+}
+
+// RemoveSTCPEntry implements RPCClient.
+func (mc *mockRPCClient) RemoveSTCPEntry(pk cipher.PubKey) error {
+	return mc.do(true, func() error {
+		for i, e := range mc.stcpTable {
+			if e.PK == pk {
+				mc.stcpTable = append(mc.stcpTable[:i], mc.stcpTable[i+1:]...)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// TrustedVisors implements RPCClient.
+// The mock has no trusted visors.
+func (mc *mockRPCClient) TrustedVisors() ([]transport.DefaultVisorState, error) {
+	return []transport.DefaultVisorState{}, nil
+}
+
 func (mc *mockRPCClient) DiscoverTransportsByPK(cipher.PubKey) ([]*transport.EntryWithStatus, error) {
 	return nil, ErrNotImplemented
 }
@@ -1,6 +1,7 @@
 package visor
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -21,6 +22,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/util/pathutil"
 )
 
@@ -96,6 +98,8 @@ func TestListApps(t *testing.T) {
 	pm := &appserver.MockProcManager{}
 	pm.On("Exists", apps["foo"].App).Return(false)
 	pm.On("Exists", apps["bar"].App).Return(true)
+	pm.On("Usage", apps["bar"].App).Return(appserver.ProcUsage{MemoryRSS: 1 << 20, CPUTime: 1500 * time.Millisecond}, nil)
+	pm.On("Health", apps["bar"].App).Return(appserver.Health{Status: appserver.HealthStarting, Message: "syncing"}, nil)
 
 	n := Visor{
 		appsConf:    apps,
@@ -125,6 +129,54 @@ func TestListApps(t *testing.T) {
 	assert.True(t, app2.AutoStart)
 	assert.Equal(t, routing.Port(11), app2.Port)
 	assert.Equal(t, AppStatusRunning, app2.Status)
+	assert.Equal(t, uint64(1<<20), app2.MemoryRSS)
+	assert.Equal(t, 1.5, app2.CPUTime)
+	assert.Equal(t, appserver.HealthStarting, app2.Health)
+	assert.Equal(t, "syncing", app2.HealthMessage)
+}
+
+func TestSTCPTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stcp-table")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	confPath := filepath.Join(dir, "config.json")
+	c := &Config{
+		Path:    &confPath,
+		log:     logging.MustGetLogger("config"),
+		KeyPair: NewKeyPair(),
+		STCP: &snet.STCPConfig{
+			PubKeyTable: map[cipher.PubKey]string{pk1: "127.0.0.1:7001"},
+		},
+	}
+	n := snet.New(snet.Config{PubKey: c.KeyPair.PubKey, SecKey: c.KeyPair.SecKey, STCP: c.STCP})
+	defer func() { require.NoError(t, n.Close()) }()
+
+	rpc := &RPC{visor: &Visor{conf: c, n: n, logger: logging.MustGetLogger("visor")}, log: logrus.New()}
+
+	require.NoError(t, rpc.AddSTCPEntry(&STCPEntry{PK: pk2, Addr: "127.0.0.1:7002"}, nil))
+	require.Error(t, rpc.AddSTCPEntry(&STCPEntry{PK: pk2, Addr: "invalid"}, nil))
+	require.NoError(t, rpc.RemoveSTCPEntry(&pk1, nil))
+	require.Equal(t, ErrNotFound, rpc.RemoveSTCPEntry(&pk1, nil))
+
+	var entries []STCPEntry
+	require.NoError(t, rpc.STCPTable(nil, &entries))
+	assert.Equal(t, []STCPEntry{{PK: pk2, Addr: "127.0.0.1:7002"}}, entries)
+
+	addr, ok := n.STCPTable().Addr(pk2)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:7002", addr)
+
+	// Changes are persisted to the config file.
+	b, err := ioutil.ReadFile(confPath) // nolint:gosec
+	require.NoError(t, err)
+
+	var saved Config
+	require.NoError(t, json.Unmarshal(b, &saved))
+	assert.Equal(t, map[cipher.PubKey]string{pk2: "127.0.0.1:7002"}, saved.STCP.PubKeyTable)
 }
 
 func TestStartStopApp(t *testing.T) {
@@ -138,6 +190,7 @@ func TestStartStopApp(t *testing.T) {
 
 	defer func() {
 		require.NoError(t, os.RemoveAll("skychat"))
+		require.NoError(t, os.RemoveAll("skychat"+appLogsSuffix))
 	}()
 
 	appCfg := []AppConfig{
@@ -168,6 +221,7 @@ func TestStartStopApp(t *testing.T) {
 		conf:     &visorCfg,
 	}
 
+	visor.localPath = visor.dir()
 	require.NoError(t, pathutil.EnsureDir(visor.dir()))
 
 	defer func() {
@@ -178,10 +232,10 @@ func TestStartStopApp(t *testing.T) {
 		Name:       app,
 		ServerAddr: appcommon.DefaultServerAddr,
 		VisorPK:    visorCfg.Keys().PubKey.Hex(),
-		WorkDir:    filepath.Join("", app),
+		WorkDir:    filepath.Join(visor.localPath, app),
 	}
 
-	appArgs1 := append([]string{filepath.Join(visor.dir(), app)}, apps["foo"].Args...)
+	appArgs1 := apps["foo"].Args
 	appPID1 := appcommon.ProcID(10)
 
 	pm := &appserver.MockProcManager{}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
@@ -22,6 +23,7 @@ import (
 	"github.com/SkycoinProject/dmsg/dmsgpty"
 	"github.com/SkycoinProject/skycoin/src/util/logging"
 
+	"github.com/SkycoinProject/skywire-mainnet/pkg/app"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appcommon"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appnet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/app/appserver"
@@ -29,6 +31,7 @@ import (
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routefinder/rfclient"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/router"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/routing"
+	"github.com/SkycoinProject/skywire-mainnet/pkg/setup/setupclient"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/skyenv"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/snet"
 	"github.com/SkycoinProject/skywire-mainnet/pkg/transport"
@@ -50,6 +53,9 @@ const (
 var (
 	// ErrUnknownApp represents lookup error for App related calls.
 	ErrUnknownApp = errors.New("unknown app")
+
+	// ErrSTCPDisabled is returned on attempt to modify the stcp pk table when stcp is not configured.
+	ErrSTCPDisabled = errors.New("stcp is not enabled")
 )
 
 const (
@@ -58,7 +64,7 @@ const (
 	shortHashLen             = 6
 )
 
-var reservedPorts = map[routing.Port]string{0: "router", 1: "skychat", 3: "skysocks"}
+var reservedPorts = map[routing.Port]string{0: "router", 1: "skychat", 3: "skysocks", 5: "skyforward"}
 
 // AppState defines state parameters for a registered App.
 type AppState struct {
@@ -66,6 +72,12 @@ type AppState struct {
 	AutoStart bool         `json:"autostart"`
 	Port      routing.Port `json:"port"`
 	Status    AppStatus    `json:"status"`
+	MemoryRSS uint64       `json:"memory_rss,omitempty"` // in bytes
+	CPUTime   float64      `json:"cpu_time,omitempty"`   // in seconds
+
+	// Health is reported by apps which support it, it is empty otherwise.
+	Health        appserver.HealthStatus `json:"health,omitempty"`
+	HealthMessage string                 `json:"health_message,omitempty"`
 }
 
 // Visor provides messaging runtime for Apps by setting up all
@@ -77,12 +89,18 @@ type Visor struct {
 	tm     *transport.Manager
 	pty    *dmsgpty.Host
 
+	setupPool *setupclient.Pool // health of setup nodes, as observed by the router
+
 	Logger *logging.MasterLogger
 	logger *logging.Logger
 
 	appsPath  string
 	localPath string
 	appsConf  map[string]AppConfig
+	appsMu    sync.RWMutex // guards appsConf and conf.Apps
+
+	appLogs   map[string]app.LogStore
+	appLogsMu sync.Mutex
 
 	startedAt  time.Time
 	restartCtx *restart.Context
@@ -114,6 +132,9 @@ func NewVisor(cfg *Config, logger *logging.MasterLogger, restartCtx *restart.Con
 
 	pk := cfg.Keys().PubKey
 	sk := cfg.Keys().SecKey
+	if sk.Null() {
+		return nil, ErrKeyStoreLocked
+	}
 
 	logger.WithField("PK", pk).Infof("Starting visor")
 
@@ -131,6 +152,7 @@ func NewVisor(cfg *Config, logger *logging.MasterLogger, restartCtx *restart.Con
 		SecKey: sk,
 		Dmsg:   cfg.DmsgConfig(),
 		STCP:   cfg.STCP,
+		SUDP:   cfg.SUDP,
 	})
 	if err := visor.n.Init(ctx); err != nil {
 		return nil, fmt.Errorf("failed to init network: %v", err)
@@ -156,12 +178,22 @@ func NewVisor(cfg *Config, logger *logging.MasterLogger, restartCtx *restart.Con
 		return nil, fmt.Errorf("invalid TransportLogStore: %s", err)
 	}
 
+	rConf := cfg.RoutingConfig()
+
+	rfClient, err := rfclient.NewHTTPFailover(rConf.RouteFinderAddrs(), time.Duration(rConf.RouteFinderTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("invalid route finder config: %s", err)
+	}
+
+	rfCache := rfclient.NewCached(rfClient, time.Duration(rConf.RouteFinderCacheTTL))
+
 	tmConfig := &transport.ManagerConfig{
 		PubKey:          pk,
 		SecKey:          sk,
 		DefaultVisors:   cfg.TrustedVisors,
 		DiscoveryClient: trDiscovery,
 		LogStore:        logStore,
+		OnTransportDown: rfCache.InvalidateTransport,
 	}
 
 	visor.tm, err = transport.NewManager(visor.n, tmConfig)
@@ -169,13 +201,16 @@ func NewVisor(cfg *Config, logger *logging.MasterLogger, restartCtx *restart.Con
 		return nil, fmt.Errorf("transport manager: %s", err)
 	}
 
+	visor.setupPool = setupclient.NewPool()
+
 	rConfig := &router.Config{
 		Logger:           visor.Logger.PackageLogger("router"),
 		PubKey:           pk,
 		SecKey:           sk,
 		TransportManager: visor.tm,
-		RouteFinder:      rfclient.NewHTTP(cfg.RoutingConfig().RouteFinder, time.Duration(cfg.RoutingConfig().RouteFinderTimeout)),
-		SetupNodes:       cfg.RoutingConfig().SetupNodes,
+		RouteFinder:      rfCache,
+		RouteGroupDialer: setupclient.NewSetupNodeDialerWithPool(visor.setupPool),
+		SetupNodes:       rConf.SetupNodes,
 	}
 
 	r, err := router.New(visor.n, rConfig)
@@ -225,6 +260,14 @@ func NewVisor(cfg *Config, logger *logging.MasterLogger, restartCtx *restart.Con
 		}
 	}()
 
+	if addr := visor.conf.AppServerJSONAddr; addr != "" {
+		go func() {
+			if err := visor.appRPCServer.ListenAndServeJSON(addr); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+				visor.logger.WithError(err).Error("Serve app_rpc JSON stopped.")
+			}
+		}()
+	}
+
 	visor.procManager = appserver.NewProcManager(logging.MustGetLogger("proc_manager"), visor.appRPCServer)
 
 	visor.updater = updater.New(visor.logger, visor.restartCtx, visor.appsPath)
@@ -253,6 +296,8 @@ func (visor *Visor) Start() error {
 		return err
 	}
 
+	go visor.monitorAppsHealth(ctx, appHealthCheckInterval)
+
 	if err := visor.startDmsgPty(ctx); err != nil {
 		return err
 	}
@@ -273,6 +318,9 @@ func (visor *Visor) startApps() error {
 		return err
 	}
 
+	visor.appsMu.RLock()
+	defer visor.appsMu.RUnlock()
+
 	for _, ac := range visor.appsConf {
 		if !ac.AutoStart {
 			continue
@@ -487,20 +535,22 @@ func (visor *Visor) Close() (err error) {
 		visor.logger.WithError(err).Error("RPC server closed with error.")
 	}
 
+	visor.closeAppLogStores()
+
 	return err
 }
 
 // App returns a single app state of given name.
 func (visor *Visor) App(name string) (*AppState, bool) {
+	visor.appsMu.RLock()
 	app, ok := visor.appsConf[name]
+	visor.appsMu.RUnlock()
+
 	if !ok {
 		return nil, false
 	}
-	state := &AppState{app.App, app.AutoStart, app.Port, AppStatusStopped}
-	if visor.procManager.Exists(app.App) {
-		state.Status = AppStatusRunning
-	}
-	return state, true
+
+	return visor.appState(app), true
 }
 
 // Apps returns list of AppStates for all registered apps.
@@ -508,40 +558,72 @@ func (visor *Visor) Apps() []*AppState {
 	// TODO: move app states to the app module
 	res := make([]*AppState, 0)
 
+	visor.appsMu.RLock()
+	defer visor.appsMu.RUnlock()
+
 	for _, app := range visor.appsConf {
-		state := &AppState{app.App, app.AutoStart, app.Port, AppStatusStopped}
+		res = append(res, visor.appState(app))
+	}
 
-		if visor.procManager.Exists(app.App) {
-			state.Status = AppStatusRunning
-		}
+	return res
+}
+
+// appState returns state of app, including its resource usage if it is running.
+func (visor *Visor) appState(app AppConfig) *AppState {
+	state := &AppState{Name: app.App, AutoStart: app.AutoStart, Port: app.Port, Status: AppStatusStopped}
 
-		res = append(res, state)
+	if !visor.procManager.Exists(app.App) {
+		return state
 	}
 
-	return res
+	state.Status = AppStatusRunning
+
+	// usage is not available on all platforms, and the app may have just exited
+	if usage, err := visor.procManager.Usage(app.App); err == nil {
+		state.MemoryRSS = usage.MemoryRSS
+		state.CPUTime = usage.CPUTime.Seconds()
+	}
+
+	if health, err := visor.procManager.Health(app.App); err == nil {
+		state.Health = health.Status
+		state.HealthMessage = health.Message
+	}
+
+	return state
 }
 
 // StartApp starts registered App.
 func (visor *Visor) StartApp(appName string) error {
-	for _, app := range visor.appsConf {
-		if app.App == appName {
-			startCh := make(chan struct{})
-
-			go func(app AppConfig) {
-				if err := visor.SpawnApp(&app, startCh); err != nil {
-					visor.logger.
-						WithError(err).
-						WithField("app_name", appName).
-						Warn("App stopped.")
-				}
-			}(app)
-
-			<-startCh
-			return nil
-		}
+	visor.appsMu.RLock()
+	app, ok := visor.appsConf[appName]
+	visor.appsMu.RUnlock()
+
+	if !ok {
+		return ErrUnknownApp
 	}
 
-	return ErrUnknownApp
+	startCh := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	go func() {
+		err := visor.SpawnApp(&app, startCh)
+		if err != nil {
+			visor.logger.
+				WithError(err).
+				WithField("app_name", appName).
+				Warn("App stopped.")
+		}
+
+		errCh <- err
+	}()
+
+	// Apps added at runtime may fail to start, e.g. if their binary is not uploaded yet.
+	select {
+	case <-startCh:
+		return nil
+	case err := <-errCh:
+		return err
+	}
 }
 
 // SpawnApp configures and starts new App.
@@ -555,18 +637,45 @@ func (visor *Visor) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err er
 		return fmt.Errorf("can't bind to reserved port %d", config.Port)
 	}
 
+	if err := validateAppProtocol(*config, visor.conf.AppServerJSONAddr); err != nil {
+		return err
+	}
+
+	serverAddr := visor.conf.AppServerAddr
+	if config.Protocol == AppProtocolJSONRPC {
+		serverAddr = visor.conf.AppServerJSONAddr
+	}
+
 	appCfg := appcommon.Config{
 		Name:       config.App,
-		ServerAddr: visor.conf.AppServerAddr,
+		ServerAddr: serverAddr,
 		VisorPK:    visor.conf.Keys().PubKey.Hex(),
 		BinaryDir:  visor.appsPath,
 		WorkDir:    filepath.Join(visor.localPath, config.App),
+		Credential: config.RunAs,
+		UnixSocket: config.UnixSocket,
+	}
+
+	if config.Limits != nil {
+		appCfg.Limits = *config.Limits
 	}
 
 	if _, err := ensureDir(appCfg.WorkDir); err != nil {
 		return err
 	}
 
+	// app running as another user should be able to write to its work dir
+	if config.RunAs != nil {
+		if err := os.Chown(appCfg.WorkDir, int(config.RunAs.UID), int(config.RunAs.GID)); err != nil {
+			return fmt.Errorf("failed to chown %s: %v", appCfg.WorkDir, err)
+		}
+	}
+
+	logStore, err := visor.appLogStore(config.App)
+	if err != nil {
+		return err
+	}
+
 	// TODO: make PackageLogger return *RuleEntry. FieldLogger doesn't expose Writer.
 	logger := visor.logger.WithField("_module", config.App).Writer()
 	errLogger := visor.logger.WithField("_module", config.App+"[ERROR]").Writer()
@@ -582,9 +691,10 @@ func (visor *Visor) SpawnApp(config *AppConfig, startCh chan<- struct{}) (err er
 	}()
 
 	appLogger := logging.MustGetLogger(fmt.Sprintf("app_%s", config.App))
-	appArgs := append([]string{filepath.Join(visor.dir(), config.App)}, config.Args...)
+	stdout := io.MultiWriter(logger, logStore)
+	stderr := io.MultiWriter(errLogger, logStore)
 
-	pid, err := visor.procManager.Start(appLogger, appCfg, appArgs, logger, errLogger)
+	pid, err := visor.procManager.Start(appLogger, appCfg, config.Args, stdout, stderr)
 	if err != nil {
 		return fmt.Errorf("error running app %s: %v", config.App, err)
 	}
@@ -689,6 +799,9 @@ func (visor *Visor) UpdateAvailable() (*updater.Version, error) {
 }
 
 func (visor *Visor) setAutoStart(appName string, autoStart bool) error {
+	visor.appsMu.Lock()
+	defer visor.appsMu.Unlock()
+
 	appConf, ok := visor.appsConf[appName]
 	if !ok {
 		return ErrUnknownApp
@@ -702,50 +815,6 @@ func (visor *Visor) setAutoStart(appName string, autoStart bool) error {
 	return visor.updateAppAutoStart(appName, autoStart)
 }
 
-func (visor *Visor) setSocksPassword(password string) error {
-	visor.logger.Infof("Changing skysocks password to %q", password)
-
-	const (
-		socksName       = "skysocks"
-		passcodeArgName = "-passcode"
-	)
-
-	if err := visor.updateAppArg(socksName, passcodeArgName, password); err != nil {
-		return err
-	}
-
-	if visor.procManager.Exists(socksName) {
-		visor.logger.Infof("Updated %v password, restarting it", socksName)
-		return visor.RestartApp(socksName)
-	}
-
-	visor.logger.Infof("Updated %v password", socksName)
-
-	return nil
-}
-
-func (visor *Visor) setSocksClientPK(pk cipher.PubKey) error {
-	visor.logger.Infof("Changing skysocks-client PK to %q", pk)
-
-	const (
-		socksClientName = "skysocks-client"
-		pkArgName       = "-srv"
-	)
-
-	if err := visor.updateAppArg(socksClientName, pkArgName, pk.String()); err != nil {
-		return err
-	}
-
-	if visor.procManager.Exists(socksClientName) {
-		visor.logger.Infof("Updated %v PK, restarting it", socksClientName)
-		return visor.RestartApp(socksClientName)
-	}
-
-	visor.logger.Infof("Updated %v PK", socksClientName)
-
-	return nil
-}
-
 func (visor *Visor) updateAppAutoStart(appName string, autoStart bool) error {
 	changed := false
 
@@ -769,38 +838,76 @@ func (visor *Visor) updateAppAutoStart(appName string, autoStart bool) error {
 	return visor.conf.flush()
 }
 
-func (visor *Visor) updateAppArg(appName, argName, value string) error {
-	configChanged := true
+// updateAppArgs replaces the arguments of app 'appName' with those returned by 'update', and saves them to config.
+func (visor *Visor) updateAppArgs(appName string, update func(args []string) []string) error {
+	visor.appsMu.Lock()
+	defer visor.appsMu.Unlock()
+
+	changed := false
 
 	for i := range visor.conf.Apps {
-		argChanged := false
-		if visor.conf.Apps[i].App == appName {
-			configChanged = true
-
-			for j := range visor.conf.Apps[i].Args {
-				if visor.conf.Apps[i].Args[j] == argName && j+1 < len(visor.conf.Apps[i].Args) {
-					visor.conf.Apps[i].Args[j+1] = value
-					argChanged = true
-					break
-				}
-			}
+		if visor.conf.Apps[i].App != appName {
+			continue
+		}
 
-			if !argChanged {
-				visor.conf.Apps[i].Args = append(visor.conf.Apps[i].Args, argName, value)
-			}
+		visor.conf.Apps[i].Args = update(visor.conf.Apps[i].Args)
 
-			if v, ok := visor.appsConf[appName]; ok {
-				v.Args = visor.conf.Apps[i].Args
-				visor.appsConf[appName] = v
-			}
+		if v, ok := visor.appsConf[appName]; ok {
+			v.Args = visor.conf.Apps[i].Args
+			visor.appsConf[appName] = v
 		}
+
+		changed = true
 	}
 
-	if configChanged {
-		return visor.conf.flush()
+	if !changed {
+		return nil
 	}
 
-	return nil
+	return visor.conf.flush()
+}
+
+func (visor *Visor) stcpTable() (map[cipher.PubKey]string, error) {
+	table := visor.n.STCPTable()
+	if table == nil {
+		return nil, ErrSTCPDisabled
+	}
+
+	return table.Entries(), nil
+}
+
+func (visor *Visor) addSTCPEntry(pk cipher.PubKey, addr string) error {
+	table := visor.n.STCPTable()
+	if table == nil {
+		return ErrSTCPDisabled
+	}
+
+	if err := table.Add(pk, addr); err != nil {
+		return err
+	}
+
+	visor.logger.Infof("Saving stcp pk table entry %v: %v to config", pk, addr)
+
+	return visor.conf.updateSTCPTable(func(t map[cipher.PubKey]string) {
+		t[pk] = addr
+	})
+}
+
+func (visor *Visor) removeSTCPEntry(pk cipher.PubKey) error {
+	table := visor.n.STCPTable()
+	if table == nil {
+		return ErrSTCPDisabled
+	}
+
+	if ok := table.Remove(pk); !ok {
+		return ErrNotFound
+	}
+
+	visor.logger.Infof("Removing stcp pk table entry %v from config", pk)
+
+	return visor.conf.updateSTCPTable(func(t map[cipher.PubKey]string) {
+		delete(t, pk)
+	})
 }
 
 // UnlinkSocketFiles removes unix socketFiles from file system
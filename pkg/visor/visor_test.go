@@ -101,6 +101,7 @@ func TestVisorStartClose(t *testing.T) {
 
 	defer func() {
 		require.NoError(t, os.RemoveAll("skychat"))
+		require.NoError(t, os.RemoveAll("skychat"+appLogsSuffix))
 	}()
 
 	visorCfg := Config{
@@ -125,7 +126,7 @@ func TestVisorStartClose(t *testing.T) {
 		VisorPK:    visorCfg.Keys().PubKey.Hex(),
 		WorkDir:    filepath.Join("", apps["skychat"].App),
 	}
-	appArgs1 := append([]string{filepath.Join(visor.dir(), apps["skychat"].App)}, apps["skychat"].Args...)
+	appArgs1 := apps["skychat"].Args
 	appPID1 := appcommon.ProcID(10)
 	pm.On("Start", mock.Anything, appCfg1, appArgs1, mock.Anything, mock.Anything).
 		Return(appPID1, testhelpers.NoErr)
@@ -140,7 +141,7 @@ func TestVisorStartClose(t *testing.T) {
 
 	var netConf snet.Config
 
-	network := snet.NewRaw(netConf, dmsgC, nil)
+	network := snet.NewRaw(netConf, dmsgC, nil, nil)
 	tmConf := &transport.ManagerConfig{
 		PubKey:          cipher.PubKey{},
 		DiscoveryClient: transport.NewDiscoveryMock(),
@@ -191,6 +192,7 @@ func TestVisorSpawnApp(t *testing.T) {
 		conf:     &visorCfg,
 	}
 
+	visor.localPath = visor.dir()
 	require.NoError(t, pathutil.EnsureDir(visor.dir()))
 
 	defer func() {
@@ -201,10 +203,10 @@ func TestVisorSpawnApp(t *testing.T) {
 		Name:       app.App,
 		ServerAddr: appcommon.DefaultServerAddr,
 		VisorPK:    visorCfg.Keys().PubKey.Hex(),
-		WorkDir:    filepath.Join("", app.App),
+		WorkDir:    filepath.Join(visor.localPath, app.App),
 	}
 
-	appArgs := append([]string{filepath.Join(visor.dir(), app.App)}, app.Args...)
+	appArgs := app.Args
 	appPID := appcommon.ProcID(10)
 
 	pm := &appserver.MockProcManager{}
@@ -244,6 +246,7 @@ func TestVisorSpawnAppValidations(t *testing.T) {
 		conf:   c,
 	}
 
+	visor.localPath = visor.dir()
 	require.NoError(t, pathutil.EnsureDir(visor.dir()))
 
 	defer func() {
@@ -260,10 +263,10 @@ func TestVisorSpawnAppValidations(t *testing.T) {
 			Name:       app.App,
 			ServerAddr: appcommon.DefaultServerAddr,
 			VisorPK:    c.Keys().PubKey.Hex(),
-			WorkDir:    filepath.Join("", app.App),
+			WorkDir:    filepath.Join(visor.localPath, app.App),
 		}
 
-		appArgs := append([]string{filepath.Join(visor.dir(), app.App)}, app.Args...)
+		appArgs := app.Args
 		appPID := appcommon.ProcID(10)
 
 		pm := &appserver.MockProcManager{}
@@ -299,9 +302,9 @@ func TestVisorSpawnAppValidations(t *testing.T) {
 			Name:       app.App,
 			ServerAddr: appcommon.DefaultServerAddr,
 			VisorPK:    c.Keys().PubKey.Hex(),
-			WorkDir:    filepath.Join("", app.App),
+			WorkDir:    filepath.Join(visor.localPath, app.App),
 		}
-		appArgs := append([]string{filepath.Join(visor.dir(), app.App)}, app.Args...)
+		appArgs := app.Args
 
 		appPID := appcommon.ProcID(10)
 		pm.On("Start", mock.Anything, appCfg, appArgs, mock.Anything, mock.Anything).
@@ -23,6 +23,8 @@ export interface Application {
   autostart: boolean;
   port: number;
   status: number;
+  health?: string;
+  health_message?: string;
 }
 
 export interface Transport {